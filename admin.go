@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database/psql"
+)
+
+const grantAdminUsage = `usage: chat grant-admin <email> [config flags]
+
+gives the admin role to the registered user of the email, to bootstrap the first admin`
+
+// runGrantAdmin runs the grant-admin subcommand.
+//  @param args []string: arguments after "grant-admin", the user email and then the config flags.
+//  @return err error: invalid arguments, config, unknown user or database error.
+func runGrantAdmin(args []string) (err error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		err = fmt.Errorf("invalid grant-admin arguments: a user email is required\n%s", grantAdminUsage)
+		return
+	}
+	email, args := args[0], args[1:]
+
+	conf, err := config.Load(args)
+	if err != nil {
+		return
+	}
+
+	conn := newPostgreSQLConnector(conf)
+	err = conn.Connect()
+	if err != nil {
+		return
+	}
+	return grantAdmin(conn, email)
+}
+
+// grantAdmin gives the admin role to the user of the email provided.
+func grantAdmin(conn *psql.PostgreSQLConnector, email string) (err error) {
+	usersRepo, err := psql.NewUsersRepository(conn)
+	if err != nil {
+		return
+	}
+	roleRepo, err := psql.NewRoleRepository(conn)
+	if err != nil {
+		return
+	}
+
+	user, err := usersRepo.GetUserByEmail(email)
+	if err != nil {
+		return
+	}
+
+	err = roleRepo.AssignRole(user.ID, auth.ADMIN_ROLE)
+	if err != nil {
+		return
+	}
+	log.Printf("Granted %s role to user %d %s", auth.ADMIN_ROLE, user.ID, user.Email)
+	return
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"time"
+)
+
+// AuditAction is the kind of a privileged action performed by a admin.
+type AuditAction string
+
+const (
+	AuditActionImpersonationStarted AuditAction = "impersonation_started"
+	AuditActionImpersonationStopped AuditAction = "impersonation_stopped"
+	AuditActionNicknameGranted      AuditAction = "nickname_granted"
+)
+
+// AuditEntry represents a record of the audit log, the trail of the privileged actions of the admins.
+type AuditEntry struct {
+	ID int `json:"id"`
+
+	// ActorID is the admin which performed the action.
+	ActorID int         `json:"actor_id"`
+	Action  AuditAction `json:"action"`
+
+	// TargetUserID is the user affected by the action, 0 if there is none.
+	TargetUserID int `json:"target_user_id,omitempty"`
+
+	// SessionID is the session created or affected by the action, 0 if there is none.
+	SessionID int    `json:"session_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	IP        string `json:"ip,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewAuditEntry initializes a new audit entry of a action performed now.
+//  @param actorID int: admin which performed the action.
+//  @param action AuditAction: kind of the action.
+//  @param targetUserID int: user affected by the action.
+//  @param sessionID int: session created or affected by the action.
+//  @param reason string: reason given by the admin.
+//  @param ip string: ip address of the admin.
+//  @return $1 AuditEntry: new AuditEntry instance.
+func NewAuditEntry(actorID int, action AuditAction, targetUserID, sessionID int, reason, ip string) AuditEntry {
+	return AuditEntry{
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		SessionID:    sessionID,
+		Reason:       reason,
+		IP:           ip,
+		CreatedAt:    time.Now(),
+	}
+}
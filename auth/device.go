@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// Device represents a known device which a user has logged in from.
+//  A device is identified by its device cookie, or by its user agent family and IP network
+//  when the cookie is missing.
+type Device struct {
+	ID     int
+	UserID int
+
+	// CookieHash is the SHA-256 hash of the device cookie.
+	CookieHash string
+
+	UserAgentFamily string
+	IPNetwork       string
+
+	// SessionID is the last session logged from the device.
+	SessionID int
+
+	// RevokeHash is the SHA-256 hash of the "this wasn't me" token of the device login.
+	RevokeHash string
+
+	// RevokeExpiresAt is the expiration of the "this wasn't me" token.
+	RevokeExpiresAt time.Time
+
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// Matches checks if the device is the one identified by the data provided.
+//  @param cookieHash string: hash of the device cookie of the login.
+//  @param family string: user agent family of the login.
+//  @param network string: IP network of the login.
+//  @return $1 bool: the device matches.
+func (d Device) Matches(cookieHash, family, network string) bool {
+	if cookieHash != "" && d.CookieHash == cookieHash {
+		return true
+	}
+	return d.UserAgentFamily == family && d.IPNetwork == network
+}
+
+var (
+	// browserFamilies are checked in order, as most user agents include the names of other browsers.
+	browserFamilies = []struct{ token, family string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	osFamilies = []struct{ token, family string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// UserAgentFamily gets the browser and operating system families of the user agent, like "Firefox on Linux".
+//  @param ua string: User-Agent header.
+//  @return $1 string: user agent family.
+func UserAgentFamily(ua string) string {
+	browser, os := "Other", "Other"
+	for _, b := range browserFamilies {
+		if strings.Contains(ua, b.token) {
+			browser = b.family
+			break
+		}
+	}
+	for _, o := range osFamilies {
+		if strings.Contains(ua, o.token) {
+			os = o.family
+			break
+		}
+	}
+	return browser + " on " + os
+}
+
+// IPNetwork gets the network of the IP address, /24 for IPv4 and /48 for IPv6.
+//  @param ip string: IP address.
+//  @return $1 string: network in CIDR notation, or the value provided if it isn't a IP address.
+func IPNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+
+	mask := net.CIDRMask(48, 128)
+	if v4 := parsed.To4(); v4 != nil {
+		parsed = v4
+		mask = net.CIDRMask(24, 32)
+	}
+	n := net.IPNet{IP: parsed.Mask(mask), Mask: mask}
+	return n.String()
+}
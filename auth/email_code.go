@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// EmailCode represents a numeric one-time code sent by email to login or sign up.
+type EmailCode struct {
+	ID    int
+	Email string
+
+	// Hash is the bcrypt hash of the code. The code itself is never stored.
+	Hash string
+
+	// Attempts is the number of verification attempts performed with the code.
+	Attempts int
+
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// NewEmailCode initializes a new one-time code with random digits.
+//  @param email string: email which the code is sent to.
+//  @param digits int: number of digits of the code.
+//  @param ttl time.Duration: lifetime of the code.
+//  @return code EmailCode: new EmailCode instance.
+//  @return raw string: code to be sent to the user.
+//  @return err error: random generation or hashing error.
+func NewEmailCode(email string, digits int, ttl time.Duration) (code EmailCode, raw string, err error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		err = fmt.Errorf("failed to generate email code: %s", err)
+		return
+	}
+	raw = fmt.Sprintf("%0*d", digits, n)
+
+	h, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+	if err != nil {
+		err = fmt.Errorf("failed to hash email code: %s", err)
+		return
+	}
+
+	now := time.Now()
+	code = EmailCode{
+		Email:     email,
+		Hash:      string(h),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	return
+}
+
+// Check checks if the raw code provided matches with the code.
+//  @param raw string: code provided by the user.
+//  @return $1 bool: the code matches.
+func (e EmailCode) Check(raw string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(e.Hash), []byte(raw)) == nil
+}
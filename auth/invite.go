@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"time"
+)
+
+// INVITE_PREFIX is the prefix of the invite codes, to be recognized by the users.
+const INVITE_PREFIX = "inv_"
+
+// Invite represents a code which allows to sign up when the registration is invite-only.
+type Invite struct {
+	ID int `json:"id"`
+
+	// Hash is the SHA-256 hash of the code. The code itself is never stored.
+	Hash string `json:"-"`
+
+	// CreatedBy is the user which created the invite.
+	CreatedBy int `json:"created_by"`
+
+	// MaxUses is the number of sign ups allowed by the invite, 1 for a single-use invite.
+	MaxUses int `json:"max_uses"`
+	Uses    int `json:"uses"`
+
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewInvite initializes a new invite with a random code.
+//  @param createdBy int: user which creates the invite.
+//  @param maxUses int: number of sign ups allowed by the invite.
+//  @param ttl time.Duration: lifetime of the invite.
+//  @return invite Invite: new Invite instance.
+//  @return raw string: code to be shared with the invited users.
+//  @return err error: random generation error.
+func NewInvite(createdBy, maxUses int, ttl time.Duration) (invite Invite, raw string, err error) {
+	raw, err = RandomToken(INVITE_PREFIX)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	invite = Invite{
+		Hash:      HashToken(raw),
+		CreatedBy: createdBy,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	return
+}
+
+// Valid checks if the invite is not revoked, expired nor exhausted.
+func (i Invite) Valid(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && i.Uses < i.MaxUses
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// MagicLink represents a single-use passwordless login link sent by email.
+type MagicLink struct {
+	// Hash is the SHA-256 hash of the link token id.
+	Hash string
+
+	UserID int
+	Email  string
+
+	// BrowserHash is the hash of the browser binding cookie of the browser which requested the link.
+	BrowserHash string
+
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type magicLinkClaims struct {
+	jwt.StandardClaims
+	Email       string `json:"email"`
+	BrowserHash string `json:"bh,omitempty"`
+}
+
+// NewMagicLink initializes a new magic link and its HMAC signed token.
+//  @param secret []byte: HMAC key to sign the token.
+//  @param userID int: user to login.
+//  @param email string: email which the link is sent to.
+//  @param browser string: browser binding cookie value, optional.
+//  @param ttl time.Duration: lifetime of the link.
+//  @return link MagicLink: new MagicLink instance.
+//  @return token string: signed token to be sent in the link.
+//  @return err error: random generation or signing error.
+func NewMagicLink(secret []byte, userID int, email, browser string, ttl time.Duration) (link MagicLink, token string, err error) {
+	jti, err := RandomToken("")
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	link = MagicLink{
+		Hash:      HashToken(jti),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if browser != "" {
+		link.BrowserHash = HashToken(browser)
+	}
+
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, magicLinkClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: link.ExpiresAt.Unix(),
+		},
+		Email:       email,
+		BrowserHash: link.BrowserHash,
+	}).SignedString(secret)
+	if err != nil {
+		err = fmt.Errorf("failed to sign magic link: %s", err)
+	}
+	return
+}
+
+// ParseMagicLink verifies the signature and expiration of a magic link token.
+//  It doesn't check if the link has been already used.
+//  @param secret []byte: HMAC key which signed the token.
+//  @param token string: token of the link.
+//  @return link MagicLink: link of the token.
+//  @return err error: invalid or expired token error.
+func ParseMagicLink(secret []byte, token string) (link MagicLink, err error) {
+	claims := magicLinkClaims{}
+	_, err = jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return secret, nil
+	})
+	if err != nil {
+		err = fmt.Errorf("invalid magic link: %s", err)
+		return
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || claims.Id == "" {
+		err = errors.New("invalid magic link: malformed claims")
+		return
+	}
+
+	link = MagicLink{
+		Hash:        HashToken(claims.Id),
+		UserID:      userID,
+		Email:       claims.Email,
+		BrowserHash: claims.BrowserHash,
+		ExpiresAt:   time.Unix(claims.ExpiresAt, 0),
+		CreatedAt:   time.Unix(claims.IssuedAt, 0),
+	}
+	return
+}
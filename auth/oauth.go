@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+const (
+	// OAUTH_ACCESS_TOKEN is the type of the OAuth tokens used to access the resources.
+	OAUTH_ACCESS_TOKEN = "access_token"
+
+	// OAUTH_REFRESH_TOKEN is the type of the OAuth tokens used to get new access tokens.
+	OAUTH_REFRESH_TOKEN = "refresh_token"
+)
+
+const (
+	oauthClientSecretPrefix = "chatcs_"
+	oauthCodePrefix         = "chatac_"
+	oauthAccessTokenPrefix  = "chatat_"
+	oauthRefreshTokenPrefix = "chatrt_"
+)
+
+// OAuthClient represents a application registered to use the service as identity provider.
+type OAuthClient struct {
+	ID   string `json:"client_id"`
+	Name string `json:"name"`
+
+	// SecretHash is the hash of the client secret, empty for public clients.
+	SecretHash string `json:"-"`
+
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewOAuthClient initializes a new OAuth client.
+//  @param name string: name of the application.
+//  @param redirectURIs []string: allowed redirect uris of the application.
+//  @param scopes []string: allowed scopes of the application.
+//  @param confidential bool: the client can keep a secret, like a server-side application.
+//  @return client OAuthClient: new OAuthClient instance.
+//  @return secret string: client secret to be shown once, empty for public clients.
+//  @return err error: random generation error.
+func NewOAuthClient(name string, redirectURIs, scopes []string, confidential bool) (client OAuthClient, secret string, err error) {
+	id, err := RandomToken("")
+	if err != nil {
+		return
+	}
+
+	client = OAuthClient{
+		ID:           id[:22],
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		CreatedAt:    time.Now(),
+	}
+
+	if confidential {
+		secret, err = RandomToken(oauthClientSecretPrefix)
+		if err != nil {
+			return
+		}
+		client.SecretHash = HashToken(secret)
+	}
+	return
+}
+
+// Public checks if the client is a public client, without secret.
+func (c OAuthClient) Public() bool {
+	return c.SecretHash == ""
+}
+
+// CheckSecret checks if the secret provided is the client secret.
+func (c OAuthClient) CheckSecret(secret string) bool {
+	if c.Public() {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(c.SecretHash)) == 1
+}
+
+// ValidRedirectURI checks if the redirect uri is registered for the client. The match must be exact.
+func (c OAuthClient) ValidRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedScopes filters the scopes provided by the allowed scopes of the client.
+func (c OAuthClient) AllowedScopes(scopes []string) []string {
+	return FilterScopes(scopes, c.Scopes)
+}
+
+// AuthorizationCode represents a authorization code grant given to a client by the user.
+type AuthorizationCode struct {
+	// Hash is the SHA-256 hash of the code. The code itself is never stored.
+	Hash string
+
+	ClientID    string
+	UserID      int
+	SessionID   int
+	RedirectURI string
+	Scopes      []string
+
+	// CodeChallenge and CodeChallengeMethod are the PKCE parameters of the authorization request.
+	CodeChallenge       string
+	CodeChallengeMethod string
+
+	Nonce     string
+	AuthTime  time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// NewAuthorizationCode initializes a new authorization code for the session provided.
+//  @param client OAuthClient: client which asks for the code.
+//  @param session Session: session of the user which grants the code.
+//  @param redirectURI string: redirect uri of the authorization request.
+//  @param scopes []string: granted scopes.
+//  @param ttl time.Duration: lifetime of the code.
+//  @return code AuthorizationCode: new AuthorizationCode instance.
+//  @return raw string: code to be sent to the client.
+//  @return err error: random generation error.
+func NewAuthorizationCode(client OAuthClient, session Session, redirectURI string, scopes []string, ttl time.Duration) (code AuthorizationCode, raw string, err error) {
+	raw, err = RandomToken(oauthCodePrefix)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	code = AuthorizationCode{
+		Hash:        HashToken(raw),
+		ClientID:    client.ID,
+		UserID:      session.UserID,
+		SessionID:   session.ID,
+		RedirectURI: redirectURI,
+		Scopes:      scopes,
+		AuthTime:    session.LoggedAt,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	return
+}
+
+// VerifyCodeChallenge checks the PKCE code verifier against the code challenge.
+//  Only the S256 method is supported.
+//  @param verifier string: code verifier sent by the client in the token request.
+//  @param challenge string: code challenge sent by the client in the authorization request.
+//  @param method string: code challenge method.
+//  @return $1 bool: the verifier matches the challenge.
+func VerifyCodeChallenge(verifier, challenge, method string) bool {
+	if method != "S256" || len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	h := sha256.Sum256([]byte(verifier))
+	got := base64.RawURLEncoding.EncodeToString(h[:])
+	return subtle.ConstantTimeCompare([]byte(got), []byte(challenge)) == 1
+}
+
+// OAuthToken represents a access or refresh token issued to a OAuth client.
+type OAuthToken struct {
+	ID int
+
+	// Hash is the SHA-256 hash of the token. The token itself is never stored.
+	Hash string
+
+	// Type is OAUTH_ACCESS_TOKEN or OAUTH_REFRESH_TOKEN.
+	Type string
+
+	ClientID  string
+	UserID    int
+	SessionID int
+	Scopes    []string
+	AuthTime  time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// NewOAuthToken initializes a new OAuth token.
+//  @param tokenType string: OAUTH_ACCESS_TOKEN or OAUTH_REFRESH_TOKEN.
+//  @param clientID string: client which the token is issued to.
+//  @param userID int: user which grants the token.
+//  @param sessionID int: session of the user which grants the token.
+//  @param scopes []string: granted scopes.
+//  @param authTime time.Time: time when the user has been authenticated.
+//  @param ttl time.Duration: lifetime of the token.
+//  @return token OAuthToken: new OAuthToken instance.
+//  @return raw string: token to be sent to the client.
+//  @return err error: random generation error.
+func NewOAuthToken(tokenType, clientID string, userID, sessionID int, scopes []string, authTime time.Time, ttl time.Duration) (token OAuthToken, raw string, err error) {
+	prefix := oauthAccessTokenPrefix
+	if tokenType == OAUTH_REFRESH_TOKEN {
+		prefix = oauthRefreshTokenPrefix
+	}
+
+	raw, err = RandomToken(prefix)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	token = OAuthToken{
+		Hash:      HashToken(raw),
+		Type:      tokenType,
+		ClientID:  clientID,
+		UserID:    userID,
+		SessionID: sessionID,
+		Scopes:    scopes,
+		AuthTime:  authTime,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	return
+}
+
+// Valid checks if the token is not revoked nor expired.
+func (t OAuthToken) Valid(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// HasScope checks if the scope has been granted to the token.
+func (t OAuthToken) HasScope(scope string) bool {
+	return HasScope(t.Scopes, scope)
+}
+
+// ParseScopes splits a space-delimited OAuth scope parameter.
+func ParseScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// HasScope checks if the scope is in the scopes provided.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterScopes gets the requested scopes which are in the allowed scopes.
+func FilterScopes(requested, allowed []string) (scopes []string) {
+	for _, s := range requested {
+		if HasScope(allowed, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return
+}
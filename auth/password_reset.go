@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"time"
+)
+
+// PasswordReset represents a single-use token to set a new password.
+type PasswordReset struct {
+	// Hash is the SHA-256 hash of the token. The token itself is never stored.
+	Hash   string
+	UserID int
+
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// NewPasswordReset initializes a new password reset with a random token.
+//  @param userID int: user whose password is reset.
+//  @param ttl time.Duration: lifetime of the token.
+//  @return reset PasswordReset: new PasswordReset instance.
+//  @return raw string: token to be sent to the user.
+//  @return err error: random generation error.
+func NewPasswordReset(userID int, ttl time.Duration) (reset PasswordReset, raw string, err error) {
+	raw, err = RandomToken("")
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	reset = PasswordReset{
+		Hash:      HashToken(raw),
+		UserID:    userID,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	return
+}
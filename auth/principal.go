@@ -0,0 +1,87 @@
+package auth
+
+// Principal represents the authenticated user behind a request.
+type Principal struct {
+	UserID    int    `json:"user_id"`
+	SessionID int    `json:"session_id"`
+	Roles     []Role `json:"roles,omitempty"`
+
+	// TokenID is the personal access token used to authenticate, 0 if it is a session.
+	TokenID int `json:"token_id,omitempty"`
+
+	// Scopes restricts the permissions of a principal authenticated with a personal access token.
+	Scopes []Permission `json:"scopes,omitempty"`
+
+	// ImpersonatorID is the admin which is impersonating the user, 0 if it isn't a impersonation.
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
+}
+
+// ImpersonationPermissions are the only permissions allowed to a impersonation session,
+//  so the admin can see the chat as the user does but can't act on its behalf.
+var ImpersonationPermissions = []Permission{
+	PermissionUsersRead,
+}
+
+// NewPrincipal initializes a new principal for the session and roles provided.
+//  @param session Session: authenticated session of the user.
+//  @param roles []Role: roles assigned to the user.
+//  @return principal Principal: new Principal instance.
+func NewPrincipal(session Session, roles []Role) (principal Principal) {
+	return Principal{
+		UserID:         session.UserID,
+		SessionID:      session.ID,
+		Roles:          roles,
+		ImpersonatorID: session.ImpersonatorID,
+	}
+}
+
+// NewTokenPrincipal initializes a new principal for the personal access token and roles provided.
+//  @param token AccessToken: authenticated access token of the user.
+//  @param roles []Role: roles assigned to the user.
+//  @return principal Principal: new Principal instance.
+func NewTokenPrincipal(token AccessToken, roles []Role) (principal Principal) {
+	return Principal{
+		UserID:  token.UserID,
+		Roles:   roles,
+		TokenID: token.ID,
+		Scopes:  token.Scopes,
+	}
+}
+
+// HasPermission checks if any of the principal roles grants the permission provided.
+//  If the principal is authenticated with a access token, the permission must be in its scopes too.
+//  If the principal is a impersonation, the permission must be in the ImpersonationPermissions too.
+//  @param permission Permission: permission to check.
+//  @return $1 bool: the principal is allowed to perform the permission.
+func (p Principal) HasPermission(permission Permission) bool {
+	if p.TokenID != 0 && !containsPermission(p.Scopes, permission) {
+		return false
+	}
+	if p.Impersonated() && !containsPermission(ImpersonationPermissions, permission) {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r.HasPermission(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Impersonated checks if the principal is a admin impersonating the user.
+//  @return $1 bool: the principal is a impersonation.
+func (p Principal) Impersonated() bool {
+	return p.ImpersonatorID != 0
+}
+
+// HasRole checks if the principal has been assigned with the role provided.
+//  @param name string: role name to check.
+//  @return $1 bool: the principal has the role.
+func (p Principal) HasRole(name string) bool {
+	for _, r := range p.Roles {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// RememberToken represents a persistent login token of a "remember me" login.
+//  The token belongs to a series, which is kept while the token itself is rotated on every use,
+//  so the reuse of a rotated token reveals it has been stolen.
+type RememberToken struct {
+	// Series is the random identifier of the series, kept between rotations.
+	Series string
+
+	// Hash is the SHA-256 hash of the current token of the series. The token itself is never stored.
+	Hash string
+
+	UserID int
+
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// NewRememberToken initializes a new persistent login series.
+//  @param userID int: user to remember.
+//  @param ttl time.Duration: lifetime of the series.
+//  @return token RememberToken: new RememberToken instance.
+//  @return raw string: series and token to be kept in the cookie.
+//  @return err error: random generation error.
+func NewRememberToken(userID int, ttl time.Duration) (token RememberToken, raw string, err error) {
+	series, err := RandomToken("")
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	token = RememberToken{
+		Series:    series,
+		UserID:    userID,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	token, raw, err = token.Rotate()
+	return
+}
+
+// Rotate generates the next token of the series.
+//  @return next RememberToken: series with the new token hash.
+//  @return raw string: series and new token to be kept in the cookie.
+//  @return err error: random generation error.
+func (t RememberToken) Rotate() (next RememberToken, raw string, err error) {
+	secret, err := RandomToken("")
+	if err != nil {
+		return
+	}
+
+	next = t
+	next.Hash = HashToken(secret)
+	raw = t.Series + ":" + secret
+	return
+}
+
+// Valid checks if the series isn't revoked nor expired.
+//  @param now time.Time: time to check.
+//  @return $1 bool: the series is valid.
+func (t RememberToken) Valid(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// ParseRememberToken splits the raw cookie value in its series and token hash.
+//  @param raw string: series and token kept in the cookie.
+//  @return series string: series of the token.
+//  @return hash string: SHA-256 hash of the token.
+//  @return err error: malformed value error.
+func ParseRememberToken(raw string) (series, hash string, err error) {
+	parts := strings.SplitN(raw, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		err = errors.New("invalid remember token: malformed value")
+		return
+	}
+	series = parts[0]
+	hash = HashToken(parts[1])
+	return
+}
@@ -0,0 +1,54 @@
+package auth
+
+// Permission represents a single action which can be granted to a role.
+// 	Permissions are named as "resource:action", for example "users:admin".
+type Permission string
+
+const (
+	// PermissionUsersAdmin allows to manage the users and their roles.
+	PermissionUsersAdmin Permission = "users:admin"
+
+	// PermissionUsersRead allows to read the users information.
+	PermissionUsersRead Permission = "users:read"
+
+	// PermissionChatModerate allows to moderate the chat rooms and messages.
+	PermissionChatModerate Permission = "chat:moderate"
+
+	// PermissionChatWrite allows to write messages in the chat.
+	PermissionChatWrite Permission = "chat:write"
+
+	// PermissionInvitesManage allows to create and revoke the registration invites.
+	PermissionInvitesManage Permission = "invites:manage"
+
+	// PermissionWSTicketVerify allows to verify the WebSocket tickets, used by the chat socket server.
+	PermissionWSTicketVerify Permission = "ws:verify"
+)
+
+// DEFAULT_ROLE is the role assigned to every new user.
+const DEFAULT_ROLE = "user"
+
+// ADMIN_ROLE is the role with all the permissions.
+const ADMIN_ROLE = "admin"
+
+// Role represents a named group of permissions assignable to the users.
+type Role struct {
+	ID          int          `json:"id,omitempty"`
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// HasPermission checks if the role grants the permission provided.
+//  @param permission Permission: permission to check.
+//  @return $1 bool: the role grants the permission.
+func (r Role) HasPermission(permission Permission) bool {
+	return containsPermission(r.Permissions, permission)
+}
+
+func containsPermission(permissions []Permission, permission Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
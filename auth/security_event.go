@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"time"
+)
+
+// SecurityEventType is the kind of a security event of a user account.
+type SecurityEventType string
+
+const (
+	SecurityEventLogin           SecurityEventType = "login"
+	SecurityEventLoginFailed     SecurityEventType = "login_failed"
+	SecurityEventSignUp          SecurityEventType = "signup"
+	SecurityEventPasswordChanged SecurityEventType = "password_changed"
+	SecurityEventEmailChanged    SecurityEventType = "email_changed"
+	SecurityEventSudoGranted     SecurityEventType = "sudo_granted"
+	SecurityEventProviderLinked  SecurityEventType = "provider_linked"
+	SecurityEventSessionRevoked  SecurityEventType = "session_revoked"
+	SecurityEventImpersonated    SecurityEventType = "impersonated"
+)
+
+// SecurityEvent represents a entry of the security log of a user account.
+type SecurityEvent struct {
+	ID     int               `json:"id"`
+	UserID int               `json:"-"`
+	Type   SecurityEventType `json:"type"`
+
+	// Method is the platform or handler which performed the event, like system, google or facebook.
+	Method    string `json:"method,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	SessionID int    `json:"session_id,omitempty"`
+
+	CreatedAt time.Time `json:"ts"`
+}
+
+// NewSecurityEvent initializes a new security event happened now.
+//  @param userID int: owner of the account.
+//  @param eventType SecurityEventType: kind of the event.
+//  @param method string: platform or handler which performed the event.
+//  @param ip string: ip address of the client.
+//  @param userAgent string: user agent of the client.
+//  @param sessionID int: session related to the event, zero if there is none.
+//  @return $1 SecurityEvent: new SecurityEvent instance.
+func NewSecurityEvent(userID int, eventType SecurityEventType, method, ip, userAgent string, sessionID int) SecurityEvent {
+	return SecurityEvent{
+		UserID:    userID,
+		Type:      eventType,
+		Method:    method,
+		IP:        ip,
+		UserAgent: userAgent,
+		SessionID: sessionID,
+		CreatedAt: time.Now(),
+	}
+}
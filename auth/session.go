@@ -21,8 +21,14 @@ type Session struct {
 
 	// Session status
 	Actived bool `json:"actived,omitempty"`
+
+	// ImpersonatorID is the admin which is impersonating the user, 0 if it is a session of the user itself.
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
 }
 
+// IMPERSONATION_PLATFORM is the platform of the sessions created by a admin impersonation.
+const IMPERSONATION_PLATFORM = "impersonation"
+
 // NewSession initializes a new session instance
 //  @param userID int: user id unique identifier.
 //  @param loggedWith string: platform which the user has been sign.
@@ -40,3 +46,43 @@ func NewSession(userID int, loggedWith string) (session Session, err error) {
 	}
 	return
 }
+
+// NewImpersonationSession initializes a new session of the user to be used by the admin impersonating it.
+//  @param userID int: impersonated user id.
+//  @param impersonatorID int: admin user id.
+//  @return session Session: new Session instance.
+//	@return err error: session encryptation error.
+func NewImpersonationSession(userID, impersonatorID int) (session Session, err error) {
+	session, err = NewSession(userID, IMPERSONATION_PLATFORM)
+	if err != nil {
+		return
+	}
+	session.ImpersonatorID = impersonatorID
+	return
+}
+
+// Impersonated checks if the session is used by a admin impersonating the user.
+//  @return $1 bool: the session is a impersonation.
+func (s Session) Impersonated() bool {
+	return s.ImpersonatorID != 0
+}
+
+// Lifetime defines the limits of a session. Zero durations are unlimited.
+type Lifetime struct {
+	// Idle is the max time between two authenticated requests of the session.
+	Idle time.Duration
+
+	// Absolute is the max time since the user has been sign, regardless of its activity.
+	Absolute time.Duration
+}
+
+// Expired checks if the session exceeded any of the lifetime limits.
+//  @param now time.Time: time to check.
+//  @param l Lifetime: limits of the session.
+//  @return $1 bool: the session is expired.
+func (s Session) Expired(now time.Time, l Lifetime) bool {
+	if l.Idle > 0 && now.Sub(s.LastSeenAt) > l.Idle {
+		return true
+	}
+	return l.Absolute > 0 && now.Sub(s.LoggedAt) > l.Absolute
+}
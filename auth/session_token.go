@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"time"
+)
+
+// SessionToken represents the server-side record of a session cookie.
+//  The cookie only holds the opaque token, the session values are kept in the record.
+type SessionToken struct {
+	// Hash is the SHA-256 hash of the cookie token. The token itself is never stored.
+	Hash string
+
+	// SessionID is the user session kept in the values, zero if the cookie has no user session.
+	SessionID int
+
+	// Values are the encoded session values.
+	Values []byte
+
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -16,3 +16,10 @@ func NewSudo(sessionID int, durationInSecs int) (sudo Sudo) {
 		CreatedAt:      time.Now(),
 	}
 }
+
+// Active checks if the sudo mode hasn't finished at the time provided.
+//  @param now time.Time: time to check.
+//  @return $1 bool: the sudo mode is active.
+func (s Sudo) Active(now time.Time) bool {
+	return now.Before(s.CreatedAt.Add(time.Duration(s.DurationInSecs) * time.Second))
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ACCESS_TOKEN_PREFIX is the recognizable prefix of every personal access token.
+const ACCESS_TOKEN_PREFIX = "chatpat_"
+
+// accessTokenDisplayLen is the length of the raw token kept as display prefix.
+const accessTokenDisplayLen = len(ACCESS_TOKEN_PREFIX) + 6
+
+// AccessToken represents a personal access token of a user used by scripts and integrations.
+type AccessToken struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+
+	// Prefix is the first characters of the token, used to recognize it by the user.
+	Prefix string `json:"prefix"`
+
+	// Hash is the SHA-256 hash of the token. The token itself is never stored.
+	Hash string `json:"-"`
+
+	// Scopes restricts the permissions which can be used with the token.
+	Scopes []Permission `json:"scopes"`
+
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewAccessToken initializes a new personal access token with a random secret.
+//  @param userID int: owner of the token.
+//  @param name string: name given by the user to the token.
+//  @param scopes []Permission: permissions allowed for the token.
+//  @param expiresAt *time.Time: optional expiration time.
+//  @return token AccessToken: new AccessToken instance.
+//  @return raw string: token to be shown once to the user.
+//  @return err error: random generation error.
+func NewAccessToken(userID int, name string, scopes []Permission, expiresAt *time.Time) (token AccessToken, raw string, err error) {
+	raw, err = RandomToken(ACCESS_TOKEN_PREFIX)
+	if err != nil {
+		return
+	}
+
+	token = AccessToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:accessTokenDisplayLen],
+		Hash:      HashToken(raw),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	return
+}
+
+// RandomToken generates a new random token of 256 bits, URL-safe encoded.
+//  @param prefix string: prefix to add to the token.
+//  @return token string: new random token.
+//  @return err error: random generation error.
+func RandomToken(prefix string) (token string, err error) {
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("failed to generate random token: %s", err)
+		return
+	}
+	token = prefix + base64.RawURLEncoding.EncodeToString(b)
+	return
+}
+
+// HashToken gets the hash to store and look up a high entropy token.
+//  @param raw string: token to hash.
+//  @return $1 string: hex encoded SHA-256 hash.
+func HashToken(raw string) string {
+	h := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(h[:])
+}
+
+// IsAccessToken checks if the value has the personal access token format.
+func IsAccessToken(raw string) bool {
+	return strings.HasPrefix(raw, ACCESS_TOKEN_PREFIX) && len(raw) > accessTokenDisplayLen
+}
+
+// Valid checks if the token is not revoked nor expired.
+func (t AccessToken) Valid(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
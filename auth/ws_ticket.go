@@ -0,0 +1,48 @@
+package auth
+
+import "time"
+
+// WS_TICKET_TTL is the lifetime of the WebSocket connection tickets.
+const WS_TICKET_TTL = 30 * time.Second
+
+const wsTicketPrefix = "chatws_"
+
+// WSTicket represents a single-use ticket to authenticate a WebSocket upgrade request,
+//  where the browsers can't send custom auth headers.
+type WSTicket struct {
+	// Hash is the SHA-256 hash of the ticket. The ticket itself is never stored.
+	Hash string `json:"-"`
+
+	UserID    int `json:"user_id"`
+	SessionID int `json:"session_id"`
+
+	// Origin is the origin of the page which requested the ticket.
+	Origin string `json:"origin"`
+
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewWSTicket initializes a new WebSocket ticket bound to the session and origin provided.
+//  @param session Session: session of the user which requests the ticket.
+//  @param origin string: origin of the page which requests the ticket.
+//  @return ticket WSTicket: new WSTicket instance.
+//  @return raw string: ticket to be sent to the client.
+//  @return err error: random generation error.
+func NewWSTicket(session Session, origin string) (ticket WSTicket, raw string, err error) {
+	raw, err = RandomToken(wsTicketPrefix)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	ticket = WSTicket{
+		Hash:      HashToken(raw),
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		Origin:    origin,
+		ExpiresAt: now.Add(WS_TICKET_TTL),
+		CreatedAt: now,
+	}
+	return
+}
@@ -0,0 +1,3 @@
+// Package captcha implements the CAPTCHA verification used to protect the sign up from bots.
+
+package captcha
@@ -0,0 +1,125 @@
+package captcha
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// HCAPTCHA_VERIFY_URL is the verification endpoint of hCaptcha.
+	HCAPTCHA_VERIFY_URL = "https://api.hcaptcha.com/siteverify"
+
+	// TURNSTILE_VERIFY_URL is the verification endpoint of Cloudflare Turnstile.
+	TURNSTILE_VERIFY_URL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+// ErrInvalidToken is returned when the CAPTCHA token is missing or it has been rejected by the provider.
+var ErrInvalidToken = errors.New("invalid captcha: token missing, expired or rejected")
+
+// CaptchaVerifier defines the behaviors to be used by a CAPTCHA provider implementation.
+type CaptchaVerifier interface {
+	// Verify verifies the token solved by the client.
+	//  @param token string: token sent by the client.
+	//  @param remoteIP string: ip address of the client.
+	//  @return $1 error: ErrInvalidToken if the token has been rejected, or a connection error.
+	Verify(token, remoteIP string) error
+}
+
+// HCaptchaVerifier is the CaptchaVerifier implementation for hCaptcha.
+type HCaptchaVerifier struct {
+	siteVerifier
+}
+
+// NewHCaptchaVerifier initializes a new HCaptchaVerifier instance.
+//  @param secret string: secret key of the site.
+//  @return $1 HCaptchaVerifier: new HCaptchaVerifier instance.
+func NewHCaptchaVerifier(secret string) HCaptchaVerifier {
+	return HCaptchaVerifier{newSiteVerifier(HCAPTCHA_VERIFY_URL, secret)}
+}
+
+// TurnstileVerifier is the CaptchaVerifier implementation for Cloudflare Turnstile.
+type TurnstileVerifier struct {
+	siteVerifier
+}
+
+// NewTurnstileVerifier initializes a new TurnstileVerifier instance.
+//  @param secret string: secret key of the site.
+//  @return $1 TurnstileVerifier: new TurnstileVerifier instance.
+func NewTurnstileVerifier(secret string) TurnstileVerifier {
+	return TurnstileVerifier{newSiteVerifier(TURNSTILE_VERIFY_URL, secret)}
+}
+
+// siteVerifier verifies the tokens with the siteverify API, shared by hCaptcha and Turnstile.
+type siteVerifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func newSiteVerifier(url, secret string) siteVerifier {
+	return siteVerifier{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s siteVerifier) Verify(token, remoteIP string) (err error) {
+	if strings.TrimSpace(token) == "" {
+		err = ErrInvalidToken
+		return
+	}
+
+	form := url.Values{
+		"secret":   {s.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	resp, err := s.client.PostForm(s.url, form)
+	if err != nil {
+		err = fmt.Errorf("failed to verify captcha: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("failed to verify captcha: unexpected status %d", resp.StatusCode)
+		return
+	}
+
+	result := struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		err = fmt.Errorf("failed to decode captcha verification: %s", err)
+		return
+	}
+
+	if !result.Success {
+		err = ErrInvalidToken
+	}
+	return
+}
+
+// FakeVerifier is the CaptchaVerifier implementation which only accepts a fixed token.
+//  It is intended for tests and development, when no CAPTCHA provider is configured.
+type FakeVerifier struct {
+	Token string
+}
+
+func (f FakeVerifier) Verify(token, remoteIP string) (err error) {
+	if token == "" || token != f.Token {
+		err = ErrInvalidToken
+	}
+	return
+}
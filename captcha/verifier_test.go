@@ -0,0 +1,44 @@
+package captcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteVerifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "203.0.113.1", r.PostForm.Get("remoteip"))
+
+		if r.PostForm.Get("response") == "solved" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer srv.Close()
+
+	v := newSiteVerifier(srv.URL, "secret")
+
+	t.Run("Given a solved token When verifying Then success", func(t *testing.T) {
+		assert.NoError(t, v.Verify("solved", "203.0.113.1"))
+	})
+	t.Run("Given a rejected token When verifying Then invalid token error", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidToken, v.Verify("bot", "203.0.113.1"))
+	})
+	t.Run("Given a empty token When verifying Then invalid token error", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidToken, v.Verify("", "203.0.113.1"))
+	})
+}
+
+func TestFakeVerifier(t *testing.T) {
+	v := FakeVerifier{Token: "pass"}
+
+	assert.NoError(t, v.Verify("pass", ""))
+	assert.Equal(t, ErrInvalidToken, v.Verify("fail", ""))
+	assert.Equal(t, ErrInvalidToken, FakeVerifier{}.Verify("", ""))
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"golang.org/x/oauth2/facebook"
+	"golang.org/x/oauth2/google"
+)
+
+// Defaults gets the default config, the base overridden by the config file, the env vars and the flags.
+//  The ports, the sudo duration and the OIDC issuer have no default, they must always be configured.
+// 	@return $1 ConfigInfo: default ConfigInfo instance.
+func Defaults() ConfigInfo {
+	return ConfigInfo{
+		OAuth: oauth{
+			Google: oauthProperties{
+				Endpoint: google.Endpoint,
+			},
+			Facebook: oauthProperties{
+				Endpoint: facebook.Endpoint,
+			},
+		},
+		OIDC: oidc{
+			AuthorizationCodeTTLInSecs: 60,
+			AccessTokenTTLInSecs:       3600,
+			RefreshTokenTTLInSecs:      30 * 24 * 3600,
+			IDTokenTTLInSecs:           3600,
+		},
+		SigningKeys: signingKeys{
+			Store:                  "dir",
+			Algorithm:              "RS256",
+			RotationIntervalInSecs: 30 * 24 * 3600,
+			RetentionInSecs:        7 * 24 * 3600,
+			PrepublishInSecs:       24 * 3600,
+			CheckIntervalInSecs:    3600,
+		},
+		Mail: mail{
+			SMTPPort: 587,
+		},
+		MagicLink: magicLink{
+			LinkURL:      "http://localhost:8080/api/v1/auth/login/magic-link",
+			TTLInSecs:    900,
+			MaxPerWindow: 3,
+			WindowInSecs: 900,
+		},
+		EmailCode: emailCode{
+			Digits:       6,
+			TTLInSecs:    300,
+			MaxAttempts:  5,
+			MaxPerWindow: 3,
+			WindowInSecs: 900,
+		},
+		Session: session{
+			Store:                  "cookie",
+			IdleTimeoutInSecs:      7 * 24 * 60 * 60,
+			AbsoluteLifetimeInSecs: 30 * 24 * 60 * 60,
+			TouchIntervalInSecs:    60,
+			ReaperIntervalInSecs:   60 * 60,
+			RememberMeTTLInSecs:    90 * 24 * 60 * 60,
+			PurgeAfterInSecs:       30 * 24 * 60 * 60,
+		},
+		Security: security{
+			NotMeURL:               "http://localhost:8080/api/v1/auth/not-me",
+			NotMeTTLInSecs:         7 * 24 * 60 * 60,
+			PasswordResetURL:       "http://localhost:3000/password-reset",
+			PasswordResetTTLInSecs: 60 * 60,
+			ImpersonationTTLInSecs: 15 * 60,
+		},
+		Registration: registration{
+			Mode:            "open",
+			InviteTTLInSecs: 7 * 24 * 60 * 60,
+		},
+		SignUp: signUp{
+			VelocityWindowInSecs: 60 * 60,
+			VelocityMaxPerIP:     5,
+			VelocityMaxPerSubnet: 20,
+		},
+		EmailValidation: emailValidation{
+			Mode:                "dns",
+			CacheTTLInSecs:      60 * 60,
+			LookupTimeoutInSecs: 5,
+		},
+		PostgreSQLProperties: postgreSQLProperties{
+			SSLMode:               "disable",
+			ConnectTimeoutInSecs:  10,
+			ApplicationName:       "chat",
+			MaxOpenConns:          25,
+			MaxIdleConns:          5,
+			ConnMaxLifetimeInSecs: 30 * 60,
+		},
+		Reload: reload{
+			WatchIntervalInSecs: 5,
+		},
+		Secrets: secretStore{
+			VaultMount: "secret",
+		},
+	}
+}
@@ -1,4 +1,8 @@
 // Package config handles all the config implementations like env and file config.
-// The supported config types are: yaml files and environment vars.
+// The supported config types are: yaml files, environment vars and command-line flags,
+// merged over the defaults by Load.
+// The secret fields can be read from files or resolved by a secrets.SecretProvider, and they are redacted
+// when the config is printed.
+// A Reloader reloads the reloadable fields on SIGHUP or when the config file changes, without restart.
 
 package config
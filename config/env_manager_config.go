@@ -1,14 +1,6 @@
 package config
 
-import (
-	"fmt"
-	"os"
-	"strconv"
-	"strings"
-
-	"golang.org/x/oauth2/facebook"
-	"golang.org/x/oauth2/google"
-)
+import "os"
 
 // EnvManagerConfig is the Config implementation for the environment config vars.
 type EnvManagerConfig struct {
@@ -19,70 +11,43 @@ func (f EnvManagerConfig) Get() ConfigInfo {
 	return f.config
 }
 
-// NewEnvManagerConfig initializes a new ConfigInfo instance by the env config vars.
+// NewEnvManagerConfig initializes a new ConfigInfo instance by the env config vars over the defaults.
 // 	@return conf ConfigInfo: new ConfigInfo instance with the env vars information.
-// 	@return err error: error getting env vars values.
+// 	@return err error: error getting env vars values or invalid config.
 func NewEnvManagerConfig() (conf ConfigInfo, err error) {
-	conf, err = newConfigWithEnvVars()
-	return
-}
-
-func newConfigWithEnvVars() (conf ConfigInfo, err error) {
-	srvPort, err := getEnvInt("PORT")
+	conf = Defaults()
+	err = loadEnvVars(&conf)
 	if err != nil {
 		return
 	}
-
-	dbPort, err := getEnvInt("DB_PORT")
-	if err != nil {
-		return
-	}
-
-	sudoDuration, err := getEnvInt("SUDO_DURATION_IN_SECS")
-	if err != nil {
-		return
-	}
-
-	conf = ConfigInfo{
-		Server: server{
-			Port:           srvPort,
-			Host:           os.Getenv("SRV_HOST"),
-			AllowedOrigins: strings.Split(os.Getenv("SRV_ALLOWED_ORIGINS"), ";"),
-		},
-		OAuth: oauth{
-			Google: oauthProperties{
-				ClientID:     os.Getenv("OAUTH_GOOGLE_CLIENT_ID"),
-				ClientSecret: os.Getenv("OAUTH_GOOGLE_CLIENT_SECRET"),
-				RedirectURIS: strings.Split(os.Getenv("OAUTH_GOOGLE_REDIRECT_URIS"), ";"),
-				Scopes:       strings.Split(os.Getenv("OAUTH_GOOGLE_SCOPES"), ";"),
-				Endpoint:     google.Endpoint,
-			},
-			Facebook: oauthProperties{
-				ClientID:     os.Getenv("OAUTH_FACEBOOK_CLIENT_ID"),
-				ClientSecret: os.Getenv("OAUTH_FACEBOOK_CLIENT_SECRET"),
-				RedirectURIS: strings.Split(os.Getenv("OAUTH_FACEBOOK_REDIRECT_URIS"), ";"),
-				Scopes:       strings.Split(os.Getenv("OAUTH_FACEBOOK_SCOPES"), ";"),
-				Endpoint:     facebook.Endpoint,
-			},
-		},
-		Sudo: sudo{
-			DurationInSecs: sudoDuration,
-		},
-		PostgreSQLProperties: postgreSQLProperties{
-			User:     os.Getenv("DB_USER"),
-			Password: os.Getenv("DB_PASS"),
-			Name:     os.Getenv("DB_NAME"),
-			Host:     os.Getenv("DB_HOST"),
-			Port:     dbPort,
-		},
-	}
+	err = conf.Validate()
 	return
 }
 
-func getEnvInt(n string) (i int, err error) {
-	i, err = strconv.Atoi(os.Getenv(n))
-	if err != nil {
-		err = fmt.Errorf("failed to load env var int %s: %s", n, err)
-	}
-	return
+// loadEnvVars sets the fields with a env var from the env vars which are set, a empty env var is unset.
+//  The secret fields can also be read from the file of its env var with the "_FILE" suffix, like DB_PASS_FILE,
+//  so the secret isn't kept in the environment. All the invalid values are reported together.
+func loadEnvVars(conf *ConfigInfo) (err error) {
+	var errs ValidationErrors
+	walkFields(conf, func(f field) {
+		raw := os.Getenv(f.env)
+		var file string
+		if f.secret {
+			file = os.Getenv(f.env + "_FILE")
+		}
+
+		switch {
+		case raw != "" && file != "":
+			errs.addf("failed to load env var %s: only one of %s and %s_FILE can be set", f.env, f.env, f.env)
+		case file != "":
+			if sErr := f.setFile(file); sErr != nil {
+				errs.addf("failed to load env var %s_FILE: %s", f.env, sErr)
+			}
+		case raw != "":
+			if sErr := f.set(raw); sErr != nil {
+				errs.addf("failed to load env var %s: %s", f.env, sErr)
+			}
+		}
+	})
+	return errs.orNil()
 }
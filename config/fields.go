@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// field is a config field which can be set from a env var or a flag.
+type field struct {
+	// env is the name of the env var of the field, the prefixes of its parents included.
+	env string
+
+	// path is the path of the field in the config file, like "psql.port".
+	path string
+
+	// secret is set for the fields tagged with `secret:"true"`, which are redacted and can be read from files.
+	secret bool
+
+	value reflect.Value
+}
+
+// flag gets the name of the flag of the field: its env var in lowercase with dashes, like "db-port" for DB_PORT.
+func (f field) flag() string {
+	return strings.ToLower(strings.ReplaceAll(f.env, "_", "-"))
+}
+
+// fileFlag gets the name of the flag of the file of a secret field, like "db-pass-file" for DB_PASS.
+func (f field) fileFlag() string {
+	return f.flag() + "-file"
+}
+
+// setFile sets the value of the field from the content of the file provided, without its trailing line breaks.
+func (f field) setFile(path string) (err error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("failed to read secret file: %s", err)
+		return
+	}
+	return f.set(strings.TrimRight(string(raw), "\r\n"))
+}
+
+// set sets the value of the field parsing the raw value provided.
+//  The lists are separated by ";".
+func (f field) set(raw string) (err error) {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Int:
+		var i int
+		i, err = strconv.Atoi(raw)
+		if err != nil {
+			err = fmt.Errorf("%s is not a valid int", raw)
+			return
+		}
+		f.value.SetInt(int64(i))
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(raw)
+		if err != nil {
+			err = fmt.Errorf("%s is not a valid bool", raw)
+			return
+		}
+		f.value.SetBool(b)
+	case reflect.Slice:
+		var l []string
+		for _, v := range strings.Split(raw, ";") {
+			if v = strings.TrimSpace(v); v != "" {
+				l = append(l, v)
+			}
+		}
+		f.value.Set(reflect.ValueOf(l))
+	default:
+		err = fmt.Errorf("unsupported field type %s", f.value.Type())
+	}
+	return
+}
+
+// walkFields calls fn with every field of the config which has a env var.
+//  The "envPrefix" tag of a struct field is the prefix of the env vars of its fields.
+func walkFields(conf *ConfigInfo, fn func(f field)) {
+	walkStruct(reflect.ValueOf(conf).Elem(), "", "", fn)
+}
+
+func walkStruct(v reflect.Value, envPrefix, pathPrefix string, fn func(f field)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		path := pathPrefix + yamlName(sf)
+
+		if env, ok := sf.Tag.Lookup("env"); ok {
+			fn(field{env: envPrefix + env, path: path, secret: sf.Tag.Get("secret") == "true", value: v.Field(i)})
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			walkStruct(v.Field(i), envPrefix+sf.Tag.Get("envPrefix"), path+".", fn)
+		}
+	}
+}
+
+// yamlName gets the name of the struct field in the config file.
+//  The fields without yaml tag use its name in lowercase, as the yaml decoder does.
+func yamlName(sf reflect.StructField) string {
+	if name := strings.Split(sf.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(sf.Name)
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path"
 
@@ -17,21 +20,40 @@ func (f FileManagerConfig) Get() ConfigInfo {
 	return f.config
 }
 
-// NewEnvManagerConfig initializes a new ConfigInfo instance by the config file provided.
+// NewFileManagerConfig initializes a new ConfigInfo instance by the config file provided over the defaults.
 //  @param env string: is the environment that must be used for get the config information.
 //	 For example: "local" is for a local configuration.
 //  @param configDir string: specifies the config dir path to locate the config files.
-// 	@return conf ConfigInfo: new ConfigInfo instance with the env vars information.
-// 	@return err error: error getting env vars values.
-func NewFileManagerConfig(env, configDir string) (conf Config, err error) {
-	var fc FileManagerConfig
+// 	@return conf ConfigInfo: new ConfigInfo instance with the config file information.
+// 	@return err error: error reading the config file or invalid config.
+func NewFileManagerConfig(env, configDir string) (conf ConfigInfo, err error) {
+	conf = Defaults()
+	err = loadFile(&conf, genConfigFileFullname(env, configDir))
+	if err != nil {
+		return
+	}
+	err = conf.Validate()
+	return
+}
 
-	raw, err := readConfigFile(genConfigFileFullname(env, configDir))
+// loadFile sets the fields present in the config file provided.
+//  The unknown fields are refused, so the typos in the file don't go unnoticed.
+func loadFile(conf *ConfigInfo, path string) (err error) {
+	raw, err := readConfigFile(path)
 	if err != nil {
 		return
 	}
 
-	fc.config, err = newConfigWithBytes(raw)
+	d := yaml.NewDecoder(bytes.NewReader(raw))
+	d.KnownFields(true)
+	err = d.Decode(conf)
+	if errors.Is(err, io.EOF) {
+		// A empty file doesn't override any field.
+		err = nil
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid config: failed to read config file %s: %s", path, err)
+	}
 	return
 }
 
@@ -46,11 +68,3 @@ func readConfigFile(path string) (raw []byte, err error) {
 func genConfigFileFullname(env, configDir string) string {
 	return path.Join(configDir, fmt.Sprintf("%s.yaml", env))
 }
-
-func newConfigWithBytes(b []byte) (c ConfigInfo, err error) {
-	err = yaml.Unmarshal(b, &c)
-	if err != nil {
-		err = fmt.Errorf("invalid config: failed to get config info. Bad structure?")
-	}
-	return
-}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// DEFAULT_CONFIG_DIR is the dir of the config files when none is provided.
+const DEFAULT_CONFIG_DIR = "config"
+
+// Load loads the config merging all its sources, each one overriding the previous ones:
+//  1. The defaults, see Defaults.
+//  2. The config file <config dir>/<env>.yaml, only if a env name is provided.
+//  3. The env vars which are set, like DB_PORT.
+//  4. The command-line flags, named as the env vars in lowercase with dashes, like --db-port.
+//  The secret fields can also be read from files, see loadEnvVars, and their "secret:<name>" values
+//  are resolved by the configured secret provider, see resolveSecrets.
+//  The env name and the config dir are taken from the --config-env and --config-dir flags,
+//  or the CONFIG_ENV and CONFIG_DIR env vars. The loaded config is validated.
+//  @param args []string: command-line arguments, without the program name.
+//  @return conf ConfigInfo: loaded ConfigInfo instance.
+//  @return err error: invalid flags, unreadable config file or invalid config.
+//   The invalid values are reported all together as ValidationErrors.
+func Load(args []string) (conf ConfigInfo, err error) {
+	conf, _, err = load(args)
+	return
+}
+
+// load loads the config as Load, also returning the path of the config file, empty if there is none.
+func load(args []string) (conf ConfigInfo, path string, err error) {
+	fs, flags := newFlagSet()
+	err = fs.Parse(args)
+	if err != nil {
+		return
+	}
+
+	conf = Defaults()
+
+	env := firstNonEmpty(*flags.env, os.Getenv("CONFIG_ENV"))
+	if env != "" {
+		dir := firstNonEmpty(*flags.dir, os.Getenv("CONFIG_DIR"), DEFAULT_CONFIG_DIR)
+		path = genConfigFileFullname(env, dir)
+		err = loadFile(&conf, path)
+		if err != nil {
+			return
+		}
+	}
+
+	var errs ValidationErrors
+	errs.add(loadEnvVars(&conf))
+	errs.add(loadFlags(&conf, fs))
+	errs.add(resolveSecrets(&conf))
+	errs.add(conf.Validate())
+	err = errs.orNil()
+	return
+}
+
+// configFlags are the flags which select the config file.
+type configFlags struct {
+	env *string
+	dir *string
+}
+
+// newFlagSet creates the flag set of the config, with a flag by config field.
+func newFlagSet() (fs *flag.FlagSet, flags configFlags) {
+	fs = flag.NewFlagSet("chat", flag.ContinueOnError)
+	flags.env = fs.String("config-env", "", "environment name of the config file, like \"local\" for local.yaml")
+	flags.dir = fs.String("config-dir", "", "dir of the config files (default \""+DEFAULT_CONFIG_DIR+"\")")
+
+	conf := Defaults()
+	walkFields(&conf, func(f field) {
+		fs.String(f.flag(), "", fmt.Sprintf("sets %s, overrides the %s env var", f.path, f.env))
+		if f.secret {
+			fs.String(f.fileFlag(), "", fmt.Sprintf("sets %s from a file, overrides the %s_FILE env var", f.path, f.env))
+		}
+	})
+	return
+}
+
+// loadFlags sets the fields of the flags provided in the command line.
+func loadFlags(conf *ConfigInfo, fs *flag.FlagSet) (err error) {
+	set := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	var errs ValidationErrors
+	walkFields(conf, func(f field) {
+		raw, ok := set[f.flag()]
+		file, fileOK := set[f.fileFlag()]
+		if !f.secret {
+			fileOK = false
+		}
+
+		switch {
+		case ok && fileOK:
+			errs.addf("failed to load flag --%s: only one of --%s and --%s can be set", f.flag(), f.flag(), f.fileFlag())
+		case fileOK:
+			if sErr := f.setFile(file); sErr != nil {
+				errs.addf("failed to load flag --%s: %s", f.fileFlag(), sErr)
+			}
+		case ok:
+			if sErr := f.set(raw); sErr != nil {
+				errs.addf("failed to load flag --%s: %s", f.flag(), sErr)
+			}
+		}
+	})
+	return errs.orNil()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
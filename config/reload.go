@@ -0,0 +1,164 @@
+package config
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Reloader is the Config implementation which reloads the config on SIGHUP or when the config file changes.
+//  Only the reloadable fields are updated, see ConfigInfo. The changes of the other fields are refused
+//  until the service is restarted. The config is swapped at once, so Get never returns a partial reload.
+type Reloader struct {
+	args []string
+	path string
+
+	m       *sync.RWMutex
+	config  ConfigInfo
+	modTime time.Time
+
+	stop chan struct{}
+}
+
+// NewReloader loads the config as Load and initializes a new *Reloader instance with it.
+//  @param args []string: command-line arguments, without the program name. They are kept for the reloads.
+//  @return r *Reloader: new *Reloader instance.
+//  @return err error: invalid flags, unreadable config file or invalid config.
+func NewReloader(args []string) (r *Reloader, err error) {
+	conf, path, err := load(args)
+	if err != nil {
+		return
+	}
+
+	r = &Reloader{
+		args:   args,
+		path:   path,
+		m:      &sync.RWMutex{},
+		config: conf,
+		stop:   make(chan struct{}),
+	}
+	r.modTime, _ = r.fileModTime()
+	return
+}
+
+// Get returns the current config, safe to call while it is being reloaded.
+//  @return $1 ConfigInfo: current config.
+func (r *Reloader) Get() ConfigInfo {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.config
+}
+
+// Reload loads the config again from all its sources and updates the reloadable fields.
+//  A invalid config is refused as a whole, keeping the current one.
+//  @return refused []string: paths of the changed fields which can't be reloaded, their current values are kept.
+//  @return err error: invalid flags, unreadable config file or invalid config.
+func (r *Reloader) Reload() (refused []string, err error) {
+	next, _, err := load(r.args)
+	if err != nil {
+		return
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	refused = keepNonReloadable(&next, r.config)
+	r.config = next
+	return
+}
+
+// Run reloads the config on every SIGHUP and when the config file changes, until Stop is called.
+//  It is intended to be run in a goroutine.
+//  @param interval time.Duration: time between the checks of changes in the config file, zero disables them.
+func (r *Reloader) Run(interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var changes <-chan time.Time
+	if interval > 0 && r.path != "" {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		changes = t.C
+	}
+
+	for {
+		select {
+		case <-hup:
+			r.reload("SIGHUP received")
+		case <-changes:
+			modTime, err := r.fileModTime()
+			if err != nil || modTime.Equal(r.modTime) {
+				continue
+			}
+			r.modTime = modTime
+			r.reload("config file " + r.path + " changed")
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the Run loop.
+func (r *Reloader) Stop() {
+	close(r.stop)
+}
+
+// reload reloads the config logging the result.
+func (r *Reloader) reload(reason string) {
+	log.Printf("Reloading config: %s", reason)
+
+	refused, err := r.Reload()
+	if err != nil {
+		log.Printf("failed to reload config, keeping the current one: %s", err)
+		return
+	}
+	for _, path := range refused {
+		log.Printf("Config reload refused the change of %s: it can't be reloaded, restart the service to apply it", path)
+	}
+	log.Println("Config reloaded")
+}
+
+func (r *Reloader) fileModTime() (modTime time.Time, err error) {
+	if r.path == "" {
+		return
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return
+	}
+	modTime = info.ModTime()
+	return
+}
+
+// keepNonReloadable sets the current values to the fields of the next config which can't be reloaded.
+//  @return refused []string: paths of the fields which can't be reloaded with a different next value.
+func keepNonReloadable(next *ConfigInfo, current ConfigInfo) (refused []string) {
+	keepStruct(reflect.ValueOf(next).Elem(), reflect.ValueOf(current), "", &refused)
+	return
+}
+
+func keepStruct(next, current reflect.Value, pathPrefix string, refused *[]string) {
+	t := next.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Tag.Get("reload") == "true" {
+			continue
+		}
+
+		path := pathPrefix + yamlName(sf)
+		n, c := next.Field(i), current.Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			keepStruct(n, c, path+".", refused)
+			continue
+		}
+		if !reflect.DeepEqual(n.Interface(), c.Interface()) {
+			*refused = append(*refused, path)
+			n.Set(c)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloader(t *testing.T) {
+	t.Run("Given a changed config file When reloading Then reloadable fields updated and other changes refused", func(t *testing.T) {
+		setRequiredEnvVars(t)
+		t.Setenv("SRV_ALLOWED_ORIGINS", "")
+		t.Setenv("DB_HOST", "")
+		t.Setenv("SUDO_DURATION_IN_SECS", "")
+		dir := writeConfigFile(t, "test", `
+server:
+  allowed_origins: ["http://localhost:3000"]
+psql:
+  host: db-host
+sudo:
+  duration_in_secs: 300
+`)
+		r, err := NewReloader([]string{"--config-env", "test", "--config-dir", dir})
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "test.yaml"), []byte(`
+server:
+  allowed_origins: ["https://chat.example"]
+psql:
+  host: other-db-host
+oauth:
+  google:
+    client_id: new-id
+    client_secret: new-secret
+    redirect_uris: ["https://chat.example/api/v1/auth/callback/google"]
+sudo:
+  duration_in_secs: 60
+magic_link:
+  max_per_window: 10
+`), 0600))
+
+		refused, err := r.Reload()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"psql.host"}, refused)
+
+		conf := r.Get()
+		assert.Equal(t, []string{"https://chat.example"}, conf.Server.AllowedOrigins)
+		assert.Equal(t, "new-secret", conf.OAuth.Google.ClientSecret)
+		assert.Equal(t, 60, conf.Sudo.DurationInSecs)
+		assert.Equal(t, 10, conf.MagicLink.MaxPerWindow)
+		assert.Equal(t, "db-host", conf.PostgreSQLProperties.Host)
+	})
+
+	t.Run("Given a invalid config file When reloading Then error and current config kept", func(t *testing.T) {
+		setRequiredEnvVars(t)
+		t.Setenv("SUDO_DURATION_IN_SECS", "")
+		dir := writeConfigFile(t, "test", "sudo:\n  duration_in_secs: 60\n")
+		r, err := NewReloader([]string{"--config-env", "test", "--config-dir", dir})
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "test.yaml"), []byte("sudo:\n  duration_in_secs: -1\n"), 0600))
+
+		_, err = r.Reload()
+		assert.IsType(t, ValidationErrors{}, err)
+		assert.Equal(t, 60, r.Get().Sudo.DurationInSecs)
+	})
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coffemanfp/chat/secrets"
+)
+
+// SECRET_REF_PREFIX is the prefix of the values of the secret fields which are resolved by the secret provider,
+// like "secret:db_pass".
+const SECRET_REF_PREFIX = "secret:"
+
+// REDACTED replaces the values of the secret fields when the config is printed.
+const REDACTED = "[REDACTED]"
+
+// resolveSecrets replaces the secret references of the secret fields by its values of the configured secret provider.
+//  All the unresolved references are reported together.
+func resolveSecrets(conf *ConfigInfo) (err error) {
+	p, err := newSecretProvider(conf.Secrets)
+	if err != nil {
+		err = fmt.Errorf("failed to set up secret provider %s: %s", conf.Secrets.Provider, err)
+		return
+	}
+	return resolveSecretsWith(conf, p)
+}
+
+// resolveSecretsWith replaces the secret references of the secret fields by its values of the provider.
+//  @param p secrets.SecretProvider: provider of the secrets, nil if there is none.
+func resolveSecretsWith(conf *ConfigInfo, p secrets.SecretProvider) (err error) {
+	var errs ValidationErrors
+	walkFields(conf, func(f field) {
+		if !f.secret || !strings.HasPrefix(f.value.String(), SECRET_REF_PREFIX) {
+			return
+		}
+		name := strings.TrimPrefix(f.value.String(), SECRET_REF_PREFIX)
+
+		if strings.HasPrefix(f.path, "secrets.") {
+			errs.addf("%s: the secret provider settings can't be secret references", f.path)
+			return
+		}
+		if p == nil {
+			errs.addf("%s: secret %s can't be resolved without a secret provider", f.path, name)
+			return
+		}
+
+		value, sErr := p.Secret(name)
+		if sErr != nil {
+			errs.addf("%s: failed to resolve secret: %s", f.path, sErr)
+			return
+		}
+		f.value.SetString(value)
+	})
+	return errs.orNil()
+}
+
+// newSecretProvider initializes the secret provider of the config, nil if there is none.
+//  The invalid providers are reported by Validate.
+func newSecretProvider(conf secretStore) (p secrets.SecretProvider, err error) {
+	switch conf.Provider {
+	case "file":
+		var key []byte
+		key, err = secrets.ParseKey(conf.Key)
+		if err != nil {
+			return
+		}
+		p, err = secrets.NewEncryptedFileSecretProvider(conf.Path, key)
+	case "vault":
+		p = secrets.NewVaultSecretProvider(conf.VaultAddr, conf.VaultToken, conf.VaultMount)
+	}
+	return
+}
+
+// Redacted gets a copy of the config with the values of the secret fields replaced by REDACTED,
+// so it can be printed or logged.
+//  @return $1 ConfigInfo: redacted copy of the config.
+func (c ConfigInfo) Redacted() ConfigInfo {
+	walkFields(&c, func(f field) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(REDACTED)
+		}
+	})
+	return c
+}
+
+// plainConfigInfo is the ConfigInfo without methods, to format it without calling String again.
+type plainConfigInfo ConfigInfo
+
+// String formats the config with the secrets redacted, so they don't leak when it is printed or logged.
+func (c ConfigInfo) String() string {
+	return fmt.Sprintf("%+v", plainConfigInfo(c.Redacted()))
+}
+
+// GoString formats the config for the %#v verb with the secrets redacted.
+func (c ConfigInfo) GoString() string {
+	return fmt.Sprintf("%#v", plainConfigInfo(c.Redacted()))
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coffemanfp/chat/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSecretFile writes the secret provided in a new temp file, with a trailing line break as the Docker secrets.
+func writeSecretFile(t *testing.T, secret string) (path string) {
+	t.Helper()
+
+	path = filepath.Join(t.TempDir(), "secret")
+	assert.NoError(t, os.WriteFile(path, []byte(secret+"\n"), 0600))
+	return
+}
+
+func TestSecretFiles(t *testing.T) {
+	t.Run("Given a secret file env var When loading config Then secret read from the file", func(t *testing.T) {
+		setRequiredEnvVars(t)
+		t.Setenv("DB_PASS_FILE", writeSecretFile(t, "db-secret"))
+		t.Setenv("OAUTH_GOOGLE_CLIENT_SECRET_FILE", writeSecretFile(t, "google-secret"))
+
+		conf, err := Load(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "db-secret", conf.PostgreSQLProperties.Password)
+		assert.Equal(t, "google-secret", conf.OAuth.Google.ClientSecret)
+	})
+
+	t.Run("Given a secret file flag When loading config Then secret read from the file", func(t *testing.T) {
+		setRequiredEnvVars(t)
+
+		conf, err := Load([]string{"--mail-pass-file", writeSecretFile(t, "mail-secret")})
+		assert.NoError(t, err)
+		assert.Equal(t, "mail-secret", conf.Mail.Password)
+	})
+
+	t.Run("Given a secret env var and its file env var When loading config Then error", func(t *testing.T) {
+		setRequiredEnvVars(t)
+		t.Setenv("DB_PASS", "db-secret")
+		t.Setenv("DB_PASS_FILE", writeSecretFile(t, "db-secret"))
+
+		_, err := Load(nil)
+		assert.EqualError(t, err, "invalid config: failed to load env var DB_PASS: only one of DB_PASS and DB_PASS_FILE can be set")
+	})
+
+	t.Run("Given a file env var of a non secret field When loading config Then ignored", func(t *testing.T) {
+		setRequiredEnvVars(t)
+		t.Setenv("DB_NAME_FILE", writeSecretFile(t, "other"))
+
+		conf, err := Load(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "chat", conf.PostgreSQLProperties.Name)
+	})
+}
+
+func TestSecretProvider(t *testing.T) {
+	t.Run("Given secret references When resolving secrets Then values of the provider", func(t *testing.T) {
+		conf := Defaults()
+		conf.PostgreSQLProperties.Password = "secret:db_pass"
+		conf.PostgreSQLProperties.Name = "secret:not_a_secret_field"
+
+		err := resolveSecretsWith(&conf, secrets.FakeSecretProvider{"db_pass": "db-secret"})
+		assert.NoError(t, err)
+		assert.Equal(t, "db-secret", conf.PostgreSQLProperties.Password)
+		assert.Equal(t, "secret:not_a_secret_field", conf.PostgreSQLProperties.Name)
+	})
+
+	t.Run("Given unknown secret references When resolving secrets Then all the errors together", func(t *testing.T) {
+		conf := Defaults()
+		conf.PostgreSQLProperties.Password = "secret:db_pass"
+		conf.Mail.Password = "secret:mail_pass"
+
+		err := resolveSecretsWith(&conf, secrets.FakeSecretProvider{})
+		assert.EqualError(t, err, "invalid config: "+
+			"psql.password: failed to resolve secret: not found: secret db_pass not found; "+
+			"mail.password: failed to resolve secret: not found: secret mail_pass not found")
+	})
+
+	t.Run("Given a secret reference without provider When loading config Then error", func(t *testing.T) {
+		setRequiredEnvVars(t)
+		t.Setenv("DB_PASS", "secret:db_pass")
+
+		_, err := Load(nil)
+		assert.EqualError(t, err, "invalid config: psql.password: secret db_pass can't be resolved without a secret provider")
+	})
+
+	t.Run("Given a encrypted secrets file When loading config Then secrets resolved", func(t *testing.T) {
+		key := bytes.Repeat([]byte{7}, secrets.KEY_SIZE)
+		raw, err := secrets.EncryptSecrets(key, map[string]string{"db_pass": "db-secret"})
+		assert.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "secrets.enc")
+		assert.NoError(t, os.WriteFile(path, raw, 0600))
+
+		setRequiredEnvVars(t)
+		t.Setenv("SECRETS_PROVIDER", "file")
+		t.Setenv("SECRETS_PATH", path)
+		t.Setenv("SECRETS_KEY_FILE", writeSecretFile(t, base64.StdEncoding.EncodeToString(key)))
+		t.Setenv("DB_PASS", "secret:db_pass")
+
+		conf, err := Load(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "db-secret", conf.PostgreSQLProperties.Password)
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	conf := Defaults()
+	conf.PostgreSQLProperties.User = "chat"
+	conf.PostgreSQLProperties.Password = "db-secret"
+	conf.OAuth.Google.ClientSecret = "google-secret"
+
+	for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+		t.Run(fmt.Sprintf("Given a config with secrets When printing it with %s Then secrets redacted", format), func(t *testing.T) {
+			s := fmt.Sprintf(format, conf)
+			assert.NotContains(t, s, "db-secret")
+			assert.NotContains(t, s, "google-secret")
+			assert.Contains(t, s, REDACTED)
+			assert.Contains(t, s, "chat")
+		})
+	}
+
+	assert.Equal(t, "db-secret", conf.PostgreSQLProperties.Password)
+	assert.Empty(t, conf.Redacted().Mail.Password)
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MIN_COOKIE_KEY_SIZE is the min size in bytes of the session cookie key.
+const MIN_COOKIE_KEY_SIZE = 32
+
+// ValidationErrors keeps all the errors of a invalid config, so they can be fixed at once.
+type ValidationErrors []error
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, err := range v {
+		msgs[i] = err.Error()
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// add adds the error provided, flattening it if they are ValidationErrors too.
+func (v *ValidationErrors) add(err error) {
+	if err == nil {
+		return
+	}
+	if errs, ok := err.(ValidationErrors); ok {
+		*v = append(*v, errs...)
+		return
+	}
+	*v = append(*v, err)
+}
+
+func (v *ValidationErrors) addf(format string, a ...interface{}) {
+	*v = append(*v, fmt.Errorf(format, a...))
+}
+
+// orNil gets the errors as error, nil if there are none.
+func (v ValidationErrors) orNil() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Validate validates the config, reporting all the invalid values together.
+// 	@return err error: ValidationErrors with all the invalid values, nil if the config is valid.
+func (c ConfigInfo) Validate() (err error) {
+	var errs ValidationErrors
+
+	validatePort(&errs, "server.port", c.Server.Port)
+	if len(c.Server.AllowedOrigins) == 0 {
+		errs.addf(`server.allowed_origins: at least one origin is required, use "*" to allow any`)
+	}
+
+	c.PostgreSQLProperties.validate(&errs)
+
+	validatePositive(&errs, "sudo.duration_in_secs", c.Sudo.DurationInSecs)
+
+	c.OAuth.Google.validate(&errs, "oauth.google")
+	c.OAuth.Facebook.validate(&errs, "oauth.facebook")
+
+	validateURL(&errs, "oidc.issuer", c.OIDC.Issuer)
+	validatePositive(&errs, "oidc.authorization_code_ttl_in_secs", c.OIDC.AuthorizationCodeTTLInSecs)
+	validatePositive(&errs, "oidc.access_token_ttl_in_secs", c.OIDC.AccessTokenTTLInSecs)
+	validatePositive(&errs, "oidc.refresh_token_ttl_in_secs", c.OIDC.RefreshTokenTTLInSecs)
+	validatePositive(&errs, "oidc.id_token_ttl_in_secs", c.OIDC.IDTokenTTLInSecs)
+
+	if c.Mail.SMTPHost != "" {
+		validatePort(&errs, "mail.smtp_port", c.Mail.SMTPPort)
+	}
+
+	validateOneOf(&errs, "signing_keys.store", c.SigningKeys.Store, "dir", "database")
+	validateOneOf(&errs, "signing_keys.algorithm", c.SigningKeys.Algorithm, "RS256", "EdDSA")
+	validatePositive(&errs, "signing_keys.rotation_interval_in_secs", c.SigningKeys.RotationIntervalInSecs)
+	validatePositive(&errs, "signing_keys.check_interval_in_secs", c.SigningKeys.CheckIntervalInSecs)
+	// A retired key must stay in the JWKS until the last ID token signed with it expires.
+	if c.SigningKeys.RetentionInSecs < c.OIDC.IDTokenTTLInSecs {
+		errs.addf("signing_keys.retention_in_secs: must be at least oidc.id_token_ttl_in_secs (%d)", c.OIDC.IDTokenTTLInSecs)
+	}
+	// The next key is only activated on time if a check runs while it is prepublished.
+	if c.SigningKeys.CheckIntervalInSecs > c.SigningKeys.PrepublishInSecs {
+		errs.addf("signing_keys.check_interval_in_secs: must be at most signing_keys.prepublish_in_secs (%d)", c.SigningKeys.PrepublishInSecs)
+	}
+
+	validatePositive(&errs, "magic_link.ttl_in_secs", c.MagicLink.TTLInSecs)
+	validatePositive(&errs, "email_code.ttl_in_secs", c.EmailCode.TTLInSecs)
+
+	validateOneOf(&errs, "session.store", c.Session.Store, "cookie", "database")
+	if c.Session.Store == "cookie" {
+		switch {
+		case c.Session.CookieKey == "" && !c.Server.Dev:
+			errs.addf("session.cookie_key: empty value, the cookie store requires it outside the dev mode")
+		case c.Session.CookieKey != "" && len(c.Session.CookieKey) < MIN_COOKIE_KEY_SIZE:
+			errs.addf("session.cookie_key: must have at least %d bytes", MIN_COOKIE_KEY_SIZE)
+		}
+	}
+	validateNonNegative(&errs, "session.idle_timeout_in_secs", c.Session.IdleTimeoutInSecs)
+	validateNonNegative(&errs, "session.absolute_lifetime_in_secs", c.Session.AbsoluteLifetimeInSecs)
+	validatePositive(&errs, "session.touch_interval_in_secs", c.Session.TouchIntervalInSecs)
+	validatePositive(&errs, "session.reaper_interval_in_secs", c.Session.ReaperIntervalInSecs)
+	validatePositive(&errs, "session.remember_me_ttl_in_secs", c.Session.RememberMeTTLInSecs)
+	validatePositive(&errs, "session.purge_after_in_secs", c.Session.PurgeAfterInSecs)
+	validatePositive(&errs, "security.not_me_ttl_in_secs", c.Security.NotMeTTLInSecs)
+	validatePositive(&errs, "security.password_reset_ttl_in_secs", c.Security.PasswordResetTTLInSecs)
+	validatePositive(&errs, "security.impersonation_ttl_in_secs", c.Security.ImpersonationTTLInSecs)
+
+	validateOneOf(&errs, "registration.mode", c.Registration.Mode, "open", "invite", "domain")
+	if c.Registration.Mode == "domain" && len(c.Registration.AllowedDomains) == 0 {
+		errs.addf("registration.allowed_domains: at least one domain is required by the domain registration mode")
+	}
+	validatePositive(&errs, "registration.invite_ttl_in_secs", c.Registration.InviteTTLInSecs)
+	validateOneOf(&errs, "signup.captcha_provider", c.SignUp.CaptchaProvider, "", "hcaptcha", "turnstile", "fake")
+	if c.SignUp.CaptchaProvider != "" {
+		validateRequired(&errs, "signup.captcha_secret", c.SignUp.CaptchaSecret)
+	}
+	validateOneOf(&errs, "email_validation.mode", c.EmailValidation.Mode, "dns", "offline")
+	validateNonNegative(&errs, "email_validation.cache_ttl_in_secs", c.EmailValidation.CacheTTLInSecs)
+	validatePositive(&errs, "email_validation.lookup_timeout_in_secs", c.EmailValidation.LookupTimeoutInSecs)
+	validateNonNegative(&errs, "reload.watch_interval_in_secs", c.Reload.WatchIntervalInSecs)
+
+	validateOneOf(&errs, "secrets.provider", c.Secrets.Provider, "", "file", "vault")
+	switch c.Secrets.Provider {
+	case "file":
+		validateRequired(&errs, "secrets.path", c.Secrets.Path)
+		validateRequired(&errs, "secrets.key", c.Secrets.Key)
+	case "vault":
+		validateURL(&errs, "secrets.vault_addr", c.Secrets.VaultAddr)
+		validateRequired(&errs, "secrets.vault_token", c.Secrets.VaultToken)
+		validateRequired(&errs, "secrets.vault_mount", c.Secrets.VaultMount)
+	}
+
+	return errs.orNil()
+}
+
+// validate validates the PostgreSQL properties, the connection fields only if there is no DSN.
+func (p postgreSQLProperties) validate(errs *ValidationErrors) {
+	if p.DSN == "" {
+		validatePort(errs, "psql.port", p.Port)
+		validateRequired(errs, "psql.host", p.Host)
+		validateRequired(errs, "psql.name", p.Name)
+		validateRequired(errs, "psql.user", p.User)
+		validateOneOf(errs, "psql.sslmode", p.SSLMode, "disable", "require", "verify-ca", "verify-full")
+		if (p.SSLCert == "") != (p.SSLKey == "") {
+			errs.addf("psql.sslcert: the client certificate requires both sslcert and sslkey")
+		}
+	}
+
+	validateNonNegative(errs, "psql.connect_timeout_in_secs", p.ConnectTimeoutInSecs)
+	validateNonNegative(errs, "psql.statement_timeout_in_secs", p.StatementTimeoutInSecs)
+	validateNonNegative(errs, "psql.max_open_conns", p.MaxOpenConns)
+	validateNonNegative(errs, "psql.max_idle_conns", p.MaxIdleConns)
+	validateNonNegative(errs, "psql.conn_max_lifetime_in_secs", p.ConnMaxLifetimeInSecs)
+}
+
+// validate validates the properties of a OAuth platform, only if it's configured with a client id.
+func (o oauthProperties) validate(errs *ValidationErrors, path string) {
+	if o.ClientID == "" {
+		return
+	}
+	validateRequired(errs, path+".client_secret", o.ClientSecret)
+	if len(o.RedirectURIS) == 0 {
+		errs.addf("%s.redirect_uris: at least one redirect URI is required", path)
+	}
+	for _, u := range o.RedirectURIS {
+		validateURL(errs, path+".redirect_uris", u)
+	}
+	validateURL(errs, path+".endpoint.authurl", o.Endpoint.AuthURL)
+	validateURL(errs, path+".endpoint.tokenurl", o.Endpoint.TokenURL)
+}
+
+func validatePort(errs *ValidationErrors, path string, port int) {
+	if port <= 0 || port > 65535 {
+		errs.addf("%s: %d is not a valid port, it must be between 1 and 65535", path, port)
+	}
+}
+
+func validatePositive(errs *ValidationErrors, path string, value int) {
+	if value <= 0 {
+		errs.addf("%s: must be greater than 0", path)
+	}
+}
+
+func validateNonNegative(errs *ValidationErrors, path string, value int) {
+	if value < 0 {
+		errs.addf("%s: %d can't be negative", path, value)
+	}
+}
+
+func validateRequired(errs *ValidationErrors, path, value string) {
+	if value == "" {
+		errs.addf("%s: empty value", path)
+	}
+}
+
+func validateOneOf(errs *ValidationErrors, path, value string, valid ...string) {
+	for _, v := range valid {
+		if value == v {
+			return
+		}
+	}
+	errs.addf("%s: %q is not one of %q", path, value, valid)
+}
+
+func validateURL(errs *ValidationErrors, path, value string) {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		errs.addf("%s: %q is not a valid absolute URL", path, value)
+	}
+}
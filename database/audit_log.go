@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// AUDIT_LOG_REPOSITORY is the key to be used when creating the repositories hashmap.
+const AUDIT_LOG_REPOSITORY RepositoryID = "AUDIT_LOG"
+
+// GetAuditLogRepository gets the AuditLogRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo AuditLogRepository: found AuditLogRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetAuditLogRepository(repoMap map[RepositoryID]interface{}) (repo AuditLogRepository, err error) {
+	repoI, ok := repoMap[AUDIT_LOG_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", AUDIT_LOG_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(AuditLogRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", AUDIT_LOG_REPOSITORY, AUDIT_LOG_REPOSITORY)
+	}
+	return
+}
+
+// AuditLogRepository defines the behaviors to be used by a AuditLogRepository implementation.
+type AuditLogRepository interface {
+	// SaveAuditEntry creates the record of a new audit entry.
+	//  @param entry auth.AuditEntry: entry to be created.
+	//  @return $1 error: failed record creation.
+	SaveAuditEntry(entry auth.AuditEntry) error
+}
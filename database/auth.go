@@ -30,6 +30,7 @@ func GetAuthRepository(repoMap map[RepositoryID]interface{}) (repo AuthRepositor
 // AuthRepository defines the behaviors to be used by a AuthRepository implementation.
 type AuthRepository interface {
 	// GetPasswordHash gets the id and the password hash of the user asked for.
+	//  The user is matched only by its email if it is provided, otherwise by its nickname.
 	//  @param user users.User: user to ask for.
 	//	@return $1 int: id of the user.
 	//  @return $2 string: password hash of the user.
@@ -44,10 +45,17 @@ type AuthRepository interface {
 	//  @return $2 error: failed record creation.
 	SignUp(user users.User, session auth.Session) (int, error)
 
-	// UpsertSession creates or updates the user session.
+	// UpsertSession creates or renews the active session of the user.
+	//  A renewed session gets the login time of the new one, so its lifetime starts again.
 	//  @param session auth.Session: session to create or update.
 	//  @return $1 error: failed record creation or update.
 	UpsertSession(session auth.Session) (int, error)
 
+	// GetSession gets the session asked for.
+	//  @param id int: session id to ask for.
+	//  @return $1 auth.Session: found session.
+	//  @return $2 error: not found session or failed record querying.
+	GetSession(id int) (auth.Session, error)
+
 	SaveSudo(sudo auth.Sudo) error
 }
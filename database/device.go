@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// DEVICE_REPOSITORY is the key to be used when creating the repositories hashmap.
+const DEVICE_REPOSITORY RepositoryID = "DEVICE"
+
+// GetDeviceRepository gets the DeviceRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo DeviceRepository: found DeviceRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetDeviceRepository(repoMap map[RepositoryID]interface{}) (repo DeviceRepository, err error) {
+	repoI, ok := repoMap[DEVICE_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", DEVICE_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(DeviceRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", DEVICE_REPOSITORY, DEVICE_REPOSITORY)
+	}
+	return
+}
+
+// DeviceRepository defines the behaviors to be used by a DeviceRepository implementation.
+type DeviceRepository interface {
+	// GetUserDevices gets the known devices of the user.
+	//  @param userID int: owner of the devices.
+	//  @return $1 []auth.Device: found devices.
+	//  @return $2 error: failed records querying.
+	GetUserDevices(userID int) ([]auth.Device, error)
+
+	// SaveDevice creates the record of a new known device.
+	//  @param device auth.Device: device to be created.
+	//  @return $1 int: new generated ID.
+	//  @return $2 error: failed record creation.
+	SaveDevice(device auth.Device) (int, error)
+
+	// TouchDevice updates the last login of the device.
+	//  @param id int: device id.
+	//  @param sessionID int: session of the login.
+	//  @param seenAt time.Time: time of the login.
+	//  @return $1 error: failed record update.
+	TouchDevice(id, sessionID int, seenAt time.Time) error
+
+	// GetDeviceByRevokeHash gets the device of the "this wasn't me" token hash provided, if the token isn't expired.
+	//  @param hash string: hash of the revoke token.
+	//  @return $1 auth.Device: found device.
+	//  @return $2 error: not found device or failed record querying.
+	GetDeviceByRevokeHash(hash string) (auth.Device, error)
+
+	// DeleteDevice deletes the device, so it is unrecognized again.
+	//  @param id int: device id.
+	//  @return $1 error: failed record deletion.
+	DeleteDevice(id int) error
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// EMAIL_CODE_REPOSITORY is the key to be used when creating the repositories hashmap.
+const EMAIL_CODE_REPOSITORY RepositoryID = "EMAIL_CODE"
+
+// GetEmailCodeRepository gets the EmailCodeRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo EmailCodeRepository: found EmailCodeRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetEmailCodeRepository(repoMap map[RepositoryID]interface{}) (repo EmailCodeRepository, err error) {
+	repoI, ok := repoMap[EMAIL_CODE_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", EMAIL_CODE_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(EmailCodeRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", EMAIL_CODE_REPOSITORY, EMAIL_CODE_REPOSITORY)
+	}
+	return
+}
+
+// EmailCodeRepository defines the behaviors to be used by a EmailCodeRepository implementation.
+type EmailCodeRepository interface {
+	// SaveEmailCode creates the record of a new sent code, invalidating the previous pending codes of the email.
+	//  @param code auth.EmailCode: code to be created.
+	//  @return $1 error: failed record creation.
+	SaveEmailCode(code auth.EmailCode) error
+
+	// GetEmailCode gets the last pending code sent to the email.
+	//  @param email string: email which the code was sent to.
+	//  @return $1 auth.EmailCode: found code.
+	//  @return $2 error: not found, expired or used code, or failed record querying.
+	GetEmailCode(email string) (auth.EmailCode, error)
+
+	// AddEmailCodeAttempt increments the verification attempts of the code.
+	//  @param id int: code id.
+	//  @return $1 int: attempts after the increment.
+	//  @return $2 error: failed record update.
+	AddEmailCodeAttempt(id int) (int, error)
+
+	// ConsumeEmailCode marks as used the code. A code can only be consumed once.
+	//  @param id int: code id.
+	//  @return $1 error: already used code or failed record update.
+	ConsumeEmailCode(id int) error
+
+	// CountEmailCodes counts the codes sent to the email since the time provided.
+	//  @param email string: email which the codes were sent to.
+	//  @param since time.Time: start of the counting window.
+	//  @return $1 int: number of sent codes.
+	//  @return $2 error: failed record querying.
+	CountEmailCodes(email string, since time.Time) (int, error)
+}
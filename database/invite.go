@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// INVITE_REPOSITORY is the key to be used when creating the repositories hashmap.
+const INVITE_REPOSITORY RepositoryID = "INVITE"
+
+// GetInviteRepository gets the InviteRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo InviteRepository: found InviteRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetInviteRepository(repoMap map[RepositoryID]interface{}) (repo InviteRepository, err error) {
+	repoI, ok := repoMap[INVITE_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", INVITE_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(InviteRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", INVITE_REPOSITORY, INVITE_REPOSITORY)
+	}
+	return
+}
+
+// InviteRepository defines the behaviors to be used by a InviteRepository implementation.
+type InviteRepository interface {
+	// SaveInvite creates the record of a new invite.
+	//  @param invite auth.Invite: invite to be created.
+	//  @return $1 int: new generated ID.
+	//  @return $2 error: failed record creation.
+	SaveInvite(invite auth.Invite) (int, error)
+
+	// RevokeInvite revokes the invite, so it can't be used anymore.
+	//  @param id int: invite id.
+	//  @return $1 error: not found invite or failed record update.
+	RevokeInvite(id int) error
+
+	// UseInvite adds a use to the invite if it is still valid at the time provided.
+	//  @param hash string: hash of the invite code.
+	//  @param usedAt time.Time: time of the use.
+	//  @return $1 error: expired, exhausted, revoked or invalid invite, or failed record update.
+	UseInvite(hash string, usedAt time.Time) error
+
+	// ReleaseInvite removes a use from the invite, used when the sign up which used it has failed.
+	//  @param hash string: hash of the invite code.
+	//  @return $1 error: failed record update.
+	ReleaseInvite(hash string) error
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/keys"
+)
+
+// KEY_REPOSITORY is the key to be used when creating the repositories hashmap.
+const KEY_REPOSITORY RepositoryID = "KEY"
+
+// GetKeyRepository gets the KeyRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo KeyRepository: found KeyRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetKeyRepository(repoMap map[RepositoryID]interface{}) (repo KeyRepository, err error) {
+	repoI, ok := repoMap[KEY_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", KEY_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(KeyRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", KEY_REPOSITORY, KEY_REPOSITORY)
+	}
+	return
+}
+
+// KeyRepository defines the behaviors to be used by a KeyRepository implementation.
+//  It is a keys.Store, to keep the signing keys in the database.
+type KeyRepository interface {
+	keys.Store
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// MAGIC_LINK_REPOSITORY is the key to be used when creating the repositories hashmap.
+const MAGIC_LINK_REPOSITORY RepositoryID = "MAGIC_LINK"
+
+// GetMagicLinkRepository gets the MagicLinkRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo MagicLinkRepository: found MagicLinkRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetMagicLinkRepository(repoMap map[RepositoryID]interface{}) (repo MagicLinkRepository, err error) {
+	repoI, ok := repoMap[MAGIC_LINK_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", MAGIC_LINK_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(MagicLinkRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", MAGIC_LINK_REPOSITORY, MAGIC_LINK_REPOSITORY)
+	}
+	return
+}
+
+// MagicLinkRepository defines the behaviors to be used by a MagicLinkRepository implementation.
+type MagicLinkRepository interface {
+	// SaveMagicLink creates the record of a new sent magic link.
+	//  @param link auth.MagicLink: link to be created.
+	//  @return $1 error: failed record creation.
+	SaveMagicLink(link auth.MagicLink) error
+
+	// ConsumeMagicLink marks as used the magic link with the hash provided.
+	//  A link can only be consumed once.
+	//  @param hash string: hash of the link token id.
+	//  @return $1 error: not found or already used link, or failed record update.
+	ConsumeMagicLink(hash string) error
+
+	// CountMagicLinks counts the magic links sent to the email since the time provided.
+	//  @param email string: email which the links were sent to.
+	//  @param since time.Time: start of the counting window.
+	//  @return $1 int: number of sent links.
+	//  @return $2 error: failed record querying.
+	CountMagicLinks(email string, since time.Time) (int, error)
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// OAUTH_REPOSITORY is the key to be used when creating the repositories hashmap.
+const OAUTH_REPOSITORY RepositoryID = "OAUTH"
+
+// GetOAuthRepository gets the OAuthRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo OAuthRepository: found OAuthRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetOAuthRepository(repoMap map[RepositoryID]interface{}) (repo OAuthRepository, err error) {
+	repoI, ok := repoMap[OAUTH_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", OAUTH_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(OAuthRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", OAUTH_REPOSITORY, OAUTH_REPOSITORY)
+	}
+	return
+}
+
+// OAuthRepository defines the behaviors to be used by a OAuthRepository implementation.
+type OAuthRepository interface {
+	// SaveClient creates the record of a new OAuth client.
+	//  @param client auth.OAuthClient: client to be created.
+	//  @return $1 error: failed record creation.
+	SaveClient(client auth.OAuthClient) error
+
+	// GetClient gets the OAuth client asked for.
+	//  @param id string: client id.
+	//  @return $1 auth.OAuthClient: found client.
+	//  @return $2 error: not found client or failed record querying.
+	GetClient(id string) (auth.OAuthClient, error)
+
+	// SaveAuthorizationCode creates the record of a new authorization code.
+	//  @param code auth.AuthorizationCode: code to be created.
+	//  @return $1 error: failed record creation.
+	SaveAuthorizationCode(code auth.AuthorizationCode) error
+
+	// ConsumeAuthorizationCode gets and marks as used the authorization code with the hash provided.
+	//  A code can only be consumed once.
+	//  @param hash string: hash of the code.
+	//  @return $1 auth.AuthorizationCode: consumed code.
+	//  @return $2 error: not found or already used code, or failed record update.
+	ConsumeAuthorizationCode(hash string) (auth.AuthorizationCode, error)
+
+	// SaveToken creates the record of a new OAuth token.
+	//  @param token auth.OAuthToken: token to be created.
+	//  @return $1 int: new generated ID.
+	//  @return $2 error: failed record creation.
+	SaveToken(token auth.OAuthToken) (int, error)
+
+	// GetToken gets the OAuth token with the hash provided.
+	//  @param hash string: hash of the token.
+	//  @return $1 auth.OAuthToken: found token.
+	//  @return $2 error: not found token or failed record querying.
+	GetToken(hash string) (auth.OAuthToken, error)
+
+	// ConsumeToken marks as revoked the OAuth token if it isn't revoked yet, so a token can only be consumed once.
+	//  @param id int: token to consume.
+	//  @return $1 error: already revoked token or failed record update.
+	ConsumeToken(id int) error
+
+	// RevokeToken marks the OAuth token as revoked.
+	//  @param id int: token to revoke.
+	//  @return $1 error: failed record update.
+	RevokeToken(id int) error
+
+	// RevokeClientSessionTokens marks as revoked all the OAuth tokens issued to the client for the session.
+	//  @param clientID string: client of the tokens.
+	//  @param sessionID int: session which granted the tokens.
+	//  @return $1 error: failed record update.
+	RevokeClientSessionTokens(clientID string, sessionID int) error
+}
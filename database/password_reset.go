@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// PASSWORD_RESET_REPOSITORY is the key to be used when creating the repositories hashmap.
+const PASSWORD_RESET_REPOSITORY RepositoryID = "PASSWORD_RESET"
+
+// GetPasswordResetRepository gets the PasswordResetRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo PasswordResetRepository: found PasswordResetRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetPasswordResetRepository(repoMap map[RepositoryID]interface{}) (repo PasswordResetRepository, err error) {
+	repoI, ok := repoMap[PASSWORD_RESET_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", PASSWORD_RESET_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(PasswordResetRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", PASSWORD_RESET_REPOSITORY, PASSWORD_RESET_REPOSITORY)
+	}
+	return
+}
+
+// PasswordResetRepository defines the behaviors to be used by a PasswordResetRepository implementation.
+type PasswordResetRepository interface {
+	// SavePasswordReset creates the record of a new password reset.
+	//  @param reset auth.PasswordReset: reset to be created.
+	//  @return $1 error: failed record creation.
+	SavePasswordReset(reset auth.PasswordReset) error
+
+	// ResetPassword consumes the password reset and sets the new password of its user.
+	//  All the sessions of the user are expired, and its remember series and OAuth tokens revoked.
+	//  @param hash string: hash of the reset token.
+	//  @param password string: hash of the new password.
+	//  @return $1 int: user whose password has been reset.
+	//  @return $2 error: expired, used or invalid reset, or failed record update.
+	ResetPassword(hash, password string) (int, error)
+}
@@ -0,0 +1,44 @@
+package psql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+)
+
+// AuditLogRepository is the implementation of a audit log repository for the PostgreSQL database.
+type AuditLogRepository struct {
+	db *sql.DB
+}
+
+// NewAuditLogRepository initializes a new audit log repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return auditRepo database.AuditLogRepository: is the final interface to keep
+//	 the AuditLogRepository implementation.
+//	@return err error: database connection error.
+func NewAuditLogRepository(conn *PostgreSQLConnector) (auditRepo database.AuditLogRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	auditRepo = AuditLogRepository{
+		db: db,
+	}
+	return
+}
+
+func (a AuditLogRepository) SaveAuditEntry(entry auth.AuditEntry) (err error) {
+	qInsertEntry := `
+		insert into
+			audit_log(actor_id, action, target_user_id, session_id, reason, ip, created_at)
+		values
+			($1, $2, nullif($3, 0), nullif($4, 0), nullif($5, ''), nullif($6, ''), $7)
+	`
+	_, err = a.db.Exec(qInsertEntry, entry.ActorID, entry.Action, entry.TargetUserID, entry.SessionID, entry.Reason, entry.IP, entry.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert %s audit entry of user %d: %s", entry.Action, entry.ActorID, err)
+	}
+	return
+}
@@ -48,14 +48,14 @@ func (u AuthRepository) SignUp(user users.User, session auth.Session) (id int, e
 
 	qInsertUser := `
 		insert into	
-			users(nickname, email, password, picture, created_at)
+			users(nickname, nickname_skeleton, email, password, picture, created_at)
 		values
-			(nullif($1, ''), nullif($2, ''), $3, $4, $5)
+			(nullif($1, ''), nullif($2, ''), nullif($3, ''), $4, $5, $6)
 		returning
 			id
 	`
 
-	err = tx.QueryRow(qInsertUser, user.Nickname, user.Email, user.Password, user.Picture, user.CreatedAt).Scan(&id)
+	err = tx.QueryRow(qInsertUser, user.Nickname, user.NicknameSkeleton, user.Email, user.Password, user.Picture, user.CreatedAt).Scan(&id)
 	if err != nil {
 		var match bool
 		match, err = newPQError(err).asAlreadyExists()
@@ -67,6 +67,22 @@ func (u AuthRepository) SignUp(user users.User, session auth.Session) (id int, e
 		return
 	}
 
+	qInsertUserRole := `
+		insert into
+			user_roles(user_id, role_id, created_at)
+		select
+			$1, id, $3
+		from
+			roles
+		where
+			name = $2
+	`
+	_, err = tx.Exec(qInsertUserRole, id, auth.DEFAULT_ROLE, user.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to assign default role to user %s: %s", user.Nickname, err)
+		return
+	}
+
 	// If the user has been sign with a external platform, insert the external platform sign record.
 	if len(user.SignedWith) > 0 {
 		sign := user.SignedWith[0]
@@ -97,10 +113,8 @@ func (u AuthRepository) UpsertSession(session auth.Session) (id int, err error)
 			user_session(user_id, logged_at, last_seen_at, logged_with, actived)
 		values
 			($1, $2, $3, $4, $5)
-		on conflict (user_id, actived) do update set
-			last_seen_at=$3
-		where
-			user_session.user_id=$1 and user_session.actived
+		on conflict (user_id) where actived and impersonator_id is null do update set
+			logged_at=$2, last_seen_at=$3, logged_with=$4
 		returning
 			id
 	`
@@ -111,17 +125,49 @@ func (u AuthRepository) UpsertSession(session auth.Session) (id int, err error)
 	return
 }
 
+func (u AuthRepository) GetSession(id int) (session auth.Session, err error) {
+	qSelectSession := `
+		select
+			id, user_id, logged_at, last_seen_at, coalesce(logged_with, ''), coalesce(actived, false), coalesce(impersonator_id, 0)
+		from
+			user_session
+		where
+			id = $1
+	`
+	err = u.db.QueryRow(qSelectSession, id).Scan(&session.ID, &session.UserID, &session.LoggedAt, &session.LastSeenAt, &session.LoggedWith, &session.Actived, &session.ImpersonatorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get session %d: %s", id, err)
+	}
+	return
+}
+
 func (u AuthRepository) GetPasswordHash(user users.User) (id int, pass string, err error) {
-	fmt.Println(user)
 	qMatchCredentials := `
 		select
 			id, password
 		from
 			users
 		where
-			nickname = $1 or email = $2
+			lower(email) = $1
 	`
-	err = u.db.QueryRow(qMatchCredentials, user.Nickname, user.Email).Scan(&id, &pass)
+	arg := users.NormalizeEmail(user.Email)
+	if arg == "" {
+		qMatchCredentials = `
+			select
+				id, password
+			from
+				users
+			where
+				nickname_skeleton = $1
+		`
+		arg = users.NicknameSkeleton(user.Nickname)
+	}
+
+	err = u.db.QueryRow(qMatchCredentials, arg).Scan(&id, &pass)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			err = errors.New("not found: user don't exists")
@@ -141,7 +187,7 @@ func (u AuthRepository) SaveSudo(sudo auth.Sudo) (err error) {
 	`
 	_, err = u.db.Exec(qInsertSudo, sudo.SessionID, sudo.DurationInSecs, sudo.CreatedAt)
 	if err != nil {
-		err = fmt.Errorf("failed to save sudo of session %s: %s", sudo.SessionID, err)
+		err = fmt.Errorf("failed to save sudo of session %d: %s", sudo.SessionID, err)
 	}
 	return
 }
@@ -3,22 +3,45 @@ package psql
 import (
 	"database/sql"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
-type properties struct {
-	user string
-	pass string
-	name string
-	host string
-	port int
+// Properties are the connection and pool settings of a PostgreSQLConnector.
+type Properties struct {
+	// DSN is a full connection string. The other connection fields are ignored when it is set, but not the pool ones.
+	DSN string
+
+	User     string
+	Password string
+	Name     string
+	Host     string
+	Port     int
+
+	// SSLMode is the TLS mode, "disable" if it's empty.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// ConnectTimeout and StatementTimeout are only set if they are greater than zero.
+	ConnectTimeout   time.Duration
+	StatementTimeout time.Duration
+	ApplicationName  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
 // PostgreSQLConnector implements a database.DatabaseConnector handler.
 // 	It is a handler for the PostgreSQL connections.
 type PostgreSQLConnector struct {
-	props properties
+	props Properties
 	db    *sql.DB
 }
 
@@ -27,6 +50,9 @@ func (p *PostgreSQLConnector) Connect() (err error) {
 	if err != nil {
 		return
 	}
+	db.SetMaxOpenConns(p.props.MaxOpenConns)
+	db.SetMaxIdleConns(p.props.MaxIdleConns)
+	db.SetConnMaxLifetime(p.props.ConnMaxLifetime)
 
 	err = db.Ping()
 	if err != nil {
@@ -55,24 +81,52 @@ func (p PostgreSQLConnector) getConn() (conn *sql.DB, err error) {
 }
 
 // NewPostgreSQLConnector initializes a new *PostgreSQLConnector.
-// 	@param user string: PostgreSQL connection property.
-// 	@param pass string: PostgreSQL connection property.
-// 	@param name string: PostgreSQL connection property.
-// 	@param host string: PostgreSQL connection property.
-// 	@param port int: PostgreSQL connection property.
+// 	@param props Properties: PostgreSQL connection and pool properties.
 // 	@return conn *PostgreSQLConnector: new *PostgreSQLConnector instance.
-func NewPostgreSQLConnector(user, pass, name, host string, port int) (conn *PostgreSQLConnector) {
+func NewPostgreSQLConnector(props Properties) (conn *PostgreSQLConnector) {
 	return &PostgreSQLConnector{
-		props: properties{
-			user: user,
-			pass: pass,
-			name: name,
-			host: host,
-			port: port,
-		},
+		props: props,
 	}
 }
 
-func connURL(props properties) string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", props.user, props.pass, props.host, props.port, props.name, "disable")
+// connURL gets the connection URL of the properties, with the credentials and the database name escaped.
+func connURL(props Properties) string {
+	if props.DSN != "" {
+		return props.DSN
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(props.User, props.Password),
+		Host:   net.JoinHostPort(props.Host, strconv.Itoa(props.Port)),
+		Path:   "/" + props.Name,
+	}
+	if props.Password == "" {
+		u.User = url.User(props.User)
+	}
+
+	q := url.Values{}
+	q.Set("sslmode", "disable")
+	if props.SSLMode != "" {
+		q.Set("sslmode", props.SSLMode)
+	}
+	setIfNotEmpty(q, "sslrootcert", props.SSLRootCert)
+	setIfNotEmpty(q, "sslcert", props.SSLCert)
+	setIfNotEmpty(q, "sslkey", props.SSLKey)
+	setIfNotEmpty(q, "application_name", props.ApplicationName)
+	if props.ConnectTimeout > 0 {
+		q.Set("connect_timeout", strconv.Itoa(int(props.ConnectTimeout.Seconds())))
+	}
+	if props.StatementTimeout > 0 {
+		// It isn't a connection param, so it's sent as run-time param of the session, in milliseconds.
+		q.Set("statement_timeout", strconv.FormatInt(props.StatementTimeout.Milliseconds(), 10))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func setIfNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
 }
@@ -0,0 +1,159 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// DeviceRepository is the implementation of a device repository for the PostgreSQL database.
+type DeviceRepository struct {
+	db *sql.DB
+}
+
+// NewDeviceRepository initializes a new device repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return deviceRepo database.DeviceRepository: is the final interface to keep
+//	 the DeviceRepository implementation.
+//	@return err error: database connection error.
+func NewDeviceRepository(conn *PostgreSQLConnector) (deviceRepo database.DeviceRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	deviceRepo = DeviceRepository{
+		db: db,
+	}
+	return
+}
+
+const deviceColumns = `
+	id, user_id, coalesce(cookie_hash, ''), user_agent_family, ip_network,
+	coalesce(session_id, 0), coalesce(revoke_hash, ''), coalesce(revoke_expires_at, first_seen_at),
+	first_seen_at, last_seen_at
+`
+
+func scanDevice(row rowScanner) (device auth.Device, err error) {
+	err = row.Scan(
+		&device.ID,
+		&device.UserID,
+		&device.CookieHash,
+		&device.UserAgentFamily,
+		&device.IPNetwork,
+		&device.SessionID,
+		&device.RevokeHash,
+		&device.RevokeExpiresAt,
+		&device.FirstSeenAt,
+		&device.LastSeenAt,
+	)
+	return
+}
+
+func (d DeviceRepository) GetUserDevices(userID int) (devices []auth.Device, err error) {
+	qSelectDevices := `
+		select
+			` + deviceColumns + `
+		from
+			user_device
+		where
+			user_id = $1
+		order by
+			last_seen_at desc
+	`
+	rows, err := d.db.Query(qSelectDevices, userID)
+	if err != nil {
+		err = fmt.Errorf("failed to get devices of user %d: %s", userID, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var device auth.Device
+		device, err = scanDevice(rows)
+		if err != nil {
+			err = fmt.Errorf("failed to scan device of user %d: %s", userID, err)
+			return
+		}
+		devices = append(devices, device)
+	}
+	err = rows.Err()
+	return
+}
+
+func (d DeviceRepository) SaveDevice(device auth.Device) (id int, err error) {
+	qInsertDevice := `
+		insert into
+			user_device(user_id, cookie_hash, user_agent_family, ip_network, session_id, revoke_hash, revoke_expires_at,
+				first_seen_at, last_seen_at)
+		values
+			($1, nullif($2, ''), $3, $4, nullif($5, 0), nullif($6, ''), $7, $8, $9)
+		returning
+			id
+	`
+	err = d.db.QueryRow(
+		qInsertDevice,
+		device.UserID, device.CookieHash, device.UserAgentFamily, device.IPNetwork,
+		device.SessionID, device.RevokeHash, device.RevokeExpiresAt, device.FirstSeenAt, device.LastSeenAt,
+	).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("failed to insert device of user %d: %s", device.UserID, err)
+	}
+	return
+}
+
+func (d DeviceRepository) TouchDevice(id, sessionID int, seenAt time.Time) (err error) {
+	qTouchDevice := `
+		update
+			user_device
+		set
+			session_id = $2,
+			last_seen_at = $3
+		where
+			id = $1
+	`
+	_, err = d.db.Exec(qTouchDevice, id, sessionID, seenAt)
+	if err != nil {
+		err = fmt.Errorf("failed to touch device %d: %s", id, err)
+	}
+	return
+}
+
+func (d DeviceRepository) GetDeviceByRevokeHash(hash string) (device auth.Device, err error) {
+	qSelectDevice := `
+		select
+			` + deviceColumns + `
+		from
+			user_device
+		where
+			revoke_hash = $1 and revoke_expires_at > $2
+	`
+	device, err = scanDevice(d.db.QueryRow(qSelectDevice, hash, time.Now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusNotFound, "not found: device login already revoked, expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get device: %s", err)
+	}
+	return
+}
+
+func (d DeviceRepository) DeleteDevice(id int) (err error) {
+	qDeleteDevice := `
+		delete from
+			user_device
+		where
+			id = $1
+	`
+	_, err = d.db.Exec(qDeleteDevice, id)
+	if err != nil {
+		err = fmt.Errorf("failed to delete device %d: %s", id, err)
+	}
+	return
+}
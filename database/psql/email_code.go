@@ -0,0 +1,156 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// EmailCodeRepository is the implementation of a email code repository for the PostgreSQL database.
+type EmailCodeRepository struct {
+	db *sql.DB
+}
+
+// NewEmailCodeRepository initializes a new email code repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return codeRepo database.EmailCodeRepository: is the final interface to keep
+//	 the EmailCodeRepository implementation.
+//	@return err error: database connection error.
+func NewEmailCodeRepository(conn *PostgreSQLConnector) (codeRepo database.EmailCodeRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	codeRepo = EmailCodeRepository{
+		db: db,
+	}
+	return
+}
+
+func (e EmailCodeRepository) SaveEmailCode(code auth.EmailCode) (err error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	qInvalidateCodes := `
+		update
+			email_code
+		set
+			used_at = $2
+		where
+			email = $1 and used_at is null
+	`
+	_, err = tx.Exec(qInvalidateCodes, code.Email, code.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to invalidate email codes of %s: %s", code.Email, err)
+		return
+	}
+
+	qInsertCode := `
+		insert into
+			email_code(email, hash, expires_at, created_at)
+		values
+			($1, $2, $3, $4)
+	`
+	_, err = tx.Exec(qInsertCode, code.Email, code.Hash, code.ExpiresAt, code.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert email code of %s: %s", code.Email, err)
+	}
+	return
+}
+
+func (e EmailCodeRepository) GetEmailCode(email string) (code auth.EmailCode, err error) {
+	qSelectCode := `
+		select
+			id, email, hash, attempts, expires_at, created_at
+		from
+			email_code
+		where
+			email = $1 and used_at is null and expires_at > $2
+		order by
+			created_at desc
+		limit 1
+	`
+	err = e.db.QueryRow(qSelectCode, email, time.Now()).Scan(&code.ID, &code.Email, &code.Hash, &code.Attempts, &code.ExpiresAt, &code.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid code: code expired, used or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get email code of %s: %s", email, err)
+	}
+	return
+}
+
+func (e EmailCodeRepository) AddEmailCodeAttempt(id int) (attempts int, err error) {
+	qAddAttempt := `
+		update
+			email_code
+		set
+			attempts = attempts + 1
+		where
+			id = $1
+		returning
+			attempts
+	`
+	err = e.db.QueryRow(qAddAttempt, id).Scan(&attempts)
+	if err != nil {
+		err = fmt.Errorf("failed to add attempt to email code %d: %s", id, err)
+	}
+	return
+}
+
+func (e EmailCodeRepository) ConsumeEmailCode(id int) (err error) {
+	qConsumeCode := `
+		update
+			email_code
+		set
+			used_at = $2
+		where
+			id = $1 and used_at is null
+	`
+	res, err := e.db.Exec(qConsumeCode, id, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to consume email code %d: %s", id, err)
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid code: code expired, used or invalid")
+	}
+	return
+}
+
+func (e EmailCodeRepository) CountEmailCodes(email string, since time.Time) (n int, err error) {
+	qCountCodes := `
+		select
+			count(*)
+		from
+			email_code
+		where
+			email = $1 and created_at >= $2
+	`
+	err = e.db.QueryRow(qCountCodes, email, since).Scan(&n)
+	if err != nil {
+		err = fmt.Errorf("failed to count email codes of %s: %s", email, err)
+	}
+	return
+}
@@ -2,6 +2,7 @@ package psql
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/lib/pq"
@@ -25,8 +26,18 @@ func (p pqErrHandler) asAlreadyExists() (match bool, err error) {
 	return
 }
 
+// detailFieldRegex matches the column of the key of a unique violation detail,
+//  including the keys of a expression index like "Key (lower(email::text))=(...)".
+var detailFieldRegex = regexp.MustCompile(`\((?:\w+\()?(\w+)`)
+
 func getFieldFromDetail(pqErr *pq.Error) string {
-	return pqErr.Detail[strings.Index(pqErr.Detail, "(")+1 : strings.Index(pqErr.Detail, ")")]
+	m := detailFieldRegex.FindStringSubmatch(pqErr.Detail)
+	if m == nil {
+		return ""
+	}
+
+	// The skeleton columns are internal, the conflict is reported on the field provided by the user.
+	return strings.TrimSuffix(m[1], "_skeleton")
 }
 
 func newPQError(pqErr error) pqErrHandler {
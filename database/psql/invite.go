@@ -0,0 +1,116 @@
+package psql
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// InviteRepository is the implementation of a registration invite repository for the PostgreSQL database.
+type InviteRepository struct {
+	db *sql.DB
+}
+
+// NewInviteRepository initializes a new invite repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return inviteRepo database.InviteRepository: is the final interface to keep
+//	 the InviteRepository implementation.
+//	@return err error: database connection error.
+func NewInviteRepository(conn *PostgreSQLConnector) (inviteRepo database.InviteRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	inviteRepo = InviteRepository{
+		db: db,
+	}
+	return
+}
+
+func (i InviteRepository) SaveInvite(invite auth.Invite) (id int, err error) {
+	qInsertInvite := `
+		insert into
+			invite(hash, created_by, max_uses, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5)
+		returning
+			id
+	`
+	err = i.db.QueryRow(qInsertInvite, invite.Hash, invite.CreatedBy, invite.MaxUses, invite.ExpiresAt, invite.CreatedAt).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("failed to insert invite of user %d: %s", invite.CreatedBy, err)
+	}
+	return
+}
+
+func (i InviteRepository) RevokeInvite(id int) (err error) {
+	qRevokeInvite := `
+		update
+			invite
+		set
+			revoked_at = $2
+		where
+			id = $1 and revoked_at is null
+	`
+	res, err := i.db.Exec(qRevokeInvite, id, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to revoke invite %d: %s", id, err)
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: invite %d not found or already revoked", id)
+	}
+	return
+}
+
+func (i InviteRepository) UseInvite(hash string, usedAt time.Time) (err error) {
+	// The use is only added while the invite is valid, so concurrent sign ups can't exceed its max uses.
+	qUseInvite := `
+		update
+			invite
+		set
+			uses = uses + 1
+		where
+			hash = $1 and revoked_at is null and expires_at > $2 and uses < max_uses
+	`
+	res, err := i.db.Exec(qUseInvite, hash, usedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to use invite: %s", err)
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusForbidden, "invalid invite: invite expired, revoked, exhausted or invalid")
+	}
+	return
+}
+
+func (i InviteRepository) ReleaseInvite(hash string) (err error) {
+	qReleaseInvite := `
+		update
+			invite
+		set
+			uses = uses - 1
+		where
+			hash = $1 and uses > 0
+	`
+	_, err = i.db.Exec(qReleaseInvite, hash)
+	if err != nil {
+		err = fmt.Errorf("failed to release invite: %s", err)
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package psql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/coffemanfp/chat/database"
+	"github.com/coffemanfp/chat/keys"
+)
+
+// KeyRepository is the implementation of a signing keys repository for the PostgreSQL database.
+type KeyRepository struct {
+	db *sql.DB
+}
+
+// NewKeyRepository initializes a new signing keys repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return keyRepo database.KeyRepository: is the final interface to keep
+//	 the KeyRepository implementation.
+//	@return err error: database connection error.
+func NewKeyRepository(conn *PostgreSQLConnector) (keyRepo database.KeyRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	keyRepo = KeyRepository{
+		db: db,
+	}
+	return
+}
+
+func (k KeyRepository) GetKeys() (ks []keys.Key, err error) {
+	rows, err := k.db.Query(`select kid, private_key from signing_key`)
+	if err != nil {
+		err = fmt.Errorf("failed to get signing keys: %s", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kid, raw string
+		err = rows.Scan(&kid, &raw)
+		if err != nil {
+			err = fmt.Errorf("failed to read signing keys: %s", err)
+			return
+		}
+
+		var key keys.Key
+		key, err = keys.ParsePEM([]byte(raw))
+		if err != nil {
+			err = fmt.Errorf("failed to load signing key %s: %s", kid, err)
+			return
+		}
+		ks = append(ks, key)
+	}
+	err = rows.Err()
+	return
+}
+
+func (k KeyRepository) SaveKey(key keys.Key) (err error) {
+	raw, err := key.MarshalPEM()
+	if err != nil {
+		return
+	}
+
+	qInsertKey := `
+		insert into
+			signing_key(kid, algorithm, private_key, created_at, activates_at, retires_at, expires_at)
+		values
+			($1, $2, $3, $4, $5, $6, $7)
+		on conflict (kid) do nothing
+	`
+	_, err = k.db.Exec(qInsertKey, key.ID, key.Algorithm, string(raw), key.CreatedAt, key.ActivatesAt, key.RetiresAt, key.ExpiresAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert signing key %s: %s", key.ID, err)
+	}
+	return
+}
+
+func (k KeyRepository) DeleteKey(kid string) (err error) {
+	_, err = k.db.Exec(`delete from signing_key where kid = $1`, kid)
+	if err != nil {
+		err = fmt.Errorf("failed to delete signing key %s: %s", kid, err)
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package psql
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// MagicLinkRepository is the implementation of a magic link repository for the PostgreSQL database.
+type MagicLinkRepository struct {
+	db *sql.DB
+}
+
+// NewMagicLinkRepository initializes a new magic link repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return linkRepo database.MagicLinkRepository: is the final interface to keep
+//	 the MagicLinkRepository implementation.
+//	@return err error: database connection error.
+func NewMagicLinkRepository(conn *PostgreSQLConnector) (linkRepo database.MagicLinkRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	linkRepo = MagicLinkRepository{
+		db: db,
+	}
+	return
+}
+
+func (m MagicLinkRepository) SaveMagicLink(link auth.MagicLink) (err error) {
+	qInsertLink := `
+		insert into
+			magic_link(hash, user_id, email, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5)
+	`
+	_, err = m.db.Exec(qInsertLink, link.Hash, link.UserID, link.Email, link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert magic link of user %d: %s", link.UserID, err)
+	}
+	return
+}
+
+func (m MagicLinkRepository) ConsumeMagicLink(hash string) (err error) {
+	qConsumeLink := `
+		update
+			magic_link
+		set
+			used_at = $2
+		where
+			hash = $1 and used_at is null and expires_at > $2
+	`
+	res, err := m.db.Exec(qConsumeLink, hash, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to consume magic link: %s", err)
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid magic link: link expired, used or invalid")
+	}
+	return
+}
+
+func (m MagicLinkRepository) CountMagicLinks(email string, since time.Time) (n int, err error) {
+	qCountLinks := `
+		select
+			count(*)
+		from
+			magic_link
+		where
+			email = $1 and created_at >= $2
+	`
+	err = m.db.QueryRow(qCountLinks, email, since).Scan(&n)
+	if err != nil {
+		err = fmt.Errorf("failed to count magic links of %s: %s", email, err)
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/coffemanfp/chat/users"
+)
+
+// migrationStep is a change of a migration which can't be done in SQL.
+type migrationStep func(ctx context.Context, tx *sql.Tx) error
+
+// migrationSteps are the Go steps of the migrations by version.
+//  A step runs after the up sql of its migration, in the same transaction.
+var migrationSteps = map[int]migrationStep{
+	19: backfillNicknameSkeletons,
+}
+
+// nicknameRecord is the nickname of a user and its skeleton.
+type nicknameRecord struct {
+	id       int
+	nickname string
+	skeleton string
+}
+
+// backfillNicknameSkeletons sets the skeletons of all the nicknames with users.NicknameSkeleton.
+func backfillNicknameSkeletons(ctx context.Context, tx *sql.Tx) (err error) {
+	rows, err := tx.QueryContext(ctx, `select id, nickname from users where nickname is not null order by id`)
+	if err != nil {
+		err = fmt.Errorf("failed to get nicknames: %s", err)
+		return
+	}
+
+	var records []nicknameRecord
+	for rows.Next() {
+		var r nicknameRecord
+		err = rows.Scan(&r.id, &r.nickname)
+		if err != nil {
+			rows.Close()
+			err = fmt.Errorf("failed to scan nickname: %s", err)
+			return
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("failed to get nicknames: %s", err)
+		return
+	}
+
+	// The old skeletons are cleared first, so a update doesn't conflict with a skeleton not recomputed yet.
+	_, err = tx.ExecContext(ctx, `update users set nickname_skeleton = null`)
+	if err != nil {
+		err = fmt.Errorf("failed to clear nickname skeletons: %s", err)
+		return
+	}
+
+	for _, r := range resolveNicknameSkeletons(records) {
+		_, err = tx.ExecContext(ctx, `update users set nickname = $2, nickname_skeleton = $3 where id = $1`, r.id, r.nickname, r.skeleton)
+		if err != nil {
+			err = fmt.Errorf("failed to update nickname skeleton of user %d: %s", r.id, err)
+			return
+		}
+	}
+	return
+}
+
+// resolveNicknameSkeletons gets the skeletons of the nicknames.
+//  The oldest nickname keeps a skeleton, the newer ones with the same skeleton are renamed with the id
+//  of their user as suffix.
+//  @param records []nicknameRecord: nicknames sorted by user id.
+//  @return resolved []nicknameRecord: nicknames with unique skeletons, in the same order.
+func resolveNicknameSkeletons(records []nicknameRecord) (resolved []nicknameRecord) {
+	taken := map[string]bool{}
+	for _, r := range records {
+		original := r.nickname
+		r.skeleton = users.NicknameSkeleton(r.nickname)
+		for n := 1; taken[r.skeleton]; n++ {
+			r.nickname = original + "_" + strconv.Itoa(r.id)
+			if n > 1 {
+				r.nickname += "_" + strconv.Itoa(n)
+			}
+			r.skeleton = users.NicknameSkeleton(r.nickname)
+		}
+		if r.nickname != original {
+			log.Printf("Renamed nickname %s of user %d to %s, its skeleton is taken by a older user", original, r.id, r.nickname)
+		}
+		taken[r.skeleton] = true
+		resolved = append(resolved, r)
+	}
+	return
+}
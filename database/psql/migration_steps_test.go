@@ -0,0 +1,49 @@
+package psql
+
+import (
+	"testing"
+
+	"github.com/coffemanfp/chat/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationSteps(t *testing.T) {
+	t.Run("Given the migration steps When getting the migrations Then every step has a migration", func(t *testing.T) {
+		list, err := migrations.All()
+		assert.NoError(t, err)
+
+		known := map[int]bool{}
+		for _, m := range list {
+			known[m.Version] = true
+		}
+		for version := range migrationSteps {
+			assert.True(t, known[version], "step of unknown migration %d", version)
+		}
+	})
+}
+
+func TestResolveNicknameSkeletons(t *testing.T) {
+	t.Run("Given existing nicknames When resolving their skeletons Then computed like on the sign up", func(t *testing.T) {
+		resolved := resolveNicknameSkeletons([]nicknameRecord{
+			{id: 1, nickname: "Alice"},
+			{id: 2, nickname: "bob"},
+		})
+		assert.Equal(t, []nicknameRecord{
+			{id: 1, nickname: "Alice", skeleton: "allce"},
+			{id: 2, nickname: "bob", skeleton: "bob"},
+		}, resolved)
+	})
+
+	t.Run("Given confusable nicknames When resolving their skeletons Then newer ones renamed", func(t *testing.T) {
+		resolved := resolveNicknameSkeletons([]nicknameRecord{
+			{id: 1, nickname: "alice"},
+			{id: 4, nickname: "A1ice"},
+			{id: 7, nickname: "alice_4"},
+		})
+		assert.Equal(t, []nicknameRecord{
+			{id: 1, nickname: "alice", skeleton: "allce"},
+			{id: 4, nickname: "A1ice_4", skeleton: "allce_4"},
+			{id: 7, nickname: "alice_4_7", skeleton: "allce_4_7"},
+		}, resolved)
+	})
+}
@@ -0,0 +1,281 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coffemanfp/chat/migrations"
+)
+
+// MIGRATIONS_LOCK_ID is the key of the advisory lock which serializes the migration runs of many instances.
+const MIGRATIONS_LOCK_ID = 4_873_112_409
+
+// MigrationStatus is the state of a migration in the database.
+type MigrationStatus struct {
+	Migration migrations.Migration
+	Applied   bool
+	AppliedAt time.Time
+
+	// Modified is set when the migration was changed after it was applied.
+	Modified bool
+
+	// Unknown is set when the applied migration isn't known by this version, so it can't be reverted.
+	Unknown bool
+}
+
+// appliedMigration is a record of the schema_migrations table.
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator runs the versioned migrations of the PostgreSQL database.
+//  The applied migrations are kept in the schema_migrations table with their checksum, and every run holds
+//  a advisory lock, so many instances starting at once don't run the same migrations.
+type Migrator struct {
+	db         *sql.DB
+	migrations []migrations.Migration
+}
+
+// NewMigrator initializes a new Migrator instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+// 	@param list []migrations.Migration: known migrations sorted by version.
+//	@return m Migrator: new Migrator instance.
+//	@return err error: database connection error.
+func NewMigrator(conn *PostgreSQLConnector, list []migrations.Migration) (m Migrator, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	m = Migrator{
+		db:         db,
+		migrations: list,
+	}
+	return
+}
+
+// Up applies all the pending migrations in order, each one in its own transaction.
+//  Nothing is applied if a applied migration was modified.
+//  @return applied []migrations.Migration: applied migrations, even if a later one failed.
+//  @return err error: modified migration or database error.
+func (m Migrator) Up() (applied []migrations.Migration, err error) {
+	err = m.withLock(func(ctx context.Context, conn *sql.Conn) (err error) {
+		records, err := m.applied(ctx, conn)
+		if err != nil {
+			return
+		}
+
+		var pending []migrations.Migration
+		for _, mig := range m.migrations {
+			r, ok := records[mig.Version]
+			if !ok {
+				pending = append(pending, mig)
+				continue
+			}
+			if r.checksum != mig.Checksum() {
+				err = fmt.Errorf("invalid migration: %s was modified after it was applied", mig)
+				return
+			}
+		}
+
+		for _, mig := range pending {
+			err = m.run(ctx, conn, mig, mig.Up, migrationSteps[mig.Version], func(tx *sql.Tx) (err error) {
+				_, err = tx.ExecContext(ctx,
+					`insert into schema_migrations(version, name, checksum, applied_at) values ($1, $2, $3, $4)`,
+					mig.Version, mig.Name, mig.Checksum(), time.Now(),
+				)
+				return
+			})
+			if err != nil {
+				return
+			}
+			applied = append(applied, mig)
+		}
+		return
+	})
+	return
+}
+
+// Down reverts the last applied migrations in reverse order, each one in its own transaction.
+//	@param steps int: number of migrations to revert.
+//	@return reverted []migrations.Migration: reverted migrations, even if a later one failed.
+//	@return err error: unknown applied migration or database error.
+func (m Migrator) Down(steps int) (reverted []migrations.Migration, err error) {
+	err = m.withLock(func(ctx context.Context, conn *sql.Conn) (err error) {
+		records, err := m.applied(ctx, conn)
+		if err != nil {
+			return
+		}
+
+		known := map[int]migrations.Migration{}
+		for _, mig := range m.migrations {
+			known[mig.Version] = mig
+		}
+
+		for len(reverted) < steps && len(records) > 0 {
+			last := appliedMigration{version: -1}
+			for _, r := range records {
+				if r.version > last.version {
+					last = r
+				}
+			}
+
+			mig, ok := known[last.version]
+			if !ok {
+				err = fmt.Errorf("invalid migration: %04d_%s is unknown by this version, it can't be reverted", last.version, last.name)
+				return
+			}
+
+			err = m.run(ctx, conn, mig, mig.Down, nil, func(tx *sql.Tx) (err error) {
+				_, err = tx.ExecContext(ctx, `delete from schema_migrations where version = $1`, mig.Version)
+				return
+			})
+			if err != nil {
+				return
+			}
+			delete(records, last.version)
+			reverted = append(reverted, mig)
+		}
+		return
+	})
+	return
+}
+
+// Status gets the state of the known migrations, and of the applied ones which are unknown by this version.
+//	@return status []MigrationStatus: states sorted by version.
+//	@return err error: database error.
+func (m Migrator) Status() (status []MigrationStatus, err error) {
+	err = m.withLock(func(ctx context.Context, conn *sql.Conn) (err error) {
+		records, err := m.applied(ctx, conn)
+		if err != nil {
+			return
+		}
+
+		for _, mig := range m.migrations {
+			s := MigrationStatus{Migration: mig}
+			if r, ok := records[mig.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = r.appliedAt
+				s.Modified = r.checksum != mig.Checksum()
+				delete(records, mig.Version)
+			}
+			status = append(status, s)
+		}
+
+		for _, r := range records {
+			status = append(status, MigrationStatus{
+				Migration: migrations.Migration{Version: r.version, Name: r.name},
+				Applied:   true,
+				AppliedAt: r.appliedAt,
+				Unknown:   true,
+			})
+		}
+		sort.Slice(status, func(i, j int) bool {
+			return status[i].Migration.Version < status[j].Migration.Version
+		})
+		return
+	})
+	return
+}
+
+// withLock runs fn holding the migrations advisory lock, with the schema_migrations table created.
+func (m Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) (err error) {
+	ctx := context.Background()
+
+	// The advisory locks belong to a database session, so everything runs in the same connection.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to get migrations connection: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `select pg_advisory_lock($1)`, MIGRATIONS_LOCK_ID)
+	if err != nil {
+		err = fmt.Errorf("failed to lock migrations: %s", err)
+		return
+	}
+	defer conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, MIGRATIONS_LOCK_ID)
+
+	qCreateSchemaMigrations := `
+		create table if not exists schema_migrations (
+			version integer unique not null,
+			name varchar not null,
+			checksum varchar not null,
+			applied_at timestamp not null,
+
+			primary key (version)
+		)
+	`
+	_, err = conn.ExecContext(ctx, qCreateSchemaMigrations)
+	if err != nil {
+		err = fmt.Errorf("failed to create schema_migrations table: %s", err)
+		return
+	}
+
+	return fn(ctx, conn)
+}
+
+// applied gets the applied migrations by version.
+func (m Migrator) applied(ctx context.Context, conn *sql.Conn) (records map[int]appliedMigration, err error) {
+	rows, err := conn.QueryContext(ctx, `select version, name, checksum, applied_at from schema_migrations`)
+	if err != nil {
+		err = fmt.Errorf("failed to get applied migrations: %s", err)
+		return
+	}
+	defer rows.Close()
+
+	records = map[int]appliedMigration{}
+	for rows.Next() {
+		var r appliedMigration
+		err = rows.Scan(&r.version, &r.name, &r.checksum, &r.appliedAt)
+		if err != nil {
+			err = fmt.Errorf("failed to scan applied migration: %s", err)
+			return
+		}
+		records[r.version] = r
+	}
+	err = rows.Err()
+	return
+}
+
+// run runs the sql of the migration, its Go step if it isn't nil and the record update in the same transaction.
+func (m Migrator) run(ctx context.Context, conn *sql.Conn, mig migrations.Migration, query string, step migrationStep, record func(tx *sql.Tx) error) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("failed to begin migration %s: %s", mig, err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, query)
+	if err != nil {
+		err = fmt.Errorf("failed to run migration %s: %s", mig, err)
+		return
+	}
+
+	if step != nil {
+		err = step(ctx, tx)
+		if err != nil {
+			err = fmt.Errorf("failed to run migration %s: %s", mig, err)
+			return
+		}
+	}
+
+	err = record(tx)
+	if err != nil {
+		err = fmt.Errorf("failed to record migration %s: %s", mig, err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("failed to commit migration %s: %s", mig, err)
+	}
+	return
+}
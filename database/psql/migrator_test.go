@@ -0,0 +1,82 @@
+package psql
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestMigrator gets a migrator of the first known migrations over the empty database of the TEST_DB_DSN env var.
+//  The test is skipped without it, and every migration is reverted when it finishes.
+func newTestMigrator(t *testing.T, n int) (m Migrator, conn *PostgreSQLConnector) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN isn't set, the migration tests need a empty PostgreSQL database")
+	}
+
+	list, err := migrations.All()
+	assert.NoError(t, err)
+
+	conn = NewPostgreSQLConnector(Properties{DSN: dsn})
+	assert.NoError(t, conn.Connect())
+
+	all, err := NewMigrator(conn, list)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := all.Down(len(list))
+		assert.NoError(t, err)
+	})
+
+	m, err = NewMigrator(conn, list[:n])
+	assert.NoError(t, err)
+	return
+}
+
+func TestEmailCaseInsensitiveMigration(t *testing.T) {
+	t.Run("Given emails which only differ in casing When migrating Then the oldest keeps the email", func(t *testing.T) {
+		m, conn := newTestMigrator(t, 17)
+		_, err := m.Up()
+		assert.NoError(t, err)
+
+		db, err := conn.getConn()
+		assert.NoError(t, err)
+		for _, email := range []string{"Bob@x.com", "bob@x.com", "BOB@X.COM", "Alice@x.com"} {
+			_, err = db.Exec(`insert into users(email, created_at) values ($1, $2)`, email, time.Now())
+			assert.NoError(t, err)
+		}
+
+		list, err := migrations.All()
+		assert.NoError(t, err)
+		m, err = NewMigrator(conn, list[:18])
+		assert.NoError(t, err)
+		_, err = m.Up()
+		assert.NoError(t, err)
+
+		rows, err := db.Query(`select id, email from users order by id`)
+		assert.NoError(t, err)
+		defer rows.Close()
+
+		var ids []int
+		var emails []string
+		for rows.Next() {
+			var id int
+			var email string
+			assert.NoError(t, rows.Scan(&id, &email))
+			ids = append(ids, id)
+			emails = append(emails, email)
+		}
+		assert.Len(t, ids, 4)
+		assert.Equal(t, []string{
+			"bob@x.com",
+			"bob+dup" + strconv.Itoa(ids[1]) + "@x.com",
+			"bob+dup" + strconv.Itoa(ids[2]) + "@x.com",
+			"alice@x.com",
+		}, emails)
+	})
+}
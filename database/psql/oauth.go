@@ -0,0 +1,201 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/lib/pq"
+)
+
+// OAuthRepository is the implementation of a OAuth provider repository for the PostgreSQL database.
+type OAuthRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthRepository initializes a new OAuth repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return oauthRepo database.OAuthRepository: is the final interface to keep
+//	 the OAuthRepository implementation.
+//	@return err error: database connection error.
+func NewOAuthRepository(conn *PostgreSQLConnector) (oauthRepo database.OAuthRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	oauthRepo = OAuthRepository{
+		db: db,
+	}
+	return
+}
+
+func (o OAuthRepository) SaveClient(client auth.OAuthClient) (err error) {
+	qInsertClient := `
+		insert into
+			oauth_client(id, name, secret_hash, redirect_uris, scopes, created_at)
+		values
+			($1, $2, nullif($3, ''), $4, $5, $6)
+	`
+	_, err = o.db.Exec(qInsertClient, client.ID, client.Name, client.SecretHash, pq.Array(client.RedirectURIs), pq.Array(client.Scopes), client.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert oauth client %s: %s", client.Name, err)
+	}
+	return
+}
+
+func (o OAuthRepository) GetClient(id string) (client auth.OAuthClient, err error) {
+	qSelectClient := `
+		select
+			id, name, coalesce(secret_hash, ''), redirect_uris, scopes, created_at
+		from
+			oauth_client
+		where
+			id = $1
+	`
+	err = o.db.QueryRow(qSelectClient, id).Scan(&client.ID, &client.Name, &client.SecretHash, pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes), &client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_client: client %s don't exists", id)
+			return
+		}
+		err = fmt.Errorf("failed to get oauth client %s: %s", id, err)
+	}
+	return
+}
+
+func (o OAuthRepository) SaveAuthorizationCode(code auth.AuthorizationCode) (err error) {
+	qInsertCode := `
+		insert into
+			oauth_authorization_code(hash, client_id, user_id, session_id, redirect_uri, scopes,
+				code_challenge, code_challenge_method, nonce, auth_time, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5, $6, $7, $8, nullif($9, ''), $10, $11, $12)
+	`
+	_, err = o.db.Exec(qInsertCode, code.Hash, code.ClientID, code.UserID, code.SessionID, code.RedirectURI, pq.Array(code.Scopes),
+		code.CodeChallenge, code.CodeChallengeMethod, code.Nonce, code.AuthTime, code.ExpiresAt, code.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert authorization code of client %s: %s", code.ClientID, err)
+	}
+	return
+}
+
+func (o OAuthRepository) ConsumeAuthorizationCode(hash string) (code auth.AuthorizationCode, err error) {
+	qConsumeCode := `
+		update
+			oauth_authorization_code
+		set
+			used_at = $2
+		where
+			hash = $1 and used_at is null
+		returning
+			hash, client_id, user_id, session_id, redirect_uri, scopes,
+			code_challenge, code_challenge_method, coalesce(nonce, ''), auth_time, expires_at, created_at
+	`
+	err = o.db.QueryRow(qConsumeCode, hash, time.Now()).Scan(&code.Hash, &code.ClientID, &code.UserID, &code.SessionID, &code.RedirectURI, pq.Array(&code.Scopes),
+		&code.CodeChallenge, &code.CodeChallengeMethod, &code.Nonce, &code.AuthTime, &code.ExpiresAt, &code.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: authorization code expired, used or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to consume authorization code: %s", err)
+	}
+	return
+}
+
+func (o OAuthRepository) SaveToken(token auth.OAuthToken) (id int, err error) {
+	qInsertToken := `
+		insert into
+			oauth_token(hash, type, client_id, user_id, session_id, scopes, auth_time, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		returning
+			id
+	`
+	err = o.db.QueryRow(qInsertToken, token.Hash, token.Type, token.ClientID, token.UserID, token.SessionID, pq.Array(token.Scopes),
+		token.AuthTime, token.ExpiresAt, token.CreatedAt).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("failed to insert oauth %s of client %s: %s", token.Type, token.ClientID, err)
+	}
+	return
+}
+
+func (o OAuthRepository) GetToken(hash string) (token auth.OAuthToken, err error) {
+	qSelectToken := `
+		select
+			id, hash, type, client_id, user_id, session_id, scopes, auth_time, expires_at, created_at, revoked_at
+		from
+			oauth_token
+		where
+			hash = $1
+	`
+	err = o.db.QueryRow(qSelectToken, hash).Scan(&token.ID, &token.Hash, &token.Type, &token.ClientID, &token.UserID, &token.SessionID, pq.Array(&token.Scopes),
+		&token.AuthTime, &token.ExpiresAt, &token.CreatedAt, &token.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_token: token expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get oauth token: %s", err)
+	}
+	return
+}
+
+func (o OAuthRepository) ConsumeToken(id int) (err error) {
+	qConsumeToken := `
+		update
+			oauth_token
+		set
+			revoked_at = $2
+		where
+			id = $1 and revoked_at is null
+		returning
+			id
+	`
+	err = o.db.QueryRow(qConsumeToken, id, time.Now()).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: refresh token expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to consume oauth token %d: %s", id, err)
+	}
+	return
+}
+
+func (o OAuthRepository) RevokeToken(id int) (err error) {
+	qRevokeToken := `
+		update
+			oauth_token
+		set
+			revoked_at = $2
+		where
+			id = $1 and revoked_at is null
+	`
+	_, err = o.db.Exec(qRevokeToken, id, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to revoke oauth token %d: %s", id, err)
+	}
+	return
+}
+
+func (o OAuthRepository) RevokeClientSessionTokens(clientID string, sessionID int) (err error) {
+	qRevokeTokens := `
+		update
+			oauth_token
+		set
+			revoked_at = $3
+		where
+			client_id = $1 and session_id = $2 and revoked_at is null
+	`
+	_, err = o.db.Exec(qRevokeTokens, clientID, sessionID, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to revoke oauth tokens of client %s for session %d: %s", clientID, sessionID, err)
+	}
+	return
+}
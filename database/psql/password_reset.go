@@ -0,0 +1,100 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// PasswordResetRepository is the implementation of a password reset repository for the PostgreSQL database.
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+// NewPasswordResetRepository initializes a new password reset repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return resetRepo database.PasswordResetRepository: is the final interface to keep
+//	 the PasswordResetRepository implementation.
+//	@return err error: database connection error.
+func NewPasswordResetRepository(conn *PostgreSQLConnector) (resetRepo database.PasswordResetRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	resetRepo = PasswordResetRepository{
+		db: db,
+	}
+	return
+}
+
+func (p PasswordResetRepository) SavePasswordReset(reset auth.PasswordReset) (err error) {
+	qInsertReset := `
+		insert into
+			password_reset(hash, user_id, expires_at, created_at)
+		values
+			($1, $2, $3, $4)
+	`
+	_, err = p.db.Exec(qInsertReset, reset.Hash, reset.UserID, reset.ExpiresAt, reset.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert password reset of user %d: %s", reset.UserID, err)
+	}
+	return
+}
+
+func (p PasswordResetRepository) ResetPassword(hash, password string) (userID int, err error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	qConsumeReset := `
+		update
+			password_reset
+		set
+			used_at = $2
+		where
+			hash = $1 and used_at is null and expires_at > $2
+		returning
+			user_id
+	`
+	err = tx.QueryRow(qConsumeReset, hash, time.Now()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid password reset: token expired, used or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to consume password reset: %s", err)
+		return
+	}
+
+	qUpdatePassword := `
+		update
+			users
+		set
+			password = $2
+		where
+			id = $1
+	`
+	_, err = tx.Exec(qUpdatePassword, userID, password)
+	if err != nil {
+		err = fmt.Errorf("failed to update password of user %d: %s", userID, err)
+		return
+	}
+
+	// Anyone who knew the old password is logged out with the new one.
+	err = revokeUserSessions(tx, userID, time.Now())
+	return
+}
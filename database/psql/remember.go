@@ -0,0 +1,110 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// RememberTokenRepository is the implementation of a remember token repository for the PostgreSQL database.
+type RememberTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRememberTokenRepository initializes a new remember token repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return rememberRepo database.RememberTokenRepository: is the final interface to keep
+//	 the RememberTokenRepository implementation.
+//	@return err error: database connection error.
+func NewRememberTokenRepository(conn *PostgreSQLConnector) (rememberRepo database.RememberTokenRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	rememberRepo = RememberTokenRepository{
+		db: db,
+	}
+	return
+}
+
+func (r RememberTokenRepository) SaveRememberToken(token auth.RememberToken) (err error) {
+	qInsertToken := `
+		insert into
+			remember_token(series, hash, user_id, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5)
+	`
+	_, err = r.db.Exec(qInsertToken, token.Series, token.Hash, token.UserID, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert remember token of user %d: %s", token.UserID, err)
+	}
+	return
+}
+
+func (r RememberTokenRepository) GetRememberToken(series string) (token auth.RememberToken, err error) {
+	qSelectToken := `
+		select
+			series, hash, user_id, expires_at, created_at, last_used_at, revoked_at
+		from
+			remember_token
+		where
+			series = $1
+	`
+	err = r.db.QueryRow(qSelectToken, series).Scan(&token.Series, &token.Hash, &token.UserID, &token.ExpiresAt, &token.CreatedAt, &token.LastUsedAt, &token.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: remember token expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get remember token: %s", err)
+	}
+	return
+}
+
+func (r RememberTokenRepository) RotateRememberToken(series, oldHash, newHash string, usedAt time.Time) (err error) {
+	qRotateToken := `
+		update
+			remember_token
+		set
+			hash = $3,
+			last_used_at = $4
+		where
+			series = $1 and hash = $2 and revoked_at is null
+	`
+	res, err := r.db.Exec(qRotateToken, series, oldHash, newHash, usedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to rotate remember token: %s", err)
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: remember token expired or invalid")
+	}
+	return
+}
+
+func (r RememberTokenRepository) RevokeRememberToken(series string) (err error) {
+	qRevokeToken := `
+		update
+			remember_token
+		set
+			revoked_at = $2
+		where
+			series = $1 and revoked_at is null
+	`
+	_, err = r.db.Exec(qRevokeToken, series, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to revoke remember token: %s", err)
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// RoleRepository is the implementation of a role repository for the PostgreSQL database.
+type RoleRepository struct {
+	db *sql.DB
+}
+
+// NewRoleRepository initializes a new role repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return roleRepo database.RoleRepository: is the final interface to keep
+//	 the RoleRepository implementation.
+//	@return err error: database connection error.
+func NewRoleRepository(conn *PostgreSQLConnector) (roleRepo database.RoleRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	roleRepo = RoleRepository{
+		db: db,
+	}
+	return
+}
+
+func (r RoleRepository) GetUserRoles(userID int) (roles []auth.Role, err error) {
+	qSelectRoles := `
+		select
+			r.id, r.name, coalesce(p.name, '')
+		from
+			user_roles ur
+			inner join roles r on r.id = ur.role_id
+			left join role_permissions rp on rp.role_id = r.id
+			left join permissions p on p.id = rp.permission_id
+		where
+			ur.user_id = $1
+		order by
+			r.id
+	`
+	rows, err := r.db.Query(qSelectRoles, userID)
+	if err != nil {
+		err = fmt.Errorf("failed to get roles of user %d: %s", userID, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role auth.Role
+		var permission string
+		err = rows.Scan(&role.ID, &role.Name, &permission)
+		if err != nil {
+			err = fmt.Errorf("failed to read roles of user %d: %s", userID, err)
+			return
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].ID != role.ID {
+			roles = append(roles, role)
+		}
+		if permission != "" {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, auth.Permission(permission))
+		}
+	}
+	err = rows.Err()
+	return
+}
+
+func (r RoleRepository) AssignRole(userID int, role string) (err error) {
+	var roleID int
+	err = r.db.QueryRow(`select id from roles where name = $1`, role).Scan(&roleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusNotFound, "not found: role %s don't exists", role)
+			return
+		}
+		err = fmt.Errorf("failed to get role %s: %s", role, err)
+		return
+	}
+
+	qInsertUserRole := `
+		insert into
+			user_roles(user_id, role_id)
+		values
+			($1, $2)
+		on conflict do nothing
+	`
+	_, err = r.db.Exec(qInsertUserRole, userID, roleID)
+	if err != nil {
+		err = fmt.Errorf("failed to assign role %s to user %d: %s", role, userID, err)
+	}
+	return
+}
+
+func (r RoleRepository) RevokeRole(userID int, role string) (err error) {
+	qDeleteUserRole := `
+		delete from
+			user_roles ur
+		using
+			roles r
+		where
+			ur.role_id = r.id and ur.user_id = $1 and r.name = $2
+	`
+	_, err = r.db.Exec(qDeleteUserRole, userID, role)
+	if err != nil {
+		err = fmt.Errorf("failed to revoke role %s of user %d: %s", role, userID, err)
+	}
+	return
+}
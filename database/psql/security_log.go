@@ -0,0 +1,76 @@
+package psql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+)
+
+// SecurityLogRepository is the implementation of a security log repository for the PostgreSQL database.
+type SecurityLogRepository struct {
+	db *sql.DB
+}
+
+// NewSecurityLogRepository initializes a new security log repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return logRepo database.SecurityLogRepository: is the final interface to keep
+//	 the SecurityLogRepository implementation.
+//	@return err error: database connection error.
+func NewSecurityLogRepository(conn *PostgreSQLConnector) (logRepo database.SecurityLogRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	logRepo = SecurityLogRepository{
+		db: db,
+	}
+	return
+}
+
+func (s SecurityLogRepository) SaveSecurityEvent(event auth.SecurityEvent) (err error) {
+	qInsertEvent := `
+		insert into
+			security_event(user_id, type, method, ip, user_agent, session_id, created_at)
+		values
+			($1, $2, nullif($3, ''), nullif($4, ''), nullif($5, ''), nullif($6, 0), $7)
+	`
+	_, err = s.db.Exec(qInsertEvent, event.UserID, event.Type, event.Method, event.IP, event.UserAgent, event.SessionID, event.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert %s security event of user %d: %s", event.Type, event.UserID, err)
+	}
+	return
+}
+
+func (s SecurityLogRepository) GetSecurityEvents(userID, before, limit int) (events []auth.SecurityEvent, err error) {
+	qSelectEvents := `
+		select
+			id, user_id, type, coalesce(method, ''), coalesce(ip, ''), coalesce(user_agent, ''), coalesce(session_id, 0), created_at
+		from
+			security_event
+		where
+			user_id = $1 and ($2 = 0 or id < $2)
+		order by
+			id desc
+		limit $3
+	`
+	rows, err := s.db.Query(qSelectEvents, userID, before, limit)
+	if err != nil {
+		err = fmt.Errorf("failed to get security events of user %d: %s", userID, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e auth.SecurityEvent
+		err = rows.Scan(&e.ID, &e.UserID, &e.Type, &e.Method, &e.IP, &e.UserAgent, &e.SessionID, &e.CreatedAt)
+		if err != nil {
+			err = fmt.Errorf("failed to scan security event of user %d: %s", userID, err)
+			return
+		}
+		events = append(events, e)
+	}
+	err = rows.Err()
+	return
+}
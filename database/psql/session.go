@@ -0,0 +1,232 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// SessionRepository is the implementation of a session lifetime repository for the PostgreSQL database.
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository initializes a new session repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return sessionRepo database.SessionRepository: is the final interface to keep
+//	 the SessionRepository implementation.
+//	@return err error: database connection error.
+func NewSessionRepository(conn *PostgreSQLConnector) (sessionRepo database.SessionRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	sessionRepo = SessionRepository{
+		db: db,
+	}
+	return
+}
+
+func (s SessionRepository) TouchSession(id int, seenAt time.Time) (err error) {
+	qTouchSession := `
+		update
+			user_session
+		set
+			last_seen_at = $2
+		where
+			id = $1
+	`
+	_, err = s.db.Exec(qTouchSession, id, seenAt)
+	if err != nil {
+		err = fmt.Errorf("failed to touch session %d: %s", id, err)
+	}
+	return
+}
+
+func (s SessionRepository) ExpireSession(id int) (err error) {
+	qExpireSession := `
+		update
+			user_session
+		set
+			actived = false
+		where
+			id = $1
+	`
+	_, err = s.db.Exec(qExpireSession, id)
+	if err != nil {
+		err = fmt.Errorf("failed to expire session %d: %s", id, err)
+	}
+	return
+}
+
+func (s SessionRepository) RevokeUserSessions(userID int) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	err = revokeUserSessions(tx, userID, time.Now())
+	return
+}
+
+// revokeUserSessions marks as inactive all the sessions of the user, and revokes its remember series, OAuth tokens
+// and personal access tokens, in the transaction provided.
+func revokeUserSessions(tx *sql.Tx, userID int, now time.Time) (err error) {
+	qExpireSessions := `
+		update
+			user_session
+		set
+			actived = false
+		where
+			user_id = $1 and actived
+	`
+	_, err = tx.Exec(qExpireSessions, userID)
+	if err != nil {
+		err = fmt.Errorf("failed to expire sessions of user %d: %s", userID, err)
+		return
+	}
+
+	qRevokeRememberTokens := `
+		update
+			remember_token
+		set
+			revoked_at = $2
+		where
+			user_id = $1 and revoked_at is null
+	`
+	_, err = tx.Exec(qRevokeRememberTokens, userID, now)
+	if err != nil {
+		err = fmt.Errorf("failed to revoke remember tokens of user %d: %s", userID, err)
+		return
+	}
+
+	qRevokeOAuthTokens := `
+		update
+			oauth_token
+		set
+			revoked_at = $2
+		where
+			user_id = $1 and revoked_at is null
+	`
+	_, err = tx.Exec(qRevokeOAuthTokens, userID, now)
+	if err != nil {
+		err = fmt.Errorf("failed to revoke oauth tokens of user %d: %s", userID, err)
+		return
+	}
+
+	qRevokeAccessTokens := `
+		update
+			access_token
+		set
+			revoked_at = $2
+		where
+			user_id = $1 and revoked_at is null
+	`
+	_, err = tx.Exec(qRevokeAccessTokens, userID, now)
+	if err != nil {
+		err = fmt.Errorf("failed to revoke access tokens of user %d: %s", userID, err)
+	}
+	return
+}
+
+func (s SessionRepository) ExpireSessions(idleBefore, loggedBefore time.Time) (n int64, err error) {
+	qExpireSessions := `
+		update
+			user_session
+		set
+			actived = false
+		where
+			actived and (last_seen_at < $1 or logged_at < $2)
+	`
+	res, err := s.db.Exec(qExpireSessions, idleBefore, loggedBefore)
+	if err != nil {
+		err = fmt.Errorf("failed to expire sessions: %s", err)
+		return
+	}
+	n, err = res.RowsAffected()
+	return
+}
+
+func (s SessionRepository) PurgeSessions(before time.Time) (n int64, err error) {
+	qPurgeSessions := `
+		delete from
+			user_session
+		where
+			not coalesce(actived, false) and last_seen_at < $1
+	`
+	res, err := s.db.Exec(qPurgeSessions, before)
+	if err != nil {
+		err = fmt.Errorf("failed to purge sessions: %s", err)
+		return
+	}
+	n, err = res.RowsAffected()
+	return
+}
+
+func (s SessionRepository) PurgeSudos(before time.Time) (n int64, err error) {
+	qPurgeSudos := `
+		delete from
+			sudo
+		where
+			created_at + make_interval(secs => duration_in_secs) < $1
+	`
+	res, err := s.db.Exec(qPurgeSudos, before)
+	if err != nil {
+		err = fmt.Errorf("failed to purge sudos: %s", err)
+		return
+	}
+	n, err = res.RowsAffected()
+	return
+}
+
+func (s SessionRepository) CreateImpersonationSession(session auth.Session) (id int, err error) {
+	qInsertSession := `
+		insert into
+			user_session(user_id, logged_at, last_seen_at, logged_with, actived, impersonator_id)
+		values
+			($1, $2, $3, $4, $5, $6)
+		returning
+			id
+	`
+	err = s.db.QueryRow(qInsertSession, session.UserID, session.LoggedAt, session.LastSeenAt, session.LoggedWith, session.Actived, session.ImpersonatorID).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("failed to create impersonation session of user %d: %s", session.UserID, err)
+	}
+	return
+}
+
+func (s SessionRepository) GetLastSudo(sessionID int) (sudo auth.Sudo, err error) {
+	qSelectSudo := `
+		select
+			id, session_id, duration_in_secs, created_at
+		from
+			sudo
+		where
+			session_id = $1
+		order by
+			created_at desc
+		limit 1
+	`
+	err = s.db.QueryRow(qSelectSudo, sessionID).Scan(&sudo.ID, &sudo.SessionID, &sudo.DurationInSecs, &sudo.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusNotFound, "not found: session %d has no sudo", sessionID)
+			return
+		}
+		err = fmt.Errorf("failed to get sudo of session %d: %s", sessionID, err)
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package psql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevokeUserSessions(t *testing.T) {
+	t.Run("Given a user with a personal access token When revoking its sessions Then the token is revoked", func(t *testing.T) {
+		list, err := migrations.All()
+		assert.NoError(t, err)
+		m, conn := newTestMigrator(t, len(list))
+		_, err = m.Up()
+		assert.NoError(t, err)
+
+		db, err := conn.getConn()
+		assert.NoError(t, err)
+		var userID int
+		err = db.QueryRow(`insert into users(email, created_at) values ($1, $2) returning id`, "bob@x.com", time.Now()).Scan(&userID)
+		assert.NoError(t, err)
+
+		tokenRepo, err := NewTokenRepository(conn)
+		assert.NoError(t, err)
+		token, _, err := auth.NewAccessToken(userID, "cli", nil, nil)
+		assert.NoError(t, err)
+		_, err = tokenRepo.SaveAccessToken(token)
+		assert.NoError(t, err)
+
+		sessionRepo, err := NewSessionRepository(conn)
+		assert.NoError(t, err)
+		assert.NoError(t, sessionRepo.RevokeUserSessions(userID))
+
+		tokens, err := tokenRepo.GetAccessTokens(userID)
+		assert.NoError(t, err)
+		assert.Len(t, tokens, 1)
+		assert.NotNil(t, tokens[0].RevokedAt)
+	})
+}
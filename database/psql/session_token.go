@@ -0,0 +1,89 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// SessionTokenRepository is the implementation of a session token repository for the PostgreSQL database.
+type SessionTokenRepository struct {
+	db *sql.DB
+}
+
+// NewSessionTokenRepository initializes a new session token repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return tokenRepo database.SessionTokenRepository: is the final interface to keep
+//	 the SessionTokenRepository implementation.
+//	@return err error: database connection error.
+func NewSessionTokenRepository(conn *PostgreSQLConnector) (tokenRepo database.SessionTokenRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	tokenRepo = SessionTokenRepository{
+		db: db,
+	}
+	return
+}
+
+func (s SessionTokenRepository) GetSessionToken(hash string) (token auth.SessionToken, err error) {
+	qSelectToken := `
+		select
+			st.hash, coalesce(st.session_id, 0), st.data, st.expires_at, st.created_at, st.updated_at
+		from
+			session_token st
+		left join
+			user_session us on us.id = st.session_id
+		where
+			st.hash = $1 and st.expires_at > $2 and (st.session_id is null or us.actived)
+	`
+	err = s.db.QueryRow(qSelectToken, hash, time.Now()).Scan(&token.Hash, &token.SessionID, &token.Values, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusNotFound, "not found: session token expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get session token: %s", err)
+	}
+	return
+}
+
+func (s SessionTokenRepository) SaveSessionToken(token auth.SessionToken) (err error) {
+	qUpsertToken := `
+		insert into
+			session_token(hash, session_id, data, expires_at, created_at, updated_at)
+		values
+			($1, nullif($2, 0), $3, $4, $5, $6)
+		on conflict (hash) do update set
+			session_id = excluded.session_id,
+			data = excluded.data,
+			expires_at = excluded.expires_at,
+			updated_at = excluded.updated_at
+	`
+	_, err = s.db.Exec(qUpsertToken, token.Hash, token.SessionID, token.Values, token.ExpiresAt, token.CreatedAt, token.UpdatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to save session token: %s", err)
+	}
+	return
+}
+
+func (s SessionTokenRepository) DeleteSessionToken(hash string) (err error) {
+	qDeleteToken := `
+		delete from
+			session_token
+		where
+			hash = $1
+	`
+	_, err = s.db.Exec(qDeleteToken, hash)
+	if err != nil {
+		err = fmt.Errorf("failed to delete session token: %s", err)
+	}
+	return
+}
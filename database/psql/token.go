@@ -0,0 +1,181 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/lib/pq"
+)
+
+// TokenRepository is the implementation of a personal access token repository for the PostgreSQL database.
+type TokenRepository struct {
+	db *sql.DB
+}
+
+// NewTokenRepository initializes a new token repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return tokenRepo database.TokenRepository: is the final interface to keep
+//	 the TokenRepository implementation.
+//	@return err error: database connection error.
+func NewTokenRepository(conn *PostgreSQLConnector) (tokenRepo database.TokenRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	tokenRepo = TokenRepository{
+		db: db,
+	}
+	return
+}
+
+const selectAccessTokenFields = `
+	id, user_id, name, prefix, hash, scopes, expires_at, last_used_at, coalesce(last_used_ip, ''), created_at, revoked_at
+`
+
+func (t TokenRepository) SaveAccessToken(token auth.AccessToken) (id int, err error) {
+	qInsertToken := `
+		insert into
+			access_token(user_id, name, prefix, hash, scopes, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5, $6, $7)
+		returning
+			id
+	`
+	err = t.db.QueryRow(qInsertToken, token.UserID, token.Name, token.Prefix, token.Hash, pq.Array(permissionsToStrings(token.Scopes)), token.ExpiresAt, token.CreatedAt).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("failed to insert access token %s of user %d: %s", token.Name, token.UserID, err)
+	}
+	return
+}
+
+func (t TokenRepository) GetAccessTokens(userID int) (tokens []auth.AccessToken, err error) {
+	qSelectTokens := `
+		select ` + selectAccessTokenFields + `
+		from
+			access_token
+		where
+			user_id = $1
+		order by
+			created_at desc
+	`
+	rows, err := t.db.Query(qSelectTokens, userID)
+	if err != nil {
+		err = fmt.Errorf("failed to get access tokens of user %d: %s", userID, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token auth.AccessToken
+		token, err = scanAccessToken(rows)
+		if err != nil {
+			err = fmt.Errorf("failed to read access tokens of user %d: %s", userID, err)
+			return
+		}
+		tokens = append(tokens, token)
+	}
+	err = rows.Err()
+	return
+}
+
+func (t TokenRepository) GetAccessTokenByHash(hash string) (token auth.AccessToken, err error) {
+	qSelectToken := `
+		select ` + selectAccessTokenFields + `
+		from
+			access_token
+		where
+			hash = $1
+	`
+	token, err = scanAccessToken(t.db.QueryRow(qSelectToken, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: access token expired or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to get access token: %s", err)
+	}
+	return
+}
+
+func (t TokenRepository) RevokeAccessToken(userID, id int) (err error) {
+	qRevokeToken := `
+		update
+			access_token
+		set
+			revoked_at = $3
+		where
+			id = $1 and user_id = $2 and revoked_at is null
+	`
+	res, err := t.db.Exec(qRevokeToken, id, userID, time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to revoke access token %d of user %d: %s", id, userID, err)
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: access token %d don't exists", id)
+	}
+	return
+}
+
+func (t TokenRepository) TouchAccessToken(id int, usedAt time.Time, ip string) (err error) {
+	qTouchToken := `
+		update
+			access_token
+		set
+			last_used_at = $2, last_used_ip = $3
+		where
+			id = $1
+	`
+	_, err = t.db.Exec(qTouchToken, id, usedAt, ip)
+	if err != nil {
+		err = fmt.Errorf("failed to update last usage of access token %d: %s", id, err)
+	}
+	return
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccessToken(row rowScanner) (token auth.AccessToken, err error) {
+	var scopes []string
+	err = row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Prefix,
+		&token.Hash,
+		pq.Array(&scopes),
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.LastUsedIP,
+		&token.CreatedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		return
+	}
+	for _, s := range scopes {
+		token.Scopes = append(token.Scopes, auth.Permission(s))
+	}
+	return
+}
+
+func permissionsToStrings(permissions []auth.Permission) (s []string) {
+	s = make([]string, len(permissions))
+	for i, p := range permissions {
+		s[i] = string(p)
+	}
+	return
+}
@@ -2,6 +2,12 @@ package psql
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/users"
 )
 
 // UsersRepository is the implementation of a user repository for the PostgreSQL database.
@@ -23,3 +29,107 @@ func NewUsersRepository(conn *PostgreSQLConnector) (usersRepo UsersRepository, e
 	}
 	return
 }
+
+func (u UsersRepository) GetUser(id int) (user users.User, err error) {
+	qSelectUser := `
+		select
+			id, coalesce(nickname, ''), coalesce(email, ''), coalesce(picture, ''), created_at
+		from
+			users
+		where
+			id = $1
+	`
+	err = u.db.QueryRow(qSelectUser, id).Scan(&user.ID, &user.Nickname, &user.Email, &user.Picture, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusNotFound, "not found: user %d don't exists", id)
+			return
+		}
+		err = fmt.Errorf("failed to get user %d: %s", id, err)
+	}
+	return
+}
+
+func (u UsersRepository) GetUserByEmail(email string) (user users.User, err error) {
+	qSelectUser := `
+		select
+			id, coalesce(nickname, ''), coalesce(email, ''), coalesce(picture, ''), created_at
+		from
+			users
+		where
+			lower(email) = $1
+	`
+	err = u.db.QueryRow(qSelectUser, users.NormalizeEmail(email)).Scan(&user.ID, &user.Nickname, &user.Email, &user.Picture, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusNotFound, "not found: user %s don't exists", email)
+			return
+		}
+		err = fmt.Errorf("failed to get user %s: %s", email, err)
+	}
+	return
+}
+
+func (u UsersRepository) UpdateNickname(id int, nickname, skeleton string) (err error) {
+	qUpdateNickname := `
+		update
+			users
+		set
+			nickname = $2, nickname_skeleton = $3
+		where
+			id = $1
+	`
+	res, err := u.db.Exec(qUpdateNickname, id, nickname, skeleton)
+	if err != nil {
+		var match bool
+		match, err = newPQError(err).asAlreadyExists()
+		if match {
+			err = sErrors.NewClientError(http.StatusConflict, err.Error())
+		} else {
+			err = fmt.Errorf("failed to update nickname of user %d: %s", id, err)
+		}
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("failed to update nickname of user %d: %s", id, err)
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: user %d don't exists", id)
+	}
+	return
+}
+
+func (u UsersRepository) UpdateEmail(id int, email string) (err error) {
+	qUpdateEmail := `
+		update
+			users
+		set
+			email = $2
+		where
+			id = $1
+	`
+	res, err := u.db.Exec(qUpdateEmail, id, email)
+	if err != nil {
+		var match bool
+		match, err = newPQError(err).asAlreadyExists()
+		if match {
+			err = sErrors.NewClientError(http.StatusConflict, err.Error())
+		} else {
+			err = fmt.Errorf("failed to update email of user %d: %s", id, err)
+		}
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("failed to update email of user %d: %s", id, err)
+		return
+	}
+	if n == 0 {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: user %d don't exists", id)
+	}
+	return
+}
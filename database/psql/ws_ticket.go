@@ -0,0 +1,70 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+// WSTicketRepository is the implementation of a WebSocket ticket repository for the PostgreSQL database.
+type WSTicketRepository struct {
+	db *sql.DB
+}
+
+// NewWSTicketRepository initializes a new WebSocket ticket repository instance.
+// 	@param conn *PostgreSQLConnector: is the PostgreSQLConnector handler.
+//	@return ticketRepo database.WSTicketRepository: is the final interface to keep
+//	 the WSTicketRepository implementation.
+//	@return err error: database connection error.
+func NewWSTicketRepository(conn *PostgreSQLConnector) (ticketRepo database.WSTicketRepository, err error) {
+	db, err := conn.getConn()
+	if err != nil {
+		return
+	}
+	ticketRepo = WSTicketRepository{
+		db: db,
+	}
+	return
+}
+
+func (w WSTicketRepository) SaveWSTicket(ticket auth.WSTicket) (err error) {
+	qInsertTicket := `
+		insert into
+			ws_ticket(hash, user_id, session_id, origin, expires_at, created_at)
+		values
+			($1, $2, $3, $4, $5, $6)
+	`
+	_, err = w.db.Exec(qInsertTicket, ticket.Hash, ticket.UserID, ticket.SessionID, ticket.Origin, ticket.ExpiresAt, ticket.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to insert ws ticket of session %d: %s", ticket.SessionID, err)
+	}
+	return
+}
+
+func (w WSTicketRepository) ConsumeWSTicket(hash string) (ticket auth.WSTicket, err error) {
+	qConsumeTicket := `
+		update
+			ws_ticket
+		set
+			used_at = $2
+		where
+			hash = $1 and used_at is null
+		returning
+			hash, user_id, session_id, origin, expires_at, created_at
+	`
+	err = w.db.QueryRow(qConsumeTicket, hash, time.Now()).Scan(&ticket.Hash, &ticket.UserID, &ticket.SessionID, &ticket.Origin, &ticket.ExpiresAt, &ticket.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid ticket: ticket expired, used or invalid")
+			return
+		}
+		err = fmt.Errorf("failed to consume ws ticket: %s", err)
+	}
+	return
+}
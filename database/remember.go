@@ -0,0 +1,55 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// REMEMBER_TOKEN_REPOSITORY is the key to be used when creating the repositories hashmap.
+const REMEMBER_TOKEN_REPOSITORY RepositoryID = "REMEMBER_TOKEN"
+
+// GetRememberTokenRepository gets the RememberTokenRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo RememberTokenRepository: found RememberTokenRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetRememberTokenRepository(repoMap map[RepositoryID]interface{}) (repo RememberTokenRepository, err error) {
+	repoI, ok := repoMap[REMEMBER_TOKEN_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", REMEMBER_TOKEN_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(RememberTokenRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", REMEMBER_TOKEN_REPOSITORY, REMEMBER_TOKEN_REPOSITORY)
+	}
+	return
+}
+
+// RememberTokenRepository defines the behaviors to be used by a RememberTokenRepository implementation.
+type RememberTokenRepository interface {
+	// SaveRememberToken creates a new persistent login series.
+	//  @param token auth.RememberToken: series to be created.
+	//  @return $1 error: failed record creation.
+	SaveRememberToken(token auth.RememberToken) error
+
+	// GetRememberToken gets the persistent login series asked for.
+	//  @param series string: series identifier.
+	//  @return $1 auth.RememberToken: found series.
+	//  @return $2 error: not found series or failed record querying.
+	GetRememberToken(series string) (auth.RememberToken, error)
+
+	// RotateRememberToken replaces the token of the series, only if its current token is the one provided.
+	//  @param series string: series identifier.
+	//  @param oldHash string: hash of the current token.
+	//  @param newHash string: hash of the new token.
+	//  @param usedAt time.Time: time of the rotation.
+	//  @return $1 error: token already rotated or failed record update.
+	RotateRememberToken(series, oldHash, newHash string, usedAt time.Time) error
+
+	// RevokeRememberToken revokes the whole series.
+	//  @param series string: series identifier.
+	//  @return $1 error: failed record update.
+	RevokeRememberToken(series string) error
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// ROLE_REPOSITORY is the key to be used when creating the repositories hashmap.
+const ROLE_REPOSITORY RepositoryID = "ROLE"
+
+// GetRoleRepository gets the RoleRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo RoleRepository: found RoleRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetRoleRepository(repoMap map[RepositoryID]interface{}) (repo RoleRepository, err error) {
+	repoI, ok := repoMap[ROLE_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", ROLE_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(RoleRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", ROLE_REPOSITORY, ROLE_REPOSITORY)
+	}
+	return
+}
+
+// RoleRepository defines the behaviors to be used by a RoleRepository implementation.
+type RoleRepository interface {
+	// GetUserRoles gets the roles, with its permissions, assigned to the user.
+	//  @param userID int: user to ask for.
+	//  @return $1 []auth.Role: roles of the user.
+	//  @return $2 error: failed record querying.
+	GetUserRoles(userID int) ([]auth.Role, error)
+
+	// AssignRole assigns the role to the user. Assigning an already assigned role does nothing.
+	//  @param userID int: user to assign the role.
+	//  @param role string: name of the role to assign.
+	//  @return $1 error: not found role or failed record creation.
+	AssignRole(userID int, role string) error
+
+	// RevokeRole removes the role from the user.
+	//  @param userID int: user to remove the role.
+	//  @param role string: name of the role to remove.
+	//  @return $1 error: failed record deletion.
+	RevokeRole(userID int, role string) error
+}
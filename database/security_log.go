@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// SECURITY_LOG_REPOSITORY is the key to be used when creating the repositories hashmap.
+const SECURITY_LOG_REPOSITORY RepositoryID = "SECURITY_LOG"
+
+// GetSecurityLogRepository gets the SecurityLogRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo SecurityLogRepository: found SecurityLogRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetSecurityLogRepository(repoMap map[RepositoryID]interface{}) (repo SecurityLogRepository, err error) {
+	repoI, ok := repoMap[SECURITY_LOG_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", SECURITY_LOG_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(SecurityLogRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", SECURITY_LOG_REPOSITORY, SECURITY_LOG_REPOSITORY)
+	}
+	return
+}
+
+// SecurityLogRepository defines the behaviors to be used by a SecurityLogRepository implementation.
+type SecurityLogRepository interface {
+	// SaveSecurityEvent creates the record of a new security event.
+	//  @param event auth.SecurityEvent: event to be created.
+	//  @return $1 error: failed record creation.
+	SaveSecurityEvent(event auth.SecurityEvent) error
+
+	// GetSecurityEvents gets a page of the security events of the user, newest first.
+	//  @param userID int: owner of the events.
+	//  @param before int: only the events with a lower id are returned, zero for the first page.
+	//  @param limit int: max number of events.
+	//  @return $1 []auth.SecurityEvent: found events.
+	//  @return $2 error: failed records querying.
+	GetSecurityEvents(userID, before, limit int) ([]auth.SecurityEvent, error)
+}
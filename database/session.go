@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// SESSION_REPOSITORY is the key to be used when creating the repositories hashmap.
+const SESSION_REPOSITORY RepositoryID = "SESSION"
+
+// GetSessionRepository gets the SessionRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo SessionRepository: found SessionRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetSessionRepository(repoMap map[RepositoryID]interface{}) (repo SessionRepository, err error) {
+	repoI, ok := repoMap[SESSION_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", SESSION_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(SessionRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", SESSION_REPOSITORY, SESSION_REPOSITORY)
+	}
+	return
+}
+
+// SessionRepository defines the behaviors to be used by a SessionRepository implementation.
+type SessionRepository interface {
+	// TouchSession updates the last time the session was seen.
+	//  @param id int: session id.
+	//  @param seenAt time.Time: last seen time.
+	//  @return $1 error: failed record update.
+	TouchSession(id int, seenAt time.Time) error
+
+	// ExpireSession marks as inactive the session.
+	//  @param id int: session id.
+	//  @return $1 error: failed record update.
+	ExpireSession(id int) error
+
+	// RevokeUserSessions marks as inactive all the sessions of the user, and revokes its remember series, OAuth tokens
+	//  and personal access tokens.
+	//  @param userID int: user id.
+	//  @return $1 error: failed records update.
+	RevokeUserSessions(userID int) error
+
+	// ExpireSessions marks as inactive the active sessions last seen before idleBefore or logged before loggedBefore.
+	//  @param idleBefore time.Time: limit of the last seen time.
+	//  @param loggedBefore time.Time: limit of the logged time.
+	//  @return $1 int64: number of expired sessions.
+	//  @return $2 error: failed records update.
+	ExpireSessions(idleBefore, loggedBefore time.Time) (int64, error)
+
+	// PurgeSessions deletes the inactive sessions last seen before the time provided, with their dependent records.
+	//  @param before time.Time: limit of the last seen time.
+	//  @return $1 int64: number of deleted sessions.
+	//  @return $2 error: failed records deletion.
+	PurgeSessions(before time.Time) (int64, error)
+
+	// PurgeSudos deletes the sudo records finished before the time provided, with their events.
+	//  @param before time.Time: limit of the finish time.
+	//  @return $1 int64: number of deleted sudo records.
+	//  @return $2 error: failed records deletion.
+	PurgeSudos(before time.Time) (int64, error)
+
+	// CreateImpersonationSession creates a new impersonation session, without replacing the active session of the user.
+	//  @param session auth.Session: impersonation session to create.
+	//  @return $1 int: new generated ID.
+	//  @return $2 error: failed record creation.
+	CreateImpersonationSession(session auth.Session) (int, error)
+
+	// GetLastSudo gets the last sudo mode granted to the session.
+	//  @param sessionID int: session id.
+	//  @return $1 auth.Sudo: found sudo.
+	//  @return $2 error: not found sudo or failed record querying.
+	GetLastSudo(sessionID int) (auth.Sudo, error)
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// SESSION_TOKEN_REPOSITORY is the key to be used when creating the repositories hashmap.
+const SESSION_TOKEN_REPOSITORY RepositoryID = "SESSION_TOKEN"
+
+// GetSessionTokenRepository gets the SessionTokenRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo SessionTokenRepository: found SessionTokenRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetSessionTokenRepository(repoMap map[RepositoryID]interface{}) (repo SessionTokenRepository, err error) {
+	repoI, ok := repoMap[SESSION_TOKEN_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", SESSION_TOKEN_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(SessionTokenRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", SESSION_TOKEN_REPOSITORY, SESSION_TOKEN_REPOSITORY)
+	}
+	return
+}
+
+// SessionTokenRepository defines the behaviors to be used by a SessionTokenRepository implementation.
+type SessionTokenRepository interface {
+	// GetSessionToken gets the valid session token with the hash provided.
+	//  A token is invalid when it is expired or its user session is inactive.
+	//  @param hash string: hash of the cookie token.
+	//  @return $1 auth.SessionToken: found session token.
+	//  @return $2 error: not found or invalid token, or failed record querying.
+	GetSessionToken(hash string) (auth.SessionToken, error)
+
+	// SaveSessionToken creates or updates the session token.
+	//  @param token auth.SessionToken: token to create or update.
+	//  @return $1 error: failed record creation or update.
+	SaveSessionToken(token auth.SessionToken) error
+
+	// DeleteSessionToken deletes the session token, invalidating its cookie.
+	//  @param hash string: hash of the cookie token.
+	//  @return $1 error: failed record deletion.
+	DeleteSessionToken(hash string) error
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// TOKEN_REPOSITORY is the key to be used when creating the repositories hashmap.
+const TOKEN_REPOSITORY RepositoryID = "TOKEN"
+
+// GetTokenRepository gets the TokenRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo TokenRepository: found TokenRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetTokenRepository(repoMap map[RepositoryID]interface{}) (repo TokenRepository, err error) {
+	repoI, ok := repoMap[TOKEN_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", TOKEN_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(TokenRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", TOKEN_REPOSITORY, TOKEN_REPOSITORY)
+	}
+	return
+}
+
+// TokenRepository defines the behaviors to be used by a TokenRepository implementation.
+type TokenRepository interface {
+	// SaveAccessToken creates the record of a new personal access token.
+	//  @param token auth.AccessToken: token to be created.
+	//  @return $1 int: new generated ID.
+	//  @return $2 error: failed record creation.
+	SaveAccessToken(token auth.AccessToken) (int, error)
+
+	// GetAccessTokens gets all the personal access tokens of the user, revoked included.
+	//  @param userID int: owner of the tokens.
+	//  @return $1 []auth.AccessToken: tokens of the user.
+	//  @return $2 error: failed record querying.
+	GetAccessTokens(userID int) ([]auth.AccessToken, error)
+
+	// GetAccessTokenByHash gets the personal access token with the hash provided.
+	//  @param hash string: hash of the token.
+	//  @return $1 auth.AccessToken: found token.
+	//  @return $2 error: not found token or failed record querying.
+	GetAccessTokenByHash(hash string) (auth.AccessToken, error)
+
+	// RevokeAccessToken marks the token of the user as revoked.
+	//  @param userID int: owner of the token.
+	//  @param id int: token to revoke.
+	//  @return $1 error: not found token or failed record update.
+	RevokeAccessToken(userID, id int) error
+
+	// TouchAccessToken saves the last usage of the token.
+	//  @param id int: used token.
+	//  @param usedAt time.Time: usage time.
+	//  @param ip string: ip address of the client.
+	//  @return $1 error: failed record update.
+	TouchAccessToken(id int, usedAt time.Time, ip string) error
+}
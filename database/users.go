@@ -0,0 +1,55 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/users"
+)
+
+// USERS_REPOSITORY is the key to be used when creating the repositories hashmap.
+const USERS_REPOSITORY RepositoryID = "USERS"
+
+// GetUsersRepository gets the UsersRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo UsersRepository: found UsersRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetUsersRepository(repoMap map[RepositoryID]interface{}) (repo UsersRepository, err error) {
+	repoI, ok := repoMap[USERS_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", USERS_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(UsersRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", USERS_REPOSITORY, USERS_REPOSITORY)
+	}
+	return
+}
+
+// UsersRepository defines the behaviors to be used by a UsersRepository implementation.
+type UsersRepository interface {
+	// GetUser gets the user asked for, without its password.
+	//  @param id int: user id.
+	//  @return $1 users.User: found user.
+	//  @return $2 error: not found user or failed record querying.
+	GetUser(id int) (users.User, error)
+
+	// GetUserByEmail gets the user with the email provided, without its password.
+	//  @param email string: user email.
+	//  @return $1 users.User: found user.
+	//  @return $2 error: not found user or failed record querying.
+	GetUserByEmail(email string) (users.User, error)
+
+	// UpdateNickname updates the nickname of the user provided.
+	//  @param id int: user id.
+	//  @param nickname string: new normalized nickname.
+	//  @param skeleton string: skeleton of the new nickname.
+	//  @return $1 error: not found user, already taken nickname or failed record updating.
+	UpdateNickname(id int, nickname, skeleton string) error
+
+	// UpdateEmail updates the email of the user provided.
+	//  @param id int: user id.
+	//  @param email string: new normalized email.
+	//  @return $1 error: not found user, already taken email or failed record updating.
+	UpdateEmail(id int, email string) error
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/coffemanfp/chat/auth"
+)
+
+// WS_TICKET_REPOSITORY is the key to be used when creating the repositories hashmap.
+const WS_TICKET_REPOSITORY RepositoryID = "WS_TICKET"
+
+// GetWSTicketRepository gets the WSTicketRepository instance inside the repositories hashmap.
+// 	@param repoMap map[RepositoryID]interface{}: repositories hashmap.
+// 	@return repo WSTicketRepository: found WSTicketRepository instance.
+//  @return err error: missing or invalid repository instance error.
+func GetWSTicketRepository(repoMap map[RepositoryID]interface{}) (repo WSTicketRepository, err error) {
+	repoI, ok := repoMap[WS_TICKET_REPOSITORY]
+	if !ok {
+		err = fmt.Errorf("missing repository: %s not found in repository map", WS_TICKET_REPOSITORY)
+		return
+	}
+	repo, ok = repoI.(WSTicketRepository)
+	if !ok {
+		err = fmt.Errorf("invalid repository value: %s has a invalid %s repository handler", WS_TICKET_REPOSITORY, WS_TICKET_REPOSITORY)
+	}
+	return
+}
+
+// WSTicketRepository defines the behaviors to be used by a WSTicketRepository implementation.
+type WSTicketRepository interface {
+	// SaveWSTicket creates the record of a new WebSocket ticket.
+	//  @param ticket auth.WSTicket: ticket to be created.
+	//  @return $1 error: failed record creation.
+	SaveWSTicket(ticket auth.WSTicket) error
+
+	// ConsumeWSTicket gets and marks as used the WebSocket ticket with the hash provided.
+	//  A ticket can only be consumed once.
+	//  @param hash string: hash of the ticket.
+	//  @return $1 auth.WSTicket: consumed ticket.
+	//  @return $2 error: not found or already used ticket, or failed record update.
+	ConsumeWSTicket(hash string) (auth.WSTicket, error)
+}
@@ -0,0 +1,3 @@
+// Package emailcheck implements the validation of the email addresses, its syntax and the mail host of its domain.
+
+package emailcheck
@@ -0,0 +1,202 @@
+package emailcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	sErrors "github.com/coffemanfp/chat/errors"
+	"golang.org/x/net/idna"
+)
+
+// Resolver defines the DNS lookups used to check the mail host of a domain.
+//  It's implemented by *net.Resolver, so net.DefaultResolver can be used.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Validator defines the behaviors to be used by a email validator implementation.
+type Validator interface {
+	// Validate validates the email provided.
+	//  @param email string: email to validate.
+	//  @return $1 string: address of the email, with the domain in its ASCII form.
+	//  @return $2 error: invalid email format or host, or a failed lookup.
+	Validate(email string) (string, error)
+}
+
+// SyntaxValidator is the Validator implementation which only validates the syntax of the emails,
+//  used in the offline mode, when the DNS can't be reached.
+type SyntaxValidator struct{}
+
+func (SyntaxValidator) Validate(email string) (addr string, err error) {
+	addr, _, err = parse(email)
+	return
+}
+
+// MAX_CACHE_ENTRIES is the max number of domains kept in the lookup cache of a DNSValidator.
+const MAX_CACHE_ENTRIES = 10_000
+
+// DNSValidator is the Validator implementation which also checks the domain of the emails can receive emails:
+//  it must have a MX record, or a A or AAAA record if it has no MX records.
+//  The lookup results are cached by domain for the TTL provided, up to MAX_CACHE_ENTRIES domains.
+type DNSValidator struct {
+	resolver Resolver
+	ttl      time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	m          *sync.Mutex
+	cache      map[string]cacheEntry
+	maxEntries int
+}
+
+type cacheEntry struct {
+	exists    bool
+	expiresAt time.Time
+}
+
+// NewDNSValidator initializes a new DNSValidator instance.
+//  @param resolver Resolver: resolver of the lookups.
+//  @param ttl time.Duration: time the lookup results are cached, zero disables the cache.
+//  @param timeout time.Duration: max time of the lookups of a domain.
+//  @return $1 DNSValidator: new DNSValidator instance.
+func NewDNSValidator(resolver Resolver, ttl, timeout time.Duration) DNSValidator {
+	return DNSValidator{
+		resolver:   resolver,
+		ttl:        ttl,
+		timeout:    timeout,
+		now:        time.Now,
+		m:          &sync.Mutex{},
+		cache:      map[string]cacheEntry{},
+		maxEntries: MAX_CACHE_ENTRIES,
+	}
+}
+
+func (d DNSValidator) Validate(email string) (addr string, err error) {
+	addr, domain, err := parse(email)
+	if err != nil {
+		return
+	}
+
+	exists, err := d.hostExists(domain)
+	if err != nil {
+		return
+	}
+	if !exists {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email host: %s not exists", domain)
+	}
+	return
+}
+
+// hostExists checks the domain has a mail host, using the cached result if it isn't expired.
+//  The failed lookups are not cached.
+func (d DNSValidator) hostExists(domain string) (exists bool, err error) {
+	now := d.now()
+	d.m.Lock()
+	e, ok := d.cache[domain]
+	d.m.Unlock()
+	if ok && now.Before(e.expiresAt) {
+		exists = e.exists
+		return
+	}
+
+	exists, err = d.lookup(domain)
+	if err != nil || d.ttl <= 0 {
+		return
+	}
+
+	d.m.Lock()
+	d.store(domain, cacheEntry{exists: exists, expiresAt: now.Add(d.ttl)}, now)
+	d.m.Unlock()
+	return
+}
+
+// store caches the entry of the domain, it must be called holding the lock.
+//  When the cache is full the expired entries are evicted, and then arbitrary ones if it's still full, so the
+//  domains sent by the clients can't grow it without limit.
+func (d DNSValidator) store(domain string, e cacheEntry, now time.Time) {
+	if _, ok := d.cache[domain]; !ok && len(d.cache) >= d.maxEntries {
+		for k, c := range d.cache {
+			if !now.Before(c.expiresAt) {
+				delete(d.cache, k)
+			}
+		}
+		for k := range d.cache {
+			if len(d.cache) < d.maxEntries {
+				break
+			}
+			delete(d.cache, k)
+		}
+	}
+	d.cache[domain] = e
+}
+
+func (d DNSValidator) lookup(domain string) (exists bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	mxs, err := d.resolver.LookupMX(ctx, domain)
+	if err == nil && len(mxs) > 0 {
+		// A null MX record explicitly declares the domain doesn't accept emails.
+		exists = !(len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == ""))
+		return
+	}
+	if err != nil && !isNotFound(err) {
+		err = fmt.Errorf("failed to lookup MX records of %s: %s", domain, err)
+		return
+	}
+
+	// Without MX records, the mail is delivered to the A or AAAA record of the domain.
+	hosts, err := d.resolver.LookupHost(ctx, domain)
+	if err != nil {
+		if isNotFound(err) {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("failed to lookup host of %s: %s", domain, err)
+		return
+	}
+	exists = len(hosts) > 0
+	return
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// parse validates the syntax of the email and converts its domain to the ASCII form.
+func parse(email string) (addr, domain string, err error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email format: %s is not valid, cause missing @ or local part", email)
+		return
+	}
+
+	// The internationalized domains are converted to the ASCII form, so the same domain is always kept in the same form.
+	domain, err = idna.Lookup.ToASCII(strings.TrimSuffix(email[at+1:], "."))
+	if err != nil || domain == "" {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email format: %s is not valid, cause invalid domain", email)
+		return
+	}
+
+	a, err := mail.ParseAddress(email[:at+1] + domain)
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email format: %s is not valid, cause %s", email, err)
+		return
+	}
+	if a.Name != "" || a.Address != email[:at+1]+domain {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email format: %s is not valid, cause it must be a bare address", email)
+		return
+	}
+	addr = a.Address
+	return
+}
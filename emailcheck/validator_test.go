@@ -0,0 +1,189 @@
+package emailcheck
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resolverImpl struct {
+	mx      map[string][]*net.MX
+	hosts   map[string][]string
+	err     error
+	lookups int
+}
+
+// This statement is to check if the resolverImpl mock is doing well with the Resolver interface.
+var _ Resolver = &resolverImpl{}
+
+func (r *resolverImpl) LookupMX(ctx context.Context, name string) (mxs []*net.MX, err error) {
+	r.lookups++
+	if r.err != nil {
+		err = r.err
+		return
+	}
+	mxs, ok := r.mx[name]
+	if !ok {
+		err = &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return
+}
+
+func (r *resolverImpl) LookupHost(ctx context.Context, host string) (addrs []string, err error) {
+	addrs, ok := r.hosts[host]
+	if !ok {
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return
+}
+
+func newResolver() *resolverImpl {
+	return &resolverImpl{
+		mx: map[string][]*net.MX{
+			"mail.example":          {{Host: "mx.mail.example.", Pref: 10}},
+			"nomail.example":        {{Host: ".", Pref: 0}},
+			"xn--bcher-kva.example": {{Host: "mx.xn--bcher-kva.example.", Pref: 10}},
+		},
+		hosts: map[string][]string{
+			"host.example": {"203.0.113.1"},
+		},
+	}
+}
+
+func TestDNSValidator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		email    string
+		wantAddr string
+		wantErr  string
+	}{
+		{
+			name:     "Given a email of a domain with MX records When validating Then success",
+			email:    "user@mail.example",
+			wantAddr: "user@mail.example",
+		},
+		{
+			name:     "Given a email of a domain without MX records but a A record When validating Then success",
+			email:    "user@host.example",
+			wantAddr: "user@host.example",
+		},
+		{
+			name:     "Given a email of a internationalized domain When validating Then address with the ASCII domain",
+			email:    "user@Bücher.example",
+			wantAddr: "user@xn--bcher-kva.example",
+		},
+		{
+			name:    "Given a email of a domain with a null MX record When validating Then invalid email host error",
+			email:   "user@nomail.example",
+			wantErr: "invalid email host: nomail.example not exists",
+		},
+		{
+			name:    "Given a email of a unknown domain When validating Then invalid email host error",
+			email:   "user@unknown.example",
+			wantErr: "invalid email host: unknown.example not exists",
+		},
+		{
+			name:    "Given a email without domain When validating Then invalid email format error",
+			email:   "user@",
+			wantErr: "invalid email format: user@ is not valid, cause invalid domain",
+		},
+		{
+			name:    "Given a email with a display name When validating Then invalid email format error",
+			email:   "User <user@mail.example>",
+			wantErr: "invalid email format: User <user@mail.example> is not valid, cause invalid domain",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := NewDNSValidator(newResolver(), time.Minute, time.Second)
+			addr, err := v.Validate(tt.email)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAddr, addr)
+		})
+	}
+}
+
+func TestDNSValidatorCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a validated domain When validating it again before the TTL Then cached result", func(t *testing.T) {
+		r := newResolver()
+		v := NewDNSValidator(r, time.Minute, time.Second)
+		now := time.Now()
+		v.now = func() time.Time { return now }
+
+		_, err := v.Validate("a@mail.example")
+		assert.NoError(t, err)
+		_, err = v.Validate("b@mail.example")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, r.lookups)
+
+		now = now.Add(2 * time.Minute)
+		_, err = v.Validate("c@mail.example")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, r.lookups)
+	})
+
+	t.Run("Given a full cache When validating a new domain Then expired entries evicted", func(t *testing.T) {
+		r := newResolver()
+		v := NewDNSValidator(r, time.Minute, time.Second)
+		v.maxEntries = 2
+		now := time.Now()
+		v.now = func() time.Time { return now }
+
+		_, err := v.Validate("a@mail.example")
+		assert.NoError(t, err)
+		now = now.Add(2 * time.Minute)
+		_, err = v.Validate("a@host.example")
+		assert.NoError(t, err)
+		_, err = v.Validate("a@xn--bcher-kva.example")
+		assert.NoError(t, err)
+		assert.Len(t, v.cache, 2)
+		assert.NotContains(t, v.cache, "mail.example")
+
+		_, err = v.Validate("a@nomail.example")
+		assert.Error(t, err)
+		assert.Len(t, v.cache, 2)
+		assert.Contains(t, v.cache, "nomail.example")
+	})
+
+	t.Run("Given a failed lookup When validating Then error not cached", func(t *testing.T) {
+		r := newResolver()
+		r.err = &net.DNSError{Err: "i/o timeout", Name: "mail.example", IsTimeout: true}
+		v := NewDNSValidator(r, time.Minute, time.Second)
+
+		_, err := v.Validate("a@mail.example")
+		assert.Error(t, err)
+
+		r.err = nil
+		_, err = v.Validate("a@mail.example")
+		assert.NoError(t, err)
+	})
+}
+
+func TestSyntaxValidator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a email of a unknown domain When validating offline Then success", func(t *testing.T) {
+		addr, err := SyntaxValidator{}.Validate(" user@unknown.example ")
+		assert.NoError(t, err)
+		assert.Equal(t, "user@unknown.example", addr)
+	})
+
+	t.Run("Given a invalid email When validating offline Then invalid email format error", func(t *testing.T) {
+		_, err := SyntaxValidator{}.Validate("invalid.email.format")
+		assert.Contains(t, err.Error(), "invalid email format: invalid.email.format is not valid")
+	})
+}
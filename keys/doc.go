@@ -0,0 +1,5 @@
+// Package keys manages the keys used to sign the tokens issued by the service.
+// Keys are rotated automatically and the previous public keys are kept published
+// until the tokens signed with them can't be valid anymore.
+
+package keys
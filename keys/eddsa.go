@@ -0,0 +1,50 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA implements the EdDSA JWT signing method with Ed25519 keys,
+//  which is not available in the jwt-go version used.
+var SigningMethodEdDSA jwt.SigningMethod = signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(EdDSA, func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m signingMethodEdDSA) Alg() string {
+	return EdDSA
+}
+
+func (m signingMethodEdDSA) Sign(signingString string, key interface{}) (sig string, err error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		err = jwt.ErrInvalidKeyType
+		return
+	}
+	sig = jwt.EncodeSegment(ed25519.Sign(priv, []byte(signingString)))
+	return
+}
+
+func (m signingMethodEdDSA) Verify(signingString, signature string, key interface{}) (err error) {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return
+	}
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		err = errors.New("ed25519: verification error")
+	}
+	return
+}
@@ -0,0 +1,215 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// RS256 is the algorithm for the RSA keys, RSASSA-PKCS1-v1_5 with SHA-256.
+	RS256 = "RS256"
+
+	// EdDSA is the algorithm for the Ed25519 keys.
+	EdDSA = "EdDSA"
+)
+
+const pemBlockType = "PRIVATE KEY"
+
+// Key represents a signing key and its schedule.
+//  A key is pending before ActivatesAt, is used to sign between ActivatesAt and RetiresAt,
+//  and is only published to verify tokens between RetiresAt and ExpiresAt.
+type Key struct {
+	ID          string
+	Algorithm   string
+	PrivateKey  crypto.Signer
+	CreatedAt   time.Time
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// JWK is the JSON Web Key representation of a public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+
+	// RSA public key parameters.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519 public key parameters.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Generate generates a new key.
+//  @param algorithm string: RS256 or EdDSA.
+//  @param activatesAt time.Time: time when the key starts to sign.
+//  @param activeFor time.Duration: time which the key is used to sign.
+//  @param retention time.Duration: time which the key is published after its retirement.
+//  @return key Key: new Key instance.
+//  @return err error: unsupported algorithm or generation error.
+func Generate(algorithm string, activatesAt time.Time, activeFor, retention time.Duration) (key Key, err error) {
+	var priv crypto.Signer
+	switch algorithm {
+	case RS256:
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case EdDSA:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("invalid signing key algorithm: %s is not supported", algorithm)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to generate %s signing key: %s", algorithm, err)
+		return
+	}
+
+	kid, err := keyID(priv.Public())
+	if err != nil {
+		return
+	}
+
+	key = Key{
+		ID:          kid,
+		Algorithm:   algorithm,
+		PrivateKey:  priv,
+		CreatedAt:   time.Now(),
+		ActivatesAt: activatesAt,
+		RetiresAt:   activatesAt.Add(activeFor),
+		ExpiresAt:   activatesAt.Add(activeFor + retention),
+	}
+	return
+}
+
+// Active checks if the key can be used to sign at the time provided.
+func (k Key) Active(now time.Time) bool {
+	return !now.Before(k.ActivatesAt) && now.Before(k.RetiresAt)
+}
+
+// Expired checks if the key can't be used to verify anymore at the time provided.
+func (k Key) Expired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
+
+// JWK gets the JSON Web Key of the public key.
+func (k Key) JWK() (j JWK) {
+	j = JWK{
+		Use: "sig",
+		Alg: k.Algorithm,
+		Kid: k.ID,
+	}
+
+	switch pub := k.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		j.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return
+}
+
+// MarshalPEM encodes the key as a PKCS #8 PEM block. The key schedule is kept in the block headers.
+//  @return b []byte: PEM encoded key.
+//  @return err error: encoding error.
+func (k Key) MarshalPEM() (b []byte, err error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		err = fmt.Errorf("failed to encode signing key %s: %s", k.ID, err)
+		return
+	}
+
+	b = pem.EncodeToMemory(&pem.Block{
+		Type: pemBlockType,
+		Headers: map[string]string{
+			"Kid":          k.ID,
+			"Algorithm":    k.Algorithm,
+			"Created-At":   k.CreatedAt.UTC().Format(time.RFC3339),
+			"Activates-At": k.ActivatesAt.UTC().Format(time.RFC3339),
+			"Retires-At":   k.RetiresAt.UTC().Format(time.RFC3339),
+			"Expires-At":   k.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Bytes: der,
+	})
+	return
+}
+
+// ParsePEM decodes a key encoded with Key.MarshalPEM.
+//  @param b []byte: PEM encoded key.
+//  @return key Key: decoded key.
+//  @return err error: invalid format error.
+func ParsePEM(b []byte) (key Key, err error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != pemBlockType {
+		err = errors.New("invalid signing key: not a PEM encoded private key")
+		return
+	}
+
+	privI, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		err = fmt.Errorf("invalid signing key: %s", err)
+		return
+	}
+
+	switch priv := privI.(type) {
+	case *rsa.PrivateKey:
+		key.PrivateKey = priv
+		key.Algorithm = RS256
+	case ed25519.PrivateKey:
+		key.PrivateKey = priv
+		key.Algorithm = EdDSA
+	default:
+		err = errors.New("invalid signing key: only RSA and Ed25519 keys are supported")
+		return
+	}
+
+	key.ID = block.Headers["Kid"]
+	if key.ID == "" {
+		key.ID, err = keyID(key.PrivateKey.Public())
+		if err != nil {
+			return
+		}
+	}
+
+	for h, t := range map[string]*time.Time{
+		"Created-At":   &key.CreatedAt,
+		"Activates-At": &key.ActivatesAt,
+		"Retires-At":   &key.RetiresAt,
+		"Expires-At":   &key.ExpiresAt,
+	} {
+		*t, err = time.Parse(time.RFC3339, block.Headers[h])
+		if err != nil {
+			err = fmt.Errorf("invalid signing key %s: invalid %s header", key.ID, h)
+			return
+		}
+	}
+	return
+}
+
+// keyID gets the key id of a public key, a truncated SHA-256 of its DER encoding.
+func keyID(pub crypto.PublicKey) (kid string, err error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		err = fmt.Errorf("failed to encode signing public key: %s", err)
+		return
+	}
+	h := sha256.Sum256(der)
+	kid = base64.RawURLEncoding.EncodeToString(h[:])[:16]
+	return
+}
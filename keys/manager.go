@@ -0,0 +1,235 @@
+package keys
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Schedule keeps the rotation settings of the signing keys.
+type Schedule struct {
+	// Algorithm of the new generated keys, RS256 or EdDSA.
+	Algorithm string
+
+	// ActiveFor is the time which every key is used to sign.
+	ActiveFor time.Duration
+
+	// Retention is the time which a retired key keeps published to verify the tokens signed with it.
+	//  It must be longer than the lifetime of the signed tokens.
+	Retention time.Duration
+
+	// Prepublish is the time which the next key is published before it starts to sign,
+	//  so the verifiers which cache the public keys know it before they get tokens signed with it.
+	Prepublish time.Duration
+}
+
+// Manager keeps the signing keys and rotates them following its schedule.
+type Manager struct {
+	store    Store
+	schedule Schedule
+
+	m    sync.RWMutex
+	keys []Key
+
+	stop chan struct{}
+}
+
+// NewManager initializes a new *Manager, loading the stored keys and generating the missing ones.
+//  @param store Store: storage of the keys.
+//  @param schedule Schedule: rotation settings.
+//  @return m *Manager: new *Manager instance.
+//  @return err error: keys loading or generation error.
+func NewManager(store Store, schedule Schedule) (m *Manager, err error) {
+	if schedule.Algorithm != RS256 && schedule.Algorithm != EdDSA {
+		err = errors.New("invalid signing key algorithm: only RS256 and EdDSA are supported")
+		return
+	}
+	if schedule.ActiveFor <= 0 {
+		err = errors.New("invalid signing key schedule: the active time must be positive")
+		return
+	}
+
+	m = &Manager{
+		store:    store,
+		schedule: schedule,
+		stop:     make(chan struct{}),
+	}
+	err = m.Rotate(time.Now())
+	return
+}
+
+// Rotate reloads the stored keys, deletes the expired ones and generates the next key
+//  when the current one is about to retire.
+//  @param now time.Time: current time.
+//  @return err error: keys storage or generation error.
+func (m *Manager) Rotate(now time.Time) (err error) {
+	stored, err := m.store.GetKeys()
+	if err != nil {
+		return
+	}
+
+	var keys []Key
+	for _, k := range stored {
+		if !k.Expired(now) {
+			keys = append(keys, k)
+			continue
+		}
+
+		err = m.store.DeleteKey(k.ID)
+		if err != nil {
+			return
+		}
+		log.Printf("Deleted expired signing key %s", k.ID)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ActivatesAt.Before(keys[j].ActivatesAt)
+	})
+
+	var activatesAt time.Time
+	switch {
+	case len(keys) == 0 || !now.Before(keys[len(keys)-1].RetiresAt):
+		activatesAt = now
+	case keys[len(keys)-1].RetiresAt.Sub(now) <= m.schedule.Prepublish:
+		activatesAt = keys[len(keys)-1].RetiresAt
+	}
+
+	if !activatesAt.IsZero() {
+		var key Key
+		key, err = Generate(m.schedule.Algorithm, activatesAt, m.schedule.ActiveFor, m.schedule.Retention)
+		if err != nil {
+			return
+		}
+
+		err = m.store.SaveKey(key)
+		if err != nil {
+			return
+		}
+		log.Printf("Generated %s signing key %s, active from %s", key.Algorithm, key.ID, key.ActivatesAt.Format(time.RFC3339))
+
+		keys = append(keys, key)
+	}
+
+	m.m.Lock()
+	m.keys = keys
+	m.m.Unlock()
+	return
+}
+
+// Run rotates the keys every interval until Stop is called. It is intended to be run in a goroutine.
+//  @param interval time.Duration: time between every rotation check, zero disables them.
+func (m *Manager) Run(interval time.Duration) {
+	var ticks <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		ticks = t.C
+	}
+
+	for {
+		select {
+		case now := <-ticks:
+			err := m.Rotate(now)
+			if err != nil {
+				log.Printf("failed to rotate signing keys: %s", err)
+			}
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the Run loop.
+func (m *Manager) Stop() {
+	close(m.stop)
+}
+
+// Current gets the key which must be used to sign now, the latest activated key.
+//  @return key Key: current signing key.
+//  @return err error: no active key error.
+func (m *Manager) Current() (key Key, err error) {
+	now := time.Now()
+
+	m.m.RLock()
+	defer m.m.RUnlock()
+
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		if m.keys[i].Active(now) {
+			key = m.keys[i]
+			return
+		}
+	}
+	err = errors.New("no active signing key: keys rotation is not running")
+	return
+}
+
+// Sign signs the claims provided as a JWT with the current key.
+//  @param claims jwt.Claims: claims of the token.
+//  @return token string: signed token.
+//  @return err error: no active key or signing error.
+func (m *Manager) Sign(claims jwt.Claims) (token string, err error) {
+	key, err := m.Current()
+	if err != nil {
+		return
+	}
+
+	method := jwt.SigningMethod(jwt.SigningMethodRS256)
+	if key.Algorithm == EdDSA {
+		method = SigningMethodEdDSA
+	}
+
+	t := jwt.NewWithClaims(method, claims)
+	t.Header["kid"] = key.ID
+	token, err = t.SignedString(key.PrivateKey)
+	return
+}
+
+// PublicKey gets the public key of the key id provided, to verify tokens.
+//  @param kid string: key id.
+//  @return key Key: found key.
+//  @return ok bool: the key exists and it is not expired.
+func (m *Manager) PublicKey(kid string) (key Key, ok bool) {
+	now := time.Now()
+
+	m.m.RLock()
+	defer m.m.RUnlock()
+
+	for _, k := range m.keys {
+		if k.ID == kid && !k.Expired(now) {
+			return k, true
+		}
+	}
+	return
+}
+
+// JWKS gets the public keys of the pending, current and retired but not expired keys.
+func (m *Manager) JWKS() (keys []JWK) {
+	now := time.Now()
+
+	m.m.RLock()
+	defer m.m.RUnlock()
+
+	keys = []JWK{}
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		if !m.keys[i].Expired(now) {
+			keys = append(keys, m.keys[i].JWK())
+		}
+	}
+	return
+}
+
+// Algorithms gets the signing algorithms of the published keys.
+func (m *Manager) Algorithms() (algs []string) {
+	seen := map[string]bool{}
+	for _, k := range m.JWKS() {
+		if !seen[k.Alg] {
+			seen[k.Alg] = true
+			algs = append(algs, k.Alg)
+		}
+	}
+	return
+}
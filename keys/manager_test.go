@@ -0,0 +1,106 @@
+package keys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var schedule = Schedule{
+	Algorithm:  EdDSA,
+	ActiveFor:  time.Hour,
+	Retention:  30 * time.Minute,
+	Prepublish: 10 * time.Minute,
+}
+
+func TestManagerRotate(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	m, err := NewManager(store, schedule)
+	assert.NoError(t, err)
+
+	first, err := m.Current()
+	assert.NoError(t, err)
+	now := first.ActivatesAt
+
+	t.Run("Given a current key far from its retirement When rotating Then no key generated", func(t *testing.T) {
+		assert.NoError(t, m.Rotate(now.Add(10*time.Minute)))
+		assert.Len(t, m.JWKS(), 1)
+	})
+	t.Run("Given a current key about to retire When rotating Then next key prepublished", func(t *testing.T) {
+		assert.NoError(t, m.Rotate(now.Add(55*time.Minute)))
+		assert.Len(t, m.JWKS(), 2)
+
+		keys, _ := store.GetKeys()
+		for _, k := range keys {
+			if k.ID != first.ID {
+				assert.Equal(t, first.RetiresAt, k.ActivatesAt)
+			}
+		}
+	})
+	t.Run("Given a retired key When rotating after its retention Then key deleted", func(t *testing.T) {
+		assert.NoError(t, m.Rotate(first.ExpiresAt))
+
+		_, ok := m.PublicKey(first.ID)
+		assert.False(t, ok)
+		keys, _ := store.GetKeys()
+		assert.Len(t, keys, 1)
+	})
+}
+
+func TestManagerSign(t *testing.T) {
+	t.Parallel()
+
+	for _, alg := range []string{RS256, EdDSA} {
+		alg := alg
+		t.Run("Given a "+alg+" key When signing claims Then verifiable token", func(t *testing.T) {
+			t.Parallel()
+
+			s := schedule
+			s.Algorithm = alg
+			m, err := NewManager(NewMemoryStore(), s)
+			assert.NoError(t, err)
+
+			token, err := m.Sign(jwt.MapClaims{"sub": "1"})
+			assert.NoError(t, err)
+
+			parsed, err := jwt.Parse(token, func(tk *jwt.Token) (interface{}, error) {
+				key, _ := m.PublicKey(tk.Header["kid"].(string))
+				return key.PrivateKey.Public(), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, alg, parsed.Method.Alg())
+			assert.Equal(t, []string{alg}, m.Algorithms())
+		})
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Given a saved key When loading the keys Then same key", func(t *testing.T) {
+		store, err := NewDirStore(t.TempDir())
+		assert.NoError(t, err)
+
+		key, err := Generate(RS256, time.Now().Truncate(time.Second), time.Hour, time.Hour)
+		assert.NoError(t, err)
+		key.CreatedAt = key.CreatedAt.Truncate(time.Second)
+		assert.NoError(t, store.SaveKey(key))
+
+		keys, err := store.GetKeys()
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.Equal(t, key.ID, keys[0].ID)
+		assert.True(t, key.ActivatesAt.Equal(keys[0].ActivatesAt))
+		assert.True(t, key.ExpiresAt.Equal(keys[0].ExpiresAt))
+		assert.Equal(t, key.JWK(), keys[0].JWK())
+
+		assert.NoError(t, store.DeleteKey(key.ID))
+		keys, err = store.GetKeys()
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+}
@@ -0,0 +1,138 @@
+package keys
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// Store defines the behaviors to be used by a signing keys storage implementation.
+type Store interface {
+	// GetKeys gets all the stored keys.
+	//  @return $1 []Key: stored keys.
+	//  @return $2 error: reading error.
+	GetKeys() ([]Key, error)
+
+	// SaveKey stores a new key.
+	//  @param key Key: key to store.
+	//  @return $1 error: writing error.
+	SaveKey(key Key) error
+
+	// DeleteKey removes the key with the id provided.
+	//  @param kid string: key id.
+	//  @return $1 error: deletion error.
+	DeleteKey(kid string) error
+}
+
+// DirStore is the Store implementation which keeps every key as a PEM file inside a directory.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore initializes a new DirStore, creating the directory if it doesn't exist.
+//  @param dir string: directory of the keys.
+//  @return s DirStore: new DirStore instance.
+//  @return err error: directory creation error.
+func NewDirStore(dir string) (s DirStore, err error) {
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		err = fmt.Errorf("failed to create signing keys dir %s: %s", dir, err)
+		return
+	}
+	s = DirStore{
+		dir: dir,
+	}
+	return
+}
+
+func (d DirStore) GetKeys() (keys []Key, err error) {
+	files, err := filepath.Glob(path.Join(d.dir, "*.pem"))
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		var raw []byte
+		raw, err = ioutil.ReadFile(f)
+		if err != nil {
+			err = fmt.Errorf("failed to read signing key %s: %s", f, err)
+			return
+		}
+
+		var key Key
+		key, err = ParsePEM(raw)
+		if err != nil {
+			err = fmt.Errorf("failed to load signing key %s: %s", f, err)
+			return
+		}
+		keys = append(keys, key)
+	}
+	return
+}
+
+func (d DirStore) SaveKey(key Key) (err error) {
+	raw, err := key.MarshalPEM()
+	if err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(d.keyPath(key.ID), raw, 0600)
+	if err != nil {
+		err = fmt.Errorf("failed to write signing key %s: %s", key.ID, err)
+	}
+	return
+}
+
+func (d DirStore) DeleteKey(kid string) (err error) {
+	err = os.Remove(d.keyPath(kid))
+	if err != nil && !os.IsNotExist(err) {
+		err = fmt.Errorf("failed to delete signing key %s: %s", kid, err)
+		return
+	}
+	err = nil
+	return
+}
+
+func (d DirStore) keyPath(kid string) string {
+	return path.Join(d.dir, kid+".pem")
+}
+
+// MemoryStore is the Store implementation which keeps the keys only in memory.
+//  Its keys are lost in every restart, so it is intended for development and tests.
+type MemoryStore struct {
+	m    sync.Mutex
+	keys map[string]Key
+}
+
+// NewMemoryStore initializes a new empty *MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys: map[string]Key{},
+	}
+}
+
+func (s *MemoryStore) GetKeys() (keys []Key, err error) {
+	s.m.Lock()
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	s.m.Unlock()
+	return
+}
+
+func (s *MemoryStore) SaveKey(key Key) (err error) {
+	s.m.Lock()
+	s.keys[key.ID] = key
+	s.m.Unlock()
+	return
+}
+
+func (s *MemoryStore) DeleteKey(kid string) (err error) {
+	s.m.Lock()
+	delete(s.keys, kid)
+	s.m.Unlock()
+	return
+}
@@ -0,0 +1,3 @@
+// Package mail implements the email delivery used to notify and authenticate the users.
+
+package mail
@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+// Message represents a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer defines the behaviors to be used by a email delivery implementation.
+type Mailer interface {
+	// Send sends the message provided.
+	//  @param msg Message: message to send.
+	//  @return $1 error: delivery error.
+	Send(msg Message) error
+}
+
+// SMTPMailer is the Mailer implementation for a SMTP server.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer initializes a new SMTPMailer instance.
+//  @param host string: SMTP server host.
+//  @param port int: SMTP server port.
+//  @param user string: SMTP user, the authentication is disabled when it is empty.
+//  @param pass string: SMTP password.
+//  @param from string: sender address of the messages.
+//  @return $1 SMTPMailer: new SMTPMailer instance.
+func NewSMTPMailer(host string, port int, user, pass, from string) SMTPMailer {
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, pass, host)
+	}
+	return SMTPMailer{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s SMTPMailer) Send(msg Message) (err error) {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		err = fmt.Errorf("invalid email message: headers can't contain line breaks")
+		return
+	}
+
+	raw := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s",
+		s.from, msg.To, msg.Subject, msg.Body,
+	)
+
+	err = smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(raw))
+	if err != nil {
+		err = fmt.Errorf("failed to send email to %s: %s", msg.To, err)
+	}
+	return
+}
+
+// LogMailer is the Mailer implementation which only logs the messages.
+//  It is intended for development, when no SMTP server is configured.
+type LogMailer struct{}
+
+func (l LogMailer) Send(msg Message) (err error) {
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return
+}
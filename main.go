@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/coffemanfp/chat/config"
 	"github.com/coffemanfp/chat/database"
@@ -11,43 +13,182 @@ import (
 )
 
 func main() {
-	conf, err := config.NewEnvManagerConfig()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "grant-admin" {
+		err := runGrantAdmin(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	reloader, err := config.NewReloader(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
+	conf := reloader.Get()
+	go reloader.Run(time.Duration(conf.Reload.WatchIntervalInSecs) * time.Second)
 
 	db, err := setUpDatabase(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	server := server.NewServer(conf, db, conf.Server.Host, conf.Server.Port)
+	server, err := server.NewServer(reloader, db, conf.Server.Host, conf.Server.Port)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Printf("Listening on port: %d\n", conf.Server.Port)
 	log.Fatal(server.Run())
 }
 
+func newPostgreSQLConnector(conf config.ConfigInfo) *psql.PostgreSQLConnector {
+	p := conf.PostgreSQLProperties
+	return psql.NewPostgreSQLConnector(psql.Properties{
+		DSN:              p.DSN,
+		User:             p.User,
+		Password:         p.Password,
+		Name:             p.Name,
+		Host:             p.Host,
+		Port:             p.Port,
+		SSLMode:          p.SSLMode,
+		SSLRootCert:      p.SSLRootCert,
+		SSLCert:          p.SSLCert,
+		SSLKey:           p.SSLKey,
+		ConnectTimeout:   time.Duration(p.ConnectTimeoutInSecs) * time.Second,
+		StatementTimeout: time.Duration(p.StatementTimeoutInSecs) * time.Second,
+		ApplicationName:  p.ApplicationName,
+		MaxOpenConns:     p.MaxOpenConns,
+		MaxIdleConns:     p.MaxIdleConns,
+		ConnMaxLifetime:  time.Duration(p.ConnMaxLifetimeInSecs) * time.Second,
+	})
+}
+
 func setUpDatabase(conf config.ConfigInfo) (db database.Database, err error) {
-	db.Conn = psql.NewPostgreSQLConnector(
-		conf.PostgreSQLProperties.User,
-		conf.PostgreSQLProperties.Password,
-		conf.PostgreSQLProperties.Name,
-		conf.PostgreSQLProperties.Host,
-		conf.PostgreSQLProperties.Port,
-	)
+	db.Conn = newPostgreSQLConnector(conf)
 
 	err = db.Conn.Connect()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if conf.PostgreSQLProperties.AutoMigrate {
+		err = migrateUp(db.Conn.(*psql.PostgreSQLConnector))
+		if err != nil {
+			return
+		}
+	}
+
 	authRepo, err := psql.NewAuthRepository(db.Conn.(*psql.PostgreSQLConnector))
 	if err != nil {
 		return
 	}
 
+	roleRepo, err := psql.NewRoleRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	tokenRepo, err := psql.NewTokenRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	oauthRepo, err := psql.NewOAuthRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	usersRepo, err := psql.NewUsersRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	keyRepo, err := psql.NewKeyRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	wsTicketRepo, err := psql.NewWSTicketRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	magicLinkRepo, err := psql.NewMagicLinkRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	emailCodeRepo, err := psql.NewEmailCodeRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	sessionRepo, err := psql.NewSessionRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	sessionTokenRepo, err := psql.NewSessionTokenRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	rememberRepo, err := psql.NewRememberTokenRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	deviceRepo, err := psql.NewDeviceRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	resetRepo, err := psql.NewPasswordResetRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	securityLogRepo, err := psql.NewSecurityLogRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	auditRepo, err := psql.NewAuditLogRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
+	inviteRepo, err := psql.NewInviteRepository(db.Conn.(*psql.PostgreSQLConnector))
+	if err != nil {
+		return
+	}
+
 	db.Repositories = map[database.RepositoryID]interface{}{
-		database.AUTH_REPOSITORY: authRepo,
+		database.AUTH_REPOSITORY:           authRepo,
+		database.ROLE_REPOSITORY:           roleRepo,
+		database.TOKEN_REPOSITORY:          tokenRepo,
+		database.OAUTH_REPOSITORY:          oauthRepo,
+		database.USERS_REPOSITORY:          usersRepo,
+		database.KEY_REPOSITORY:            keyRepo,
+		database.WS_TICKET_REPOSITORY:      wsTicketRepo,
+		database.MAGIC_LINK_REPOSITORY:     magicLinkRepo,
+		database.EMAIL_CODE_REPOSITORY:     emailCodeRepo,
+		database.SESSION_REPOSITORY:        sessionRepo,
+		database.SESSION_TOKEN_REPOSITORY:  sessionTokenRepo,
+		database.REMEMBER_TOKEN_REPOSITORY: rememberRepo,
+		database.DEVICE_REPOSITORY:         deviceRepo,
+		database.PASSWORD_RESET_REPOSITORY: resetRepo,
+		database.SECURITY_LOG_REPOSITORY:   securityLogRepo,
+		database.AUDIT_LOG_REPOSITORY:      auditRepo,
+		database.INVITE_REPOSITORY:         inviteRepo,
 	}
 	return
 }
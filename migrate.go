@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database/psql"
+	"github.com/coffemanfp/chat/migrations"
+)
+
+// MIGRATIONS_DIR is the dir where the "migrate create" subcommand creates the new migrations.
+const MIGRATIONS_DIR = "migrations"
+
+const migrateUsage = `usage: chat migrate <command> [config flags]
+
+commands:
+  up             applies all the pending migrations
+  down [steps]   reverts the last applied migrations, 1 by default
+  status         shows the state of the migrations
+  create <name>  creates the files of a new migration in the ` + MIGRATIONS_DIR + ` dir`
+
+// runMigrate runs the migrate subcommand.
+//  @param args []string: arguments after "migrate", the command and then the config flags.
+//  @return err error: invalid arguments, config or migration error.
+func runMigrate(args []string) (err error) {
+	if len(args) == 0 {
+		err = fmt.Errorf("missing migrate command\n%s", migrateUsage)
+		return
+	}
+	cmd, args := args[0], args[1:]
+
+	if cmd == "create" {
+		if len(args) != 1 {
+			err = fmt.Errorf("invalid migrate create arguments: a migration name is required\n%s", migrateUsage)
+			return
+		}
+		var up, down string
+		up, down, err = migrations.Create(MIGRATIONS_DIR, args[0])
+		if err != nil {
+			return
+		}
+		fmt.Printf("Created %s\nCreated %s\n", up, down)
+		return
+	}
+
+	steps := 1
+	if cmd == "down" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		steps, err = strconv.Atoi(args[0])
+		if err != nil || steps <= 0 {
+			err = fmt.Errorf("invalid migrate down steps: %s is not a positive number", args[0])
+			return
+		}
+		args = args[1:]
+	}
+
+	conf, err := config.Load(args)
+	if err != nil {
+		return
+	}
+
+	conn := newPostgreSQLConnector(conf)
+	err = conn.Connect()
+	if err != nil {
+		return
+	}
+
+	switch cmd {
+	case "up":
+		err = migrateUp(conn)
+	case "down":
+		err = migrateDown(conn, steps)
+	case "status":
+		err = migrateStatus(conn)
+	default:
+		err = fmt.Errorf("invalid migrate command: %s\n%s", cmd, migrateUsage)
+	}
+	return
+}
+
+func newMigrator(conn *psql.PostgreSQLConnector) (m psql.Migrator, err error) {
+	list, err := migrations.All()
+	if err != nil {
+		return
+	}
+	return psql.NewMigrator(conn, list)
+}
+
+// migrateUp applies the pending migrations, logging every applied one.
+func migrateUp(conn *psql.PostgreSQLConnector) (err error) {
+	m, err := newMigrator(conn)
+	if err != nil {
+		return
+	}
+
+	applied, err := m.Up()
+	for _, mig := range applied {
+		log.Printf("Applied migration %s", mig)
+	}
+	if err == nil && len(applied) == 0 {
+		log.Println("No pending migrations")
+	}
+	return
+}
+
+func migrateDown(conn *psql.PostgreSQLConnector, steps int) (err error) {
+	m, err := newMigrator(conn)
+	if err != nil {
+		return
+	}
+
+	reverted, err := m.Down(steps)
+	for _, mig := range reverted {
+		log.Printf("Reverted migration %s", mig)
+	}
+	if err == nil && len(reverted) == 0 {
+		log.Println("No applied migrations")
+	}
+	return
+}
+
+func migrateStatus(conn *psql.PostgreSQLConnector) (err error) {
+	m, err := newMigrator(conn)
+	if err != nil {
+		return
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT")
+	for _, s := range status {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Modified {
+			state = "modified"
+		}
+		if s.Unknown {
+			state = "unknown"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Migration, state, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,6 @@
+drop table if exists sudo_events;
+drop table if exists sudo;
+drop table if exists events;
+drop table if exists user_session;
+drop table if exists external_user_auth;
+drop table if exists users;
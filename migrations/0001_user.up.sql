@@ -33,7 +33,14 @@ create table if not exists user_session (
     foreign key (user_id) references users(id)
 );
 
-create unique index idx_user_id_actived on user_session(user_id, actived);
+-- The index is replaced by idx_user_session_user_id_actived in 0009_session_lifetime, so it isn't created again
+-- on the schemas which were migrated by hand before the migrations were versioned.
+do $$
+begin
+    if not exists (select 1 from pg_indexes where indexname = 'idx_user_session_user_id_actived') then
+        create unique index if not exists idx_user_id_actived on user_session(user_id, actived);
+    end if;
+end $$;
 
 create table if not exists events (
 	id serial unique not null,
@@ -0,0 +1,65 @@
+create table if not exists roles (
+    id serial unique not null,
+    name varchar unique not null,
+    description varchar,
+    created_at timestamp not null default now(),
+
+    primary key (id)
+);
+
+create table if not exists permissions (
+    id serial unique not null,
+    name varchar unique not null,
+    description varchar,
+    created_at timestamp not null default now(),
+
+    primary key (id)
+);
+
+create table if not exists role_permissions (
+    role_id integer not null,
+    permission_id integer not null,
+
+    primary key (role_id, permission_id),
+    foreign key (role_id) references roles(id) on delete cascade,
+    foreign key (permission_id) references permissions(id) on delete cascade
+);
+
+create table if not exists user_roles (
+    user_id integer not null,
+    role_id integer not null,
+    created_at timestamp not null default now(),
+
+    primary key (user_id, role_id),
+    foreign key (user_id) references users(id) on delete cascade,
+    foreign key (role_id) references roles(id) on delete cascade
+);
+
+-- Default roles and permissions.
+insert into roles (name, description) values
+    ('admin', 'Full access to the users and chat administration'),
+    ('moderator', 'Chat moderation'),
+    ('user', 'Default role for every registered user')
+on conflict (name) do nothing;
+
+insert into permissions (name, description) values
+    ('users:admin', 'Manage users and their roles'),
+    ('users:read', 'Read users information'),
+    ('chat:moderate', 'Moderate chat rooms and messages'),
+    ('chat:write', 'Write chat messages')
+on conflict (name) do nothing;
+
+insert into role_permissions (role_id, permission_id)
+    select r.id, p.id from roles r, permissions p
+    where r.name = 'admin'
+on conflict do nothing;
+
+insert into role_permissions (role_id, permission_id)
+    select r.id, p.id from roles r, permissions p
+    where r.name = 'moderator' and p.name in ('users:read', 'chat:moderate', 'chat:write')
+on conflict do nothing;
+
+insert into role_permissions (role_id, permission_id)
+    select r.id, p.id from roles r, permissions p
+    where r.name = 'user' and p.name = 'chat:write'
+on conflict do nothing;
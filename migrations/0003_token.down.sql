@@ -0,0 +1 @@
+drop table if exists access_token;
@@ -0,0 +1,18 @@
+create table if not exists access_token (
+    id serial unique not null,
+    user_id integer not null,
+    name varchar not null,
+    prefix varchar not null,
+    hash varchar unique not null,
+    scopes varchar[] not null default '{}',
+    expires_at timestamp,
+    last_used_at timestamp,
+    last_used_ip varchar,
+    created_at timestamp not null,
+    revoked_at timestamp,
+
+    primary key (id),
+    foreign key (user_id) references users(id) on delete cascade
+);
+
+create index if not exists idx_access_token_user_id on access_token(user_id);
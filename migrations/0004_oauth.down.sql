@@ -0,0 +1,3 @@
+drop table if exists oauth_token;
+drop table if exists oauth_authorization_code;
+drop table if exists oauth_client;
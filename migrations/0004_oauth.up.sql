@@ -0,0 +1,52 @@
+create table if not exists oauth_client (
+    id varchar unique not null,
+    name varchar not null,
+    secret_hash varchar,
+    redirect_uris varchar[] not null default '{}',
+    scopes varchar[] not null default '{}',
+    created_at timestamp not null,
+
+    primary key (id)
+);
+
+create table if not exists oauth_authorization_code (
+    hash varchar unique not null,
+    client_id varchar not null,
+    user_id integer not null,
+    session_id integer not null,
+    redirect_uri varchar not null,
+    scopes varchar[] not null default '{}',
+    code_challenge varchar not null,
+    code_challenge_method varchar not null,
+    nonce varchar,
+    auth_time timestamp not null,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null,
+
+    primary key (hash),
+    foreign key (client_id) references oauth_client(id) on delete cascade,
+    foreign key (user_id) references users(id) on delete cascade,
+    foreign key (session_id) references user_session(id)
+);
+
+create table if not exists oauth_token (
+    id serial unique not null,
+    hash varchar unique not null,
+    type varchar not null,
+    client_id varchar not null,
+    user_id integer not null,
+    session_id integer not null,
+    scopes varchar[] not null default '{}',
+    auth_time timestamp not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    revoked_at timestamp,
+
+    primary key (id),
+    foreign key (client_id) references oauth_client(id) on delete cascade,
+    foreign key (user_id) references users(id) on delete cascade,
+    foreign key (session_id) references user_session(id)
+);
+
+create index if not exists idx_oauth_token_session_id on oauth_token(session_id);
@@ -0,0 +1,11 @@
+create table if not exists signing_key (
+    kid varchar unique not null,
+    algorithm varchar not null,
+    private_key text not null,
+    created_at timestamp not null,
+    activates_at timestamp not null,
+    retires_at timestamp not null,
+    expires_at timestamp not null,
+
+    primary key (kid)
+);
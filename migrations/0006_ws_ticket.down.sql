@@ -0,0 +1,4 @@
+drop table if exists ws_ticket;
+
+delete from permissions where name = 'ws:verify';
+delete from roles where name = 'service';
@@ -0,0 +1,26 @@
+create table if not exists ws_ticket (
+    hash varchar unique not null,
+    user_id integer not null,
+    session_id integer not null,
+    origin varchar not null,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null,
+
+    primary key (hash),
+    foreign key (user_id) references users(id) on delete cascade,
+    foreign key (session_id) references user_session(id)
+);
+
+insert into roles (name, description) values
+    ('service', 'Internal services like the chat socket server')
+on conflict (name) do nothing;
+
+insert into permissions (name, description) values
+    ('ws:verify', 'Verify WebSocket connection tickets')
+on conflict (name) do nothing;
+
+insert into role_permissions (role_id, permission_id)
+    select r.id, p.id from roles r, permissions p
+    where r.name in ('admin', 'service') and p.name = 'ws:verify'
+on conflict do nothing;
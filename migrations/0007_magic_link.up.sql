@@ -0,0 +1,13 @@
+create table if not exists magic_link (
+    hash varchar unique not null,
+    user_id integer not null,
+    email varchar not null,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null,
+
+    primary key (hash),
+    foreign key (user_id) references users(id) on delete cascade
+);
+
+create index if not exists idx_magic_link_email_created_at on magic_link(email, created_at);
@@ -0,0 +1,13 @@
+create table if not exists email_code (
+    id serial unique not null,
+    email varchar not null,
+    hash varchar not null,
+    attempts integer not null default 0,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null,
+
+    primary key (id)
+);
+
+create index if not exists idx_email_code_email_created_at on email_code(email, created_at);
@@ -0,0 +1,29 @@
+alter table ws_ticket
+    drop constraint if exists ws_ticket_session_id_fkey,
+    add constraint ws_ticket_session_id_fkey foreign key (session_id) references user_session(id);
+
+alter table oauth_token
+    drop constraint if exists oauth_token_session_id_fkey,
+    add constraint oauth_token_session_id_fkey foreign key (session_id) references user_session(id);
+
+alter table oauth_authorization_code
+    drop constraint if exists oauth_authorization_code_session_id_fkey,
+    add constraint oauth_authorization_code_session_id_fkey foreign key (session_id) references user_session(id);
+
+alter table sudo_events
+    drop constraint if exists sudo_events_sudo_id_fkey,
+    add constraint sudo_events_sudo_id_fkey foreign key (sudo_id) references sudo(id);
+
+alter table sudo
+    drop constraint if exists sudo_session_id_fkey,
+    add constraint sudo_session_id_fkey foreign key (session_id) references user_session(id);
+
+drop index if exists idx_user_session_last_seen_at;
+drop index if exists idx_user_session_user_id_actived;
+
+-- The old index only allows a inactive session by user, the other inactive sessions are kept out of it.
+update user_session s set actived = null
+where
+    not actived
+    and exists (select 1 from user_session o where o.user_id = s.user_id and not o.actived and o.id > s.id);
+create unique index if not exists idx_user_id_actived on user_session(user_id, actived);
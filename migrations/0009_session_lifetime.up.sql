@@ -0,0 +1,25 @@
+-- Only the active session of a user must be unique, a user can have many inactive sessions.
+drop index if exists idx_user_id_actived;
+create unique index if not exists idx_user_session_user_id_actived on user_session(user_id) where actived;
+create index if not exists idx_user_session_last_seen_at on user_session(last_seen_at);
+
+-- The records which depend on a session are deleted with it when the session is purged.
+alter table sudo
+    drop constraint if exists sudo_session_id_fkey,
+    add constraint sudo_session_id_fkey foreign key (session_id) references user_session(id) on delete cascade;
+
+alter table sudo_events
+    drop constraint if exists sudo_events_sudo_id_fkey,
+    add constraint sudo_events_sudo_id_fkey foreign key (sudo_id) references sudo(id) on delete cascade;
+
+alter table oauth_authorization_code
+    drop constraint if exists oauth_authorization_code_session_id_fkey,
+    add constraint oauth_authorization_code_session_id_fkey foreign key (session_id) references user_session(id) on delete cascade;
+
+alter table oauth_token
+    drop constraint if exists oauth_token_session_id_fkey,
+    add constraint oauth_token_session_id_fkey foreign key (session_id) references user_session(id) on delete cascade;
+
+alter table ws_ticket
+    drop constraint if exists ws_ticket_session_id_fkey,
+    add constraint ws_ticket_session_id_fkey foreign key (session_id) references user_session(id) on delete cascade;
@@ -0,0 +1,13 @@
+create table if not exists session_token (
+    hash varchar unique not null,
+    session_id integer,
+    data bytea not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+
+    primary key (hash),
+    foreign key (session_id) references user_session(id) on delete cascade
+);
+
+create index if not exists idx_session_token_session_id on session_token(session_id);
@@ -0,0 +1 @@
+drop table if exists remember_token;
@@ -0,0 +1,12 @@
+create table if not exists remember_token (
+    series varchar unique not null,
+    hash varchar not null,
+    user_id integer not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    last_used_at timestamp,
+    revoked_at timestamp,
+
+    primary key (series),
+    foreign key (user_id) references users(id) on delete cascade
+);
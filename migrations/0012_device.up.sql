@@ -0,0 +1,17 @@
+create table if not exists user_device (
+    id serial unique not null,
+    user_id integer not null,
+    cookie_hash varchar,
+    user_agent_family varchar not null,
+    ip_network varchar not null,
+    session_id integer,
+    revoke_hash varchar unique,
+    first_seen_at timestamp not null,
+    last_seen_at timestamp not null,
+
+    primary key (id),
+    foreign key (user_id) references users(id) on delete cascade,
+    foreign key (session_id) references user_session(id) on delete set null
+);
+
+create index if not exists idx_user_device_user_id on user_device(user_id);
@@ -0,0 +1,10 @@
+create table if not exists password_reset (
+    hash varchar unique not null,
+    user_id integer not null,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null,
+
+    primary key (hash),
+    foreign key (user_id) references users(id) on delete cascade
+);
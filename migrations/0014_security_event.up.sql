@@ -0,0 +1,15 @@
+create table if not exists security_event (
+    id serial unique not null,
+    user_id integer not null,
+    type varchar not null,
+    method varchar,
+    ip varchar,
+    user_agent varchar,
+    session_id integer,
+    created_at timestamp not null,
+
+    primary key (id),
+    foreign key (user_id) references users(id) on delete cascade
+);
+
+create index if not exists idx_security_event_user_id_id on security_event(user_id, id desc);
@@ -0,0 +1,7 @@
+drop table if exists audit_log;
+
+delete from user_session where impersonator_id is not null;
+drop index if exists idx_user_session_user_id_actived;
+create unique index if not exists idx_user_session_user_id_actived on user_session(user_id) where actived;
+
+alter table user_session drop column if exists impersonator_id;
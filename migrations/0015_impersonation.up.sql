@@ -0,0 +1,25 @@
+-- The impersonation sessions are marked with the admin which is using them.
+alter table user_session
+    add column if not exists impersonator_id integer references users(id) on delete cascade;
+
+-- A impersonation session doesn't replace the active session of the user.
+drop index if exists idx_user_session_user_id_actived;
+create unique index if not exists idx_user_session_user_id_actived on user_session(user_id) where actived and impersonator_id is null;
+
+create table if not exists audit_log (
+    id serial unique not null,
+    actor_id integer not null,
+    action varchar not null,
+    target_user_id integer,
+    session_id integer,
+    reason varchar,
+    ip varchar,
+    created_at timestamp not null,
+
+    primary key (id),
+    foreign key (actor_id) references users(id),
+    foreign key (target_user_id) references users(id)
+);
+
+create index if not exists idx_audit_log_actor_id on audit_log(actor_id);
+create index if not exists idx_audit_log_target_user_id on audit_log(target_user_id);
@@ -0,0 +1,3 @@
+drop table if exists invite;
+
+delete from permissions where name = 'invites:manage';
@@ -0,0 +1,22 @@
+create table if not exists invite (
+    id serial unique not null,
+    hash varchar unique not null,
+    created_by integer not null,
+    max_uses integer not null,
+    uses integer not null default 0,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    revoked_at timestamp,
+
+    primary key (id),
+    foreign key (created_by) references users(id) on delete cascade
+);
+
+insert into permissions (name, description) values
+    ('invites:manage', 'Create and revoke registration invites')
+on conflict (name) do nothing;
+
+insert into role_permissions (role_id, permission_id)
+    select r.id, p.id from roles r, permissions p
+    where r.name = 'admin' and p.name = 'invites:manage'
+on conflict do nothing;
@@ -0,0 +1,2 @@
+drop index if exists idx_users_nickname_skeleton;
+alter table users drop column if exists nickname_skeleton;
@@ -0,0 +1,8 @@
+-- The uniqueness of the nicknames is checked on their skeleton, so the nicknames which only differ
+-- in casing or confusable characters can't be registered twice. The nickname keeps the display casing.
+alter table users add column if not exists nickname_skeleton varchar;
+
+-- The existing nicknames are ASCII only, so its lowercase form is a close enough skeleton.
+update users set nickname_skeleton = lower(nickname) where nickname_skeleton is null and nickname is not null;
+
+create unique index if not exists idx_users_nickname_skeleton on users(nickname_skeleton);
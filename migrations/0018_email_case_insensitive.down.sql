@@ -0,0 +1,4 @@
+-- The emails keep their lowercase form, the original casing isn't known anymore.
+drop index if exists idx_users_email_lower;
+alter table users drop constraint if exists users_email_key;
+alter table users add constraint users_email_key unique (email);
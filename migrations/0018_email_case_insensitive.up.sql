@@ -0,0 +1,13 @@
+-- The emails are case-insensitive, they are kept lowercase and unique regardless of their casing.
+-- The emails which only differ in casing from the one of a older user get a "+dup<id>" suffix in their local
+-- part, so the oldest account keeps the email and the others can still be found and merged by hand.
+update users u set email = regexp_replace(lower(trim(u.email)), '(@[^@]*)?$', '+dup' || u.id || '\1')
+where
+    exists (
+        select 1 from users o where o.id < u.id and lower(trim(o.email)) = lower(trim(u.email))
+    );
+
+update users set email = lower(trim(email)) where email <> lower(trim(email));
+
+alter table users drop constraint if exists users_email_key;
+create unique index if not exists idx_users_email_lower on users(lower(email));
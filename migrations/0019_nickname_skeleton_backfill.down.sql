@@ -0,0 +1 @@
+-- The recomputed skeletons are kept, they are valid for the previous versions too.
@@ -0,0 +1,3 @@
+-- The skeletons of the existing nicknames are recomputed by the Go step of this migration, with the same
+-- function used on the sign up, so the nicknames registered before 0017_nickname_skeleton are also protected.
+-- The newer nicknames whose skeleton is taken by a older one get the id of their user as suffix.
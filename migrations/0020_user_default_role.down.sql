@@ -0,0 +1 @@
+-- The default role is kept, it can't be told apart from the one assigned on the sign up.
@@ -0,0 +1,5 @@
+-- The users registered before 0002_role never got the default role, every new user gets it on the sign up.
+insert into user_roles (user_id, role_id)
+    select u.id, r.id from users u, roles r
+    where r.name = 'user'
+on conflict do nothing;
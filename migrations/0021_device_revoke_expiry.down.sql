@@ -0,0 +1 @@
+alter table user_device drop column if exists revoke_expires_at;
@@ -0,0 +1,4 @@
+alter table user_device add column if not exists revoke_expires_at timestamp;
+
+-- The tokens sent before have the default lifetime since their device login.
+update user_device set revoke_expires_at = first_seen_at + interval '7 days' where revoke_hash is not null;
@@ -0,0 +1,5 @@
+// Package migrations keeps the versioned database migrations, embedded in the binary.
+// Every migration has a "<version>_<name>.up.sql" file which applies it and a "<version>_<name>.down.sql"
+// file which reverts it, like "0001_user.up.sql". They are run by the "migrate" subcommand.
+
+package migrations
@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+var (
+	fileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	nameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Migration is a versioned change of the database schema.
+type Migration struct {
+	Version int
+	Name    string
+
+	// Up applies the migration and Down reverts it.
+	Up   string
+	Down string
+}
+
+// Checksum gets the SHA-256 checksum of the up migration, to detect the changes of the applied migrations.
+//  @return $1 string: hex encoded checksum.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// String gets the full name of the migration, like "0001_user".
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// All gets the migrations embedded in the binary.
+//  @return $1 []Migration: migrations sorted by version.
+//  @return $2 error: invalid migration files error.
+func All() ([]Migration, error) {
+	return Parse(files)
+}
+
+// Parse parses the migration files of the file system provided, the other files are ignored.
+//  @param fsys fs.FS: file system with the migration files in its root.
+//  @return list []Migration: migrations sorted by version.
+//  @return err error: duplicated versions or migrations without both up and down files.
+func Parse(fsys fs.FS) (list []Migration, err error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		err = fmt.Errorf("failed to read migrations: %s", err)
+		return
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		match := fileRegex.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			err = fmt.Errorf("invalid migrations: version %d is used by %s and %s", version, m.Name, match[2])
+			return
+		}
+
+		var raw []byte
+		raw, err = fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			err = fmt.Errorf("failed to read migration %s: %s", e.Name(), err)
+			return
+		}
+		if match[3] == "up" {
+			m.Up = string(raw)
+		} else {
+			m.Down = string(raw)
+		}
+	}
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			err = fmt.Errorf("invalid migrations: %s must have a up and a down file", m)
+			return
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return
+}
+
+// Create creates the empty up and down files of a new migration, numbered after the last one of the dir.
+//  @param dir string: dir of the migration files.
+//  @param name string: name of the migration, only lowercase letters, digits and underscores.
+//  @return up string: path of the up file.
+//  @return down string: path of the down file.
+//  @return err error: invalid name, invalid migrations or writing error.
+func Create(dir, name string) (up, down string, err error) {
+	if !nameRegex.MatchString(name) {
+		err = fmt.Errorf("invalid migration name: %s must have only lowercase letters, digits and underscores", name)
+		return
+	}
+
+	list, err := Parse(os.DirFS(dir))
+	if err != nil {
+		return
+	}
+
+	m := Migration{Version: 1, Name: name}
+	if len(list) > 0 {
+		m.Version = list[len(list)-1].Version + 1
+	}
+
+	up = filepath.Join(dir, m.String()+".up.sql")
+	down = filepath.Join(dir, m.String()+".down.sql")
+	err = os.WriteFile(up, []byte(fmt.Sprintf("-- %s: describe the schema change here.\n", m)), 0644)
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(down, []byte(fmt.Sprintf("-- Reverts %s.\n", m)), 0644)
+	return
+}
@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	t.Run("Given the embedded migrations When getting all Then consecutive versions with up and down", func(t *testing.T) {
+		list, err := All()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, list)
+
+		for i, m := range list {
+			assert.Equal(t, i+1, m.Version, m.String())
+			assert.NotEmpty(t, m.Up, m.String())
+			assert.NotEmpty(t, m.Down, m.String())
+		}
+		assert.Equal(t, "0001_user", list[0].String())
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Given migration files When parsing Then migrations sorted by version", func(t *testing.T) {
+		list, err := Parse(fstest.MapFS{
+			"0002_second.up.sql":   {Data: []byte("create table second ();")},
+			"0002_second.down.sql": {Data: []byte("drop table second;")},
+			"0001_first.up.sql":    {Data: []byte("create table first ();")},
+			"0001_first.down.sql":  {Data: []byte("drop table first;")},
+			"migrations.go":        {Data: []byte("package migrations")},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "first", Up: "create table first ();", Down: "drop table first;"},
+			{Version: 2, Name: "second", Up: "create table second ();", Down: "drop table second;"},
+		}, list)
+		assert.NotEqual(t, list[0].Checksum(), list[1].Checksum())
+	})
+
+	t.Run("Given a migration without down file When parsing Then error", func(t *testing.T) {
+		_, err := Parse(fstest.MapFS{
+			"0001_first.up.sql": {Data: []byte("create table first ();")},
+		})
+		assert.EqualError(t, err, "invalid migrations: 0001_first must have a up and a down file")
+	})
+
+	t.Run("Given a duplicated version When parsing Then error", func(t *testing.T) {
+		_, err := Parse(fstest.MapFS{
+			"0001_first.up.sql":   {Data: []byte("create table first ();")},
+			"0001_other.up.sql":   {Data: []byte("create table other ();")},
+			"0001_first.down.sql": {Data: []byte("drop table first;")},
+		})
+		assert.Contains(t, err.Error(), "invalid migrations: version 1 is used by")
+	})
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("Given existing migrations When creating a migration Then numbered after the last one", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "0007_last.up.sql"), []byte("select 1;"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "0007_last.down.sql"), []byte("select 1;"), 0644))
+
+		up, down, err := Create(dir, "add_rooms")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "0008_add_rooms.up.sql"), up)
+		assert.Equal(t, filepath.Join(dir, "0008_add_rooms.down.sql"), down)
+
+		list, err := Parse(os.DirFS(dir))
+		assert.NoError(t, err)
+		assert.Len(t, list, 2)
+	})
+
+	t.Run("Given a invalid name When creating a migration Then error", func(t *testing.T) {
+		_, _, err := Create(t.TempDir(), "Add Rooms")
+		assert.Contains(t, err.Error(), "invalid migration name")
+	})
+}
@@ -0,0 +1,3 @@
+// Package notify implements the security notifications sent to the users.
+
+package notify
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coffemanfp/chat/mail"
+	"github.com/coffemanfp/chat/users"
+)
+
+// NewDeviceLogin represents a login of a user from a unrecognized device.
+type NewDeviceLogin struct {
+	User users.User
+
+	// Method is the platform which the user has been sign with.
+	Method    string
+	UserAgent string
+	IP        string
+	At        time.Time
+
+	// RevokeURL is the "this wasn't me" link, which revokes the session and starts a password reset.
+	RevokeURL string
+}
+
+// Notifier defines the behaviors to be used by a security notifications implementation.
+type Notifier interface {
+	// NotifyNewDeviceLogin notifies the user about a login from a unrecognized device.
+	//  @param n NewDeviceLogin: login to notify.
+	//  @return $1 error: delivery error.
+	NotifyNewDeviceLogin(n NewDeviceLogin) error
+}
+
+// EmailNotifier is the Notifier implementation which sends the notifications by email.
+type EmailNotifier struct {
+	mailer mail.Mailer
+}
+
+// NewEmailNotifier initializes a new EmailNotifier instance.
+//  @param mailer mail.Mailer: mailer to send the notifications.
+//  @return $1 EmailNotifier: new EmailNotifier instance.
+func NewEmailNotifier(mailer mail.Mailer) EmailNotifier {
+	return EmailNotifier{
+		mailer: mailer,
+	}
+}
+
+func (e EmailNotifier) NotifyNewDeviceLogin(n NewDeviceLogin) (err error) {
+	if n.User.Email == "" {
+		return
+	}
+
+	err = e.mailer.Send(mail.Message{
+		To:      n.User.Email,
+		Subject: "New login to your account",
+		Body: fmt.Sprintf(
+			"Your account has been logged in from a new device.\n\nWhen: %s\nDevice: %s\nIP address: %s\nMethod: %s\n\nIf it wasn't you, use the following link to close that session and reset your password:\n\n%s\n",
+			n.At.UTC().Format(time.RFC1123), n.UserAgent, n.IP, n.Method, n.RevokeURL,
+		),
+	})
+	return
+}
@@ -0,0 +1,4 @@
+// Package secrets implements the providers which resolve the secret references of the config,
+// so the secrets don't need to be kept in plain text in the config files or the env vars.
+
+package secrets
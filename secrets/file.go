@@ -0,0 +1,138 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KEY_SIZE is the size in bytes of the keys of the encrypted secrets files, for AES-256.
+const KEY_SIZE = 32
+
+// EncryptedFileSecretProvider is the SecretProvider implementation for a local encrypted file.
+//  The file keeps a YAML map of the secrets by name, encrypted with AES-256-GCM and encoded in base64.
+//  The file is decrypted once, when the provider is initialized.
+type EncryptedFileSecretProvider struct {
+	secrets map[string]string
+}
+
+// NewEncryptedFileSecretProvider initializes a new EncryptedFileSecretProvider instance.
+//  @param path string: path of the encrypted secrets file, see EncryptSecrets.
+//  @param key []byte: key of the file, see ParseKey.
+//  @return p EncryptedFileSecretProvider: new EncryptedFileSecretProvider instance.
+//  @return err error: unreadable file, wrong key or corrupted file error.
+func NewEncryptedFileSecretProvider(path string, key []byte) (p EncryptedFileSecretProvider, err error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("failed to read secrets file %s: %s", path, err)
+		return
+	}
+
+	p.secrets, err = DecryptSecrets(key, raw)
+	if err != nil {
+		err = fmt.Errorf("failed to read secrets file %s: %s", path, err)
+	}
+	return
+}
+
+func (e EncryptedFileSecretProvider) Secret(name string) (value string, err error) {
+	value, ok := e.secrets[name]
+	if !ok {
+		err = errNotFound(name)
+	}
+	return
+}
+
+// ParseKey parses a base64 encoded key of a encrypted secrets file.
+//  @param s string: base64 encoded key of KEY_SIZE bytes.
+//  @return key []byte: decoded key.
+//  @return err error: invalid encoding or size error.
+func ParseKey(s string) (key []byte, err error) {
+	key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		err = fmt.Errorf("invalid secrets key: %s", err)
+		return
+	}
+	if len(key) != KEY_SIZE {
+		err = fmt.Errorf("invalid secrets key: it must have %d bytes, but it has %d", KEY_SIZE, len(key))
+	}
+	return
+}
+
+// EncryptSecrets encrypts the secrets provided in the format of the encrypted secrets files.
+//  @param key []byte: key of KEY_SIZE bytes.
+//  @param secrets map[string]string: secrets by name.
+//  @return raw []byte: content of the encrypted file.
+//  @return err error: invalid key error.
+func EncryptSecrets(key []byte, secrets map[string]string) (raw []byte, err error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return
+	}
+
+	plain, err := yaml.Marshal(secrets)
+	if err != nil {
+		return
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return
+	}
+
+	sealed := aead.Seal(nonce, nonce, plain, nil)
+	raw = []byte(base64.StdEncoding.EncodeToString(sealed) + "\n")
+	return
+}
+
+// DecryptSecrets decrypts the content of a encrypted secrets file.
+//  @param key []byte: key of KEY_SIZE bytes.
+//  @param raw []byte: content of the encrypted file.
+//  @return secrets map[string]string: secrets by name.
+//  @return err error: invalid key, wrong key or corrupted file error.
+func DecryptSecrets(key, raw []byte) (secrets map[string]string, err error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		err = fmt.Errorf("invalid encoding: %s", err)
+		return
+	}
+	if len(sealed) < aead.NonceSize() {
+		err = fmt.Errorf("invalid content: too short")
+		return
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		err = fmt.Errorf("wrong key or corrupted content")
+		return
+	}
+
+	err = yaml.Unmarshal(plain, &secrets)
+	return
+}
+
+func newAEAD(key []byte) (aead cipher.AEAD, err error) {
+	if len(key) != KEY_SIZE {
+		err = fmt.Errorf("invalid secrets key: it must have %d bytes, but it has %d", KEY_SIZE, len(key))
+		return
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,28 @@
+package secrets
+
+import "fmt"
+
+// SecretProvider defines the behaviors to be used by a secrets store implementation.
+type SecretProvider interface {
+	// Secret gets the secret value of the name provided.
+	//  @param name string: name of the secret, its format depends on the implementation.
+	//  @return $1 string: secret value.
+	//  @return $2 error: not found secret, or a connection error.
+	Secret(name string) (string, error)
+}
+
+// FakeSecretProvider is the SecretProvider implementation which keeps the secrets in memory.
+//  It is intended for tests and development.
+type FakeSecretProvider map[string]string
+
+func (f FakeSecretProvider) Secret(name string) (value string, err error) {
+	value, ok := f[name]
+	if !ok {
+		err = errNotFound(name)
+	}
+	return
+}
+
+func errNotFound(name string) error {
+	return fmt.Errorf("not found: secret %s not found", name)
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedFileSecretProvider(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KEY_SIZE)
+	raw, err := EncryptSecrets(key, map[string]string{"db_pass": "s3cr3t"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "s3cr3t")
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	assert.NoError(t, os.WriteFile(path, raw, 0600))
+
+	t.Run("Given the file key When getting a secret Then secret value", func(t *testing.T) {
+		p, err := NewEncryptedFileSecretProvider(path, key)
+		assert.NoError(t, err)
+
+		value, err := p.Secret("db_pass")
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cr3t", value)
+
+		_, err = p.Secret("missing")
+		assert.EqualError(t, err, "not found: secret missing not found")
+	})
+
+	t.Run("Given a wrong key When reading the file Then error", func(t *testing.T) {
+		_, err := NewEncryptedFileSecretProvider(path, bytes.Repeat([]byte{8}, KEY_SIZE))
+		assert.Contains(t, err.Error(), "wrong key or corrupted content")
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	t.Run("Given a base64 key When parsing Then key", func(t *testing.T) {
+		key, err := ParseKey("BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=\n")
+		assert.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{7}, KEY_SIZE), key)
+	})
+
+	t.Run("Given a short key When parsing Then error", func(t *testing.T) {
+		_, err := ParseKey("c2hvcnQ=")
+		assert.EqualError(t, err, "invalid secrets key: it must have 32 bytes, but it has 5")
+	})
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+		if r.URL.Path != "/v1/secret/data/chat/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"password":"s3cr3t","value":"default"}}}`))
+	}))
+	defer srv.Close()
+
+	p := NewVaultSecretProvider(srv.URL+"/", "token", "secret")
+
+	t.Run("Given a path and key When getting a secret Then secret value", func(t *testing.T) {
+		value, err := p.Secret("chat/db#password")
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cr3t", value)
+	})
+	t.Run("Given a path without key When getting a secret Then default key value", func(t *testing.T) {
+		value, err := p.Secret("chat/db")
+		assert.NoError(t, err)
+		assert.Equal(t, "default", value)
+	})
+	t.Run("Given a unknown path When getting a secret Then not found error", func(t *testing.T) {
+		_, err := p.Secret("chat/other#password")
+		assert.EqualError(t, err, "not found: secret chat/other#password not found")
+	})
+}
+
+func TestFakeSecretProvider(t *testing.T) {
+	p := FakeSecretProvider{"db_pass": "s3cr3t"}
+
+	value, err := p.Secret("db_pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	_, err = p.Secret("missing")
+	assert.Error(t, err)
+}
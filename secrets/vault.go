@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VAULT_DEFAULT_KEY is the key read from a Vault secret when the name doesn't provide one.
+const VAULT_DEFAULT_KEY = "value"
+
+// VaultSecretProvider is the SecretProvider implementation for the HashiCorp Vault KV version 2 secrets engine.
+//  The names have the format "<path>#<key>", like "chat/db#password". The key is VAULT_DEFAULT_KEY if it's omitted.
+type VaultSecretProvider struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+// NewVaultSecretProvider initializes a new VaultSecretProvider instance.
+//  @param addr string: address of the Vault server, like "https://vault.example:8200".
+//  @param token string: Vault token with read access to the secrets.
+//  @param mount string: mount path of the KV secrets engine, like "secret".
+//  @return $1 VaultSecretProvider: new VaultSecretProvider instance.
+func NewVaultSecretProvider(addr, token, mount string) VaultSecretProvider {
+	return VaultSecretProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v VaultSecretProvider) Secret(name string) (value string, err error) {
+	path, key := name, VAULT_DEFAULT_KEY
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		path, key = name[:i], name[i+1:]
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/%s/data/%s", v.addr, v.mount, strings.Trim(path, "/")), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to read secret %s from vault: %s", name, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		err = errNotFound(name)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("failed to read secret %s from vault: unexpected status %d", name, resp.StatusCode)
+		return
+	}
+
+	result := struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		err = fmt.Errorf("failed to decode secret %s from vault: %s", name, err)
+		return
+	}
+
+	raw, ok := result.Data.Data[key]
+	if !ok {
+		err = errNotFound(name)
+		return
+	}
+	value, ok = raw.(string)
+	if !ok {
+		err = fmt.Errorf("invalid secret %s: the value isn't a string", name)
+	}
+	return
+}
@@ -6,11 +6,15 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/captcha"
 	"github.com/coffemanfp/chat/config"
 	"github.com/coffemanfp/chat/database"
 	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/mail"
+	"github.com/coffemanfp/chat/notify"
 	"github.com/coffemanfp/chat/server/handlers"
 	"github.com/coffemanfp/chat/users"
 	"github.com/gorilla/mux"
@@ -28,11 +32,26 @@ func (hN handlerName) string() string {
 // AuthHandler represents a handler for sign actions like sign up and login.
 // Handles external sign platforms and own-server sign service.
 type AuthHandler struct {
-	config     config.ConfigInfo
-	repository database.AuthRepository
-	writer     handlers.ResponseWriter
-	reader     handlers.RequestReader
-	store      *sessions.CookieStore
+	config      config.ConfigInfo
+	live        config.Config
+	repository  database.AuthRepository
+	roles       database.RoleRepository
+	tokens      database.TokenRepository
+	sessions    database.SessionRepository
+	remembers   database.RememberTokenRepository
+	users       database.UsersRepository
+	devices     database.DeviceRepository
+	resets      database.PasswordResetRepository
+	securityLog database.SecurityLogRepository
+	audit       database.AuditLogRepository
+	invites     database.InviteRepository
+	notifier    notify.Notifier
+	writer      handlers.ResponseWriter
+	reader      handlers.RequestReader
+	store       sessions.Store
+
+	// signUpGuards keeps the checks which run before a new user is built.
+	signUpGuards []signUpGuard
 
 	// userReaders keeps the services to be used for read the user info which is trying to sign.
 	userReaders map[handlerName]userReader
@@ -60,35 +79,92 @@ type externalSignUpHandler interface {
 	requestSignUp(w http.ResponseWriter, r *http.Request) error
 }
 
+// Repositories keeps the repositories used by the AuthHandler.
+type Repositories struct {
+	Auth           database.AuthRepository
+	Roles          database.RoleRepository
+	Tokens         database.TokenRepository
+	Sessions       database.SessionRepository
+	Users          database.UsersRepository
+	SessionTokens  database.SessionTokenRepository
+	RememberTokens database.RememberTokenRepository
+	MagicLinks     database.MagicLinkRepository
+	EmailCodes     database.EmailCodeRepository
+	Devices        database.DeviceRepository
+	PasswordResets database.PasswordResetRepository
+	SecurityLog    database.SecurityLogRepository
+	AuditLog       database.AuditLogRepository
+	Invites        database.InviteRepository
+}
+
 // NewAuthHandler initializes a new AuthHandler instance.
-//  @param repo database.AuthRepository: AuthRepository interface for the authentication handling.
+//  @param repos Repositories: repositories for the authentication and authorization handling.
+//  @param mailer mail.Mailer: Mailer interface for the emails sent by the passwordless handlers.
+//  @param notifier notify.Notifier: Notifier interface for the security notifications.
+//  @param verifier captcha.CaptchaVerifier: CaptchaVerifier interface for the sign up CAPTCHA, nil to disable it.
 //  @param r handlers.RequestReader: RequestReader interface for reading request operations.
 //  @param w handlers.ResponseWriter: ResponseWriter interface for writing request response operations.
-//  @param conf config.ConfigInfo: keeps the config information of the service.
+//  @param live config.Config: keeps the config information of the service, its reloadable values are read on every use.
 //  @return u AuthHandler: new AuthHandler instance.
-func NewAuthHandler(repo database.AuthRepository, r handlers.RequestReader, w handlers.ResponseWriter, conf config.ConfigInfo) (u AuthHandler) {
-	fbHandler := newFacebookHandler(conf)
-	gHandler := newGoogleHandler(conf)
-	store := sessions.NewCookieStore([]byte("veryprivatekey"))
-	return AuthHandler{
-		reader:     r,
-		writer:     w,
-		repository: repo,
-		config:     conf,
-		store:      store,
+//  @return err error: invalid session store, registration or sign up config error.
+func NewAuthHandler(repos Repositories, mailer mail.Mailer, notifier notify.Notifier, verifier captcha.CaptchaVerifier, r handlers.RequestReader, w handlers.ResponseWriter, live config.Config) (u AuthHandler, err error) {
+	conf := live.Get()
+	store, err := newSessionStore(repos.SessionTokens, conf)
+	if err != nil {
+		return
+	}
+
+	err = checkRegistrationConfig(conf)
+	if err != nil {
+		return
+	}
+
+	guards, err := newSignUpGuards(live, verifier)
+	if err != nil {
+		return
+	}
+
+	fbHandler := newFacebookHandler(live)
+	gHandler := newGoogleHandler(live)
+	mlHandler := newMagicLinkHandler(live, repos.MagicLinks, repos.Users, mailer, r, w)
+	ecHandler := newEmailCodeHandler(live, repos.EmailCodes, repos.Users, mailer, r, w)
+	u = AuthHandler{
+		reader:       r,
+		writer:       w,
+		repository:   repos.Auth,
+		roles:        repos.Roles,
+		tokens:       repos.Tokens,
+		sessions:     repos.Sessions,
+		remembers:    repos.RememberTokens,
+		users:        repos.Users,
+		devices:      repos.Devices,
+		resets:       repos.PasswordResets,
+		securityLog:  repos.SecurityLog,
+		audit:        repos.AuditLog,
+		invites:      repos.Invites,
+		notifier:     notifier,
+		config:       conf,
+		live:         live,
+		store:        store,
+		signUpGuards: guards,
 		userReaders: map[handlerName]userReader{
 			systemHandlerName: systemUserReader{
 				reader: r,
 				writer: w,
 			},
-			googleHandlerName:   gHandler,
-			facebookHandlerName: fbHandler,
+			googleHandlerName:    gHandler,
+			facebookHandlerName:  fbHandler,
+			magicLinkHandlerName: mlHandler,
+			emailCodeHandlerName: ecHandler,
 		},
 		externalSignHandlers: map[handlerName]externalSignUpHandler{
-			googleHandlerName:   gHandler,
-			facebookHandlerName: fbHandler,
+			googleHandlerName:    gHandler,
+			facebookHandlerName:  fbHandler,
+			magicLinkHandlerName: mlHandler,
+			emailCodeHandlerName: ecHandler,
 		},
 	}
+	return
 }
 
 // HandleAuth implements the user authentication actions.
@@ -113,6 +189,12 @@ func (a AuthHandler) HandleAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := userReader.(passwordlessUserReader); ok && action != "login" {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid action: %s only supports login", hName)
+		a.handleError(w, err)
+		return
+	}
+
 	log.Printf("Sending %s sign up", hName)
 	user, err := userReader.read(w, r)
 	if err != nil {
@@ -120,64 +202,98 @@ func (a AuthHandler) HandleAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var sessionID int
+	var session auth.Session
 
 	switch action {
 	case "signup":
-		sessionID, err = a.handleSignUp(user, w, r)
+		session, err = a.handleSignUp(user, handlerName(hName), w, r)
 	case "login":
-		sessionID, err = a.handleLogin(user, w, r)
+		session, err = a.handleLogin(user, handlerName(hName), w, r)
 	}
 	if err != nil {
 		a.handleError(w, err)
 		return
 	}
 
-	rURL, _ := url.Parse("http://localhost:3000/chat")
-	if hName != systemHandlerName.string() {
-		http.Redirect(w, r, rURL.String(), http.StatusTemporaryRedirect)
-	}
-
 	sess, err := a.store.Get(r, authCookieName)
 	if err != nil {
 		a.handleError(w, err)
 		return
 	}
 
-	sess.Values["session_id"] = sessionID
+	// A new cookie token is issued on every sign to avoid session fixation with server-side stores.
+	err = renewSessionToken(a.store, sess)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	sess.Values["session_id"] = session.ID
 	err = sess.Save(r, w)
 	if err != nil {
 		a.handleError(w, err)
 		return
 	}
 
+	if hasCookie(r, inviteCookieName) {
+		clearInviteCookie(w)
+	}
+
+	a.recordSign(r, action, user, session)
+	a.checkDevice(w, r, session)
+
+	if action == "login" && hName == systemHandlerName.string() && user.RememberMe {
+		err = a.remember(w, session.UserID)
+		if err != nil {
+			a.handleError(w, err)
+			return
+		}
+	}
+
+	// The redirect must be written after the session cookie, otherwise the cookie is never sent.
+	rURL, _ := url.Parse("http://localhost:3000/chat")
+	if hName != systemHandlerName.string() && hName != emailCodeHandlerName.string() {
+		http.Redirect(w, r, rURL.String(), http.StatusTemporaryRedirect)
+	}
+
 	log.Printf("Success %s %s", hName, action)
 }
 
-// handleSignUp performs a sign up process for the user requested.
+// handleSignUp performs a sign up process for the user requested, once the sign up guards allowed it.
 //  @param user users.User: user to sign up.
-//  @return sessionID string: session unique identificator.
-func (a AuthHandler) handleSignUp(user users.User, w http.ResponseWriter, r *http.Request) (sessionID int, err error) {
-	user, session, err := a.signUp(user)
+//  @param hName handlerName: handler which read the user.
+//  @return session auth.Session: new session of the user.
+func (a AuthHandler) handleSignUp(user users.User, hName handlerName, w http.ResponseWriter, r *http.Request) (session auth.Session, err error) {
+	err = a.guardSignUp(r, hName, user)
 	if err != nil {
 		return
 	}
 
-	sessionID = session.ID
+	user.InviteCode = inviteCode(r, user)
+	_, session, err = a.signUp(user)
 	return
 }
 
 // handleLogin performs a login process for the user requested.
 //  @param user users.User: user to login.
-//  @return sessionID string: session unique identificator.
-func (a AuthHandler) handleLogin(user users.User, w http.ResponseWriter, r *http.Request) (sessionID int, err error) {
-	session, err := a.login(user)
-	if err != nil {
+//  @param hName handlerName: handler which read the user.
+//  @return session auth.Session: new session of the user.
+func (a AuthHandler) handleLogin(user users.User, hName handlerName, w http.ResponseWriter, r *http.Request) (session auth.Session, err error) {
+	session, err = a.login(r, user, hName)
+	return
+}
+
+// recordSign records the security events of a successful sign.
+//  A sign up with a external platform also links the platform to the new account.
+func (a AuthHandler) recordSign(r *http.Request, action string, user users.User, session auth.Session) {
+	if action != "signup" {
+		a.recordSecurityEvent(r, session.UserID, auth.SecurityEventLogin, session.LoggedWith, session.ID)
 		return
 	}
 
-	sessionID = session.ID
-	return
+	a.recordSecurityEvent(r, session.UserID, auth.SecurityEventSignUp, session.LoggedWith, session.ID)
+	for _, s := range user.SignedWith {
+		a.recordSecurityEvent(r, session.UserID, auth.SecurityEventProviderLinked, s.Platform, session.ID)
+	}
 }
 
 // handleExternalSign redirects the user to a external platform which will be used for the user sign in.
@@ -195,6 +311,8 @@ func (a AuthHandler) handleExternalSign(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("Handling %s logging...", hName)
 
+	a.setInviteCookie(w, r)
+
 	err = h.requestSignUp(w, r)
 	if err != nil {
 		a.handleError(w, err)
@@ -208,7 +326,7 @@ func (a AuthHandler) handleExternalSign(w http.ResponseWriter, r *http.Request)
 //  @param userR users.User: user to sign up.
 //	@return user users.User: ending-user information.
 //	@return session auth.Session: new session of the user.
-//	@return err error: sign up, validation or connection error
+//	@return err error: sign up, validation, registration or connection error
 func (a AuthHandler) signUp(userR users.User) (user users.User, session auth.Session, err error) {
 	log.Printf("Saving sign up of %s %s", userR.Nickname, userR.Email)
 
@@ -224,6 +342,16 @@ func (a AuthHandler) signUp(userR users.User) (user users.User, session auth.Ses
 		platform = userR.SignedWith[0].Platform
 	}
 
+	return a.admit(userR, platform)
+}
+
+// register creates the records of a new user already validated and its session.
+//  @param userR users.User: user to register.
+//  @param platform string: platform which the user has been sign.
+//	@return user users.User: ending-user information.
+//	@return session auth.Session: new session of the user.
+//	@return err error: connection error
+func (a AuthHandler) register(userR users.User, platform string) (user users.User, session auth.Session, err error) {
 	id, err := a.repository.SignUp(userR, session)
 	if err != nil {
 		return
@@ -252,27 +380,57 @@ func (a AuthHandler) signUp(userR users.User) (user users.User, session auth.Ses
 }
 
 // login performs the user login process.
+//  The password is only checked when the user wasn't read by a passwordless handler.
+//  A user without id read by a passwordless handler is registered, if the sign up guards and the registration mode allow it.
+//  A failed password check is recorded in the security log of the user.
+//  @param r *http.Request: request of the login.
 //  @param userR users.User: user to login.
+//  @param hName handlerName: handler which read the user.
 //	@return session auth.Session: new session of the user.
 //	@return err error: login, validation or connection error
-func (a AuthHandler) login(userR users.User) (session auth.Session, err error) {
+func (a AuthHandler) login(r *http.Request, userR users.User, hName handlerName) (session auth.Session, err error) {
 	log.Printf("Creating login session of %s %s", userR.Nickname, userR.Email)
 
-	id, pass, err := a.repository.GetPasswordHash(userR)
-	if err != nil {
-		return
-	}
-
-	if !auth.CheckPasswordHash(userR.Password, pass) {
-		err = sErrors.NewClientError(http.StatusUnauthorized, "credentials don't match: invalid credentials of user %s %s", userR.Nickname, userR.Email)
-		return
-	}
-
 	platform := systemHandlerName
 	if len(userR.SignedWith) > 0 {
 		platform = handlerName(userR.SignedWith[0].Platform)
 	}
 
+	var id int
+	if _, ok := a.userReaders[hName].(passwordlessUserReader); ok {
+		if userR.ID == 0 {
+			err = a.guardSignUp(r, hName, userR)
+			if err != nil {
+				return
+			}
+
+			userR.CreatedAt = time.Now()
+			userR.InviteCode = inviteCode(r, userR)
+			_, session, err = a.admit(userR, hName.string())
+			return
+		}
+		id = userR.ID
+		platform = hName
+	} else {
+		// The password logins are matched by a single identifier, so a nickname can't be taken as other user email.
+		userR.Email, userR.Nickname, err = users.ResolveIdentifier(userR.Identifier)
+		if err != nil {
+			return
+		}
+
+		var pass string
+		id, pass, err = a.repository.GetPasswordHash(userR)
+		if err != nil {
+			return
+		}
+
+		if !auth.CheckPasswordHash(userR.Password, pass) {
+			a.recordSecurityEvent(r, id, auth.SecurityEventLoginFailed, hName.string(), 0)
+			err = sErrors.NewClientError(http.StatusUnauthorized, "credentials don't match: invalid credentials of user %s", userR.Identifier)
+			return
+		}
+	}
+
 	session, err = auth.NewSession(id, platform.string())
 	if err != nil {
 		return
@@ -299,39 +457,49 @@ func (a AuthHandler) getUserReader(name handlerName) (r userReader, err error) {
 }
 
 func (a AuthHandler) CreateSudo(w http.ResponseWriter, r *http.Request) {
-	sess, err := a.store.Get(r, "sess")
+	session, err := a.Session(r)
 	if err != nil {
 		a.handleError(w, err)
 		return
 	}
-
-	sessionID, ok := sess.Values["session_id"].(int)
-	if !ok {
-		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
-		a.handleError(w, err)
+	if session.Impersonated() {
+		a.handleError(w, sErrors.NewClientError(http.StatusForbidden, "forbidden: impersonation sessions can't use sudo mode"))
 		return
 	}
 
-	sudo := auth.NewSudo(sessionID, a.config.Sudo.DurationInSecs)
+	sudo := auth.NewSudo(session.ID, a.current().Sudo.DurationInSecs)
 	err = a.repository.SaveSudo(sudo)
 	if err != nil {
 		a.handleError(w, err)
 		return
 	}
 
+	a.recordSecurityEvent(r, session.UserID, auth.SecurityEventSudoGranted, session.LoggedWith, session.ID)
 	log.Println("Success sudo")
 }
 
+// current gets the current config, with the reloadable values reloaded since the handler was created.
+func (a AuthHandler) current() config.ConfigInfo {
+	if a.live == nil {
+		return a.config
+	}
+	return a.live.Get()
+}
+
 func (a AuthHandler) handleError(w http.ResponseWriter, err error) {
+	handleError(a.writer, w, err)
+}
+
+func handleError(writer handlers.ResponseWriter, w http.ResponseWriter, err error) {
 	hErr, ok := err.(sErrors.ClientError)
 	if !ok {
 		log.Println(err)
-		a.writer.JSON(w, http.StatusInternalServerError, handlers.Hash{
+		writer.JSON(w, http.StatusInternalServerError, handlers.Hash{
 			"message": sErrors.SERVER_ERROR_MESSAGE,
 		})
 		return
 	}
-	a.writer.JSON(w, hErr.HTTPCode(), handlers.Hash{
+	writer.JSON(w, hErr.HTTPCode(), handlers.Hash{
 		"message": hErr.Error(),
 	})
 }
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"errors"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -52,13 +54,15 @@ func (a *authRepositoryImpl) SignUp(user users.User, session auth.Session) (id i
 
 func (a *authRepositoryImpl) GetPasswordHash(user users.User) (id int, pass string, err error) {
 	a.m.Lock()
-	if u, ok := a.users[user.Nickname]; ok {
-		id = u.ID
-		pass = u.Password
-	} else {
-		err = errors.New("not found: user don't exists")
+	defer a.m.Unlock()
+	for _, u := range a.users {
+		if (user.Email != "" && strings.EqualFold(u.Email, user.Email)) || (user.Email == "" && u.Nickname == user.Nickname) {
+			id = u.ID
+			pass = u.Password
+			return
+		}
 	}
-	a.m.Unlock()
+	err = errors.New("not found: user don't exists")
 	return
 }
 
@@ -67,18 +71,35 @@ func (a *authRepositoryImpl) SaveSudo(sudo auth.Sudo) (err error) {
 	return
 }
 
+func (a *authRepositoryImpl) GetSession(id int) (session auth.Session, err error) {
+	a.m.Lock()
+	session, ok := a.session[id]
+	if !ok {
+		err = errors.New("not found: session don't exists")
+	}
+	a.m.Unlock()
+	return
+}
+
 func (a *authRepositoryImpl) UpsertSession(session auth.Session) (id int, err error) {
 	a.m.Lock()
-	if s, ok := a.session[session.ID]; ok {
-		s.LastSeenAt = session.LastSeenAt
-		a.session[session.ID] = s
-	} else {
-		a.sessionSerial++
-		session.ID = a.sessionSerial
-		a.session[session.ID] = session
+	defer a.m.Unlock()
+
+	// As the database, a user has a single active session which isn't a impersonation.
+	for id, s := range a.session {
+		if s.UserID == session.UserID && s.Actived && !s.Impersonated() && !session.Impersonated() {
+			s.LoggedAt = session.LoggedAt
+			s.LastSeenAt = session.LastSeenAt
+			s.LoggedWith = session.LoggedWith
+			a.session[id] = s
+			return id, nil
+		}
 	}
+
+	a.sessionSerial++
+	session.ID = a.sessionSerial
+	a.session[session.ID] = session
 	id = session.ID
-	a.m.Unlock()
 	return
 }
 
@@ -182,6 +203,7 @@ func TestUpsertSession(t *testing.T) {
 
 	t.Run("Given a non-existent session When updateing or inserting a session Then session inserted", func(t *testing.T) {
 		sessionExp := session
+		sessionExp.UserID = 2
 		prevSessionSerial := authRepo.sessionSerial
 
 		id, err := authRepo.UpsertSession(sessionExp)
@@ -195,6 +217,45 @@ func TestUpsertSession(t *testing.T) {
 	})
 }
 
+func TestLoginIdentifier(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+
+	hash, err := auth.HashPassword("1234")
+	assert.NoError(t, err)
+	authRepo.users["alice"] = users.User{ID: 1, Nickname: "alice", Email: "alice@host.com", Password: hash}
+	authRepo.users["bob"] = users.User{ID: 2, Nickname: "bob", Email: "bob@host.com", Password: hash}
+
+	tests := []struct {
+		name       string
+		identifier string
+		wantUserID int
+	}{
+		{
+			name:       "Given a email identifier with other casing When logging in Then session of the email user",
+			identifier: " Alice@HOST.com ",
+			wantUserID: 1,
+		},
+		{
+			name:       "Given a nickname identifier When logging in Then session of the nickname user",
+			identifier: "bob",
+			wantUserID: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ah.login(httptest.NewRequest("POST", "/", nil), users.User{Identifier: tt.identifier, Password: "1234"}, systemHandlerName)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUserID, got.UserID)
+		})
+	}
+
+	t.Run("Given a login without identifier When logging in Then invalid identifier error", func(t *testing.T) {
+		_, err := ah.login(httptest.NewRequest("POST", "/", nil), users.User{Nickname: "bob", Email: "alice@host.com", Password: "1234"}, systemHandlerName)
+		assert.EqualError(t, err, "invalid identifier: empty value")
+	})
+}
+
 func newExpectedUser(t *testing.T, user users.User) (r users.User) {
 	t.Helper()
 
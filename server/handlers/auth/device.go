@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/notify"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+)
+
+const (
+	// deviceCookieName is the cookie which identifies the device of the user.
+	deviceCookieName = "device_id"
+
+	deviceCookieMaxAge = 2 * 365 * 24 * 60 * 60
+)
+
+// checkDevice compares the device of the login with the known devices of the user,
+//  notifying the user when the device is unrecognized.
+//  The errors are only logged, so they don't fail the login.
+//  @param w http.ResponseWriter: response writer of the call.
+//  @param r *http.Request: request instance of the call.
+//  @param session auth.Session: new session of the login.
+func (a AuthHandler) checkDevice(w http.ResponseWriter, r *http.Request, session auth.Session) {
+	err := a.recognizeDevice(w, r, session)
+	if err != nil {
+		log.Printf("failed to check the device of the login of user %d: %s", session.UserID, err)
+	}
+}
+
+func (a AuthHandler) recognizeDevice(w http.ResponseWriter, r *http.Request, session auth.Session) (err error) {
+	var cookieHash string
+	if c, cErr := r.Cookie(deviceCookieName); cErr == nil && c.Value != "" {
+		cookieHash = auth.HashToken(c.Value)
+	} else {
+		var raw string
+		raw, err = auth.RandomToken("")
+		if err != nil {
+			return
+		}
+		cookieHash = auth.HashToken(raw)
+		http.SetCookie(w, &http.Cookie{
+			Name:     deviceCookieName,
+			Value:    raw,
+			Path:     "/",
+			MaxAge:   deviceCookieMaxAge,
+			Secure:   a.config.Session.SecureCookie,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	ip := handlers.ClientIP(r)
+	family := auth.UserAgentFamily(r.UserAgent())
+	network := auth.IPNetwork(ip)
+	now := time.Now()
+
+	devices, err := a.devices.GetUserDevices(session.UserID)
+	if err != nil {
+		return
+	}
+
+	for _, d := range devices {
+		if d.Matches(cookieHash, family, network) {
+			err = a.devices.TouchDevice(d.ID, session.ID, now)
+			return
+		}
+	}
+
+	revokeToken, err := auth.RandomToken("")
+	if err != nil {
+		return
+	}
+
+	_, err = a.devices.SaveDevice(auth.Device{
+		UserID:          session.UserID,
+		CookieHash:      cookieHash,
+		UserAgentFamily: family,
+		IPNetwork:       network,
+		SessionID:       session.ID,
+		RevokeHash:      auth.HashToken(revokeToken),
+		RevokeExpiresAt: now.Add(time.Duration(a.config.Security.NotMeTTLInSecs) * time.Second),
+		FirstSeenAt:     now,
+		LastSeenAt:      now,
+	})
+	if err != nil {
+		return
+	}
+
+	// The first device of a user is the one which has signed up, so there is nothing to notify.
+	if len(devices) == 0 {
+		return
+	}
+
+	user, err := a.users.GetUser(session.UserID)
+	if err != nil {
+		return
+	}
+
+	revokeURL, err := tokenURL(a.config.Security.NotMeURL, revokeToken)
+	if err != nil {
+		return
+	}
+
+	err = a.notifier.NotifyNewDeviceLogin(notify.NewDeviceLogin{
+		User:      user,
+		Method:    session.LoggedWith,
+		UserAgent: family,
+		IP:        ip,
+		At:        now,
+		RevokeURL: revokeURL,
+	})
+	return
+}
+
+// NotMe handles the "this wasn't me" link of a new device notification.
+//  It revokes all the sessions and persistent logins of the user, as the one of the device login could have
+//  started others, and redirects to the password reset page.
+func (a AuthHandler) NotMe(w http.ResponseWriter, r *http.Request) {
+	device, err := a.devices.GetDeviceByRevokeHash(auth.HashToken(r.URL.Query().Get("token")))
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = a.sessions.RevokeUserSessions(device.UserID)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	a.recordSecurityEvent(r, device.UserID, auth.SecurityEventSessionRevoked, "not-me", device.SessionID)
+
+	err = a.devices.DeleteDevice(device.ID)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	reset, raw, err := auth.NewPasswordReset(device.UserID, time.Duration(a.config.Security.PasswordResetTTLInSecs)*time.Second)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = a.resets.SavePasswordReset(reset)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	resetURL, err := tokenURL(a.config.Security.PasswordResetURL, raw)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	log.Printf("Revoked sessions of user %d, session %d reported as not recognized", device.UserID, device.SessionID)
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, resetURL, http.StatusSeeOther)
+}
+
+// ResetPassword sets a new password with a password reset token, logging out the user everywhere.
+func (a AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}{}
+	err := a.reader.JSON(r, &req)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err))
+		return
+	}
+
+	err = users.HashPassword(&req.Password)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	userID, err := a.resets.ResetPassword(auth.HashToken(req.Token), req.Password)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	a.recordSecurityEvent(r, userID, auth.SecurityEventPasswordChanged, "password-reset", 0)
+	log.Printf("Reset password of user %d", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tokenURL adds the token provided as "token" query param of the URL.
+func tokenURL(rawURL, token string) (s string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	s = u.String()
+	return
+}
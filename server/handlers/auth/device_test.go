@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/notify"
+	"github.com/coffemanfp/chat/users"
+	"github.com/stretchr/testify/assert"
+)
+
+type deviceRepositoryImpl struct {
+	serial  int
+	devices map[int]auth.Device
+}
+
+// This statement is to check if the deviceRepositoryImpl mock is doing well with the database.DeviceRepository interface.
+var _ database.DeviceRepository = &deviceRepositoryImpl{}
+
+func (d *deviceRepositoryImpl) GetUserDevices(userID int) (devices []auth.Device, err error) {
+	for _, device := range d.devices {
+		if device.UserID == userID {
+			devices = append(devices, device)
+		}
+	}
+	return
+}
+
+func (d *deviceRepositoryImpl) SaveDevice(device auth.Device) (id int, err error) {
+	d.serial++
+	device.ID = d.serial
+	d.devices[device.ID] = device
+	id = device.ID
+	return
+}
+
+func (d *deviceRepositoryImpl) TouchDevice(id, sessionID int, seenAt time.Time) (err error) {
+	device := d.devices[id]
+	device.SessionID = sessionID
+	device.LastSeenAt = seenAt
+	d.devices[id] = device
+	return
+}
+
+func (d *deviceRepositoryImpl) GetDeviceByRevokeHash(hash string) (device auth.Device, err error) {
+	for _, dev := range d.devices {
+		if dev.RevokeHash == hash && time.Now().Before(dev.RevokeExpiresAt) {
+			device = dev
+			return
+		}
+	}
+	err = sErrors.NewClientError(http.StatusNotFound, "not found: device login already revoked, expired or invalid")
+	return
+}
+
+func (d *deviceRepositoryImpl) DeleteDevice(id int) (err error) {
+	delete(d.devices, id)
+	return
+}
+
+type passwordResetRepositoryImpl struct {
+	resets   map[string]auth.PasswordReset
+	sessions *sessionRepositoryImpl
+}
+
+// This statement is to check if the passwordResetRepositoryImpl mock is doing well with the database.PasswordResetRepository interface.
+var _ database.PasswordResetRepository = &passwordResetRepositoryImpl{}
+
+func (p *passwordResetRepositoryImpl) SavePasswordReset(reset auth.PasswordReset) (err error) {
+	p.resets[reset.Hash] = reset
+	return
+}
+
+func (p *passwordResetRepositoryImpl) ResetPassword(hash, password string) (userID int, err error) {
+	reset, ok := p.resets[hash]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid password reset: token expired, used or invalid")
+		return
+	}
+	delete(p.resets, hash)
+	userID = reset.UserID
+	err = p.sessions.RevokeUserSessions(userID)
+	return
+}
+
+type notifierImpl struct {
+	logins []notify.NewDeviceLogin
+}
+
+func (n *notifierImpl) NotifyNewDeviceLogin(login notify.NewDeviceLogin) (err error) {
+	n.logins = append(n.logins, login)
+	return
+}
+
+const (
+	firefoxUA = "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0"
+	chromeUA  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// loginFrom checks the device of a login of the session from the user agent, ip and cookies provided.
+func loginFrom(ah AuthHandler, s auth.Session, ua, ip string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("User-Agent", ua)
+	req.RemoteAddr = ip + ":51000"
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	ah.checkDevice(rec, req, s)
+	return rec
+}
+
+func TestNewDeviceLogin(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	ah.config.Security.NotMeURL = "https://chat.example/api/v1/auth/not-me"
+	ah.config.Security.PasswordResetURL = "https://chat.example/password-reset"
+	ah.config.Security.PasswordResetTTLInSecs = 3600
+	ah.config.Security.NotMeTTLInSecs = 3600
+	ah.users = usersRepositoryImpl{users: map[string]users.User{user.Email: {ID: 1, Email: user.Email}}}
+	notifier := ah.notifier.(*notifierImpl)
+
+	s := session
+	s.UserID = 1
+	s.ID, _ = authRepo.UpsertSession(s)
+
+	rec := loginFrom(ah, s, firefoxUA, "203.0.113.10")
+	deviceCookies := rec.Result().Cookies()
+
+	t.Run("Given the first device When logging in Then no notification", func(t *testing.T) {
+		assert.Len(t, deviceCookies, 1)
+		assert.Empty(t, notifier.logins)
+	})
+
+	t.Run("Given a known device cookie When logging in from another network Then no notification", func(t *testing.T) {
+		loginFrom(ah, s, firefoxUA, "198.51.100.7", deviceCookies...)
+		assert.Empty(t, notifier.logins)
+	})
+
+	t.Run("Given the same browser and network without cookie When logging in Then no notification", func(t *testing.T) {
+		loginFrom(ah, s, firefoxUA, "203.0.113.99")
+		assert.Empty(t, notifier.logins)
+	})
+
+	t.Run("Given a unrecognized device When logging in Then notified", func(t *testing.T) {
+		loginFrom(ah, s, chromeUA, "192.0.2.1")
+		assert.Len(t, notifier.logins, 1)
+		assert.Equal(t, user.Email, notifier.logins[0].User.Email)
+		assert.Equal(t, "Chrome on Windows", notifier.logins[0].UserAgent)
+		assert.True(t, strings.HasPrefix(notifier.logins[0].RevokeURL, ah.config.Security.NotMeURL+"?token="))
+	})
+
+	var resetToken string
+	t.Run("Given a not me link When following it Then sessions revoked and password reset started", func(t *testing.T) {
+		assert.NoError(t, ah.remember(httptest.NewRecorder(), s.UserID))
+		saveTestAccessToken(t, ah, s.UserID)
+		u, err := url.Parse(notifier.logins[0].RevokeURL)
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ah.NotMe(rec, httptest.NewRequest("GET", u.RequestURI(), nil))
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Location"), ah.config.Security.PasswordResetURL+"?token="))
+		assert.False(t, authRepo.session[s.ID].Actived)
+		assertRemembersRevoked(t, ah, s.UserID)
+		assertAccessTokensRevoked(t, ah, s.UserID)
+
+		location, err := url.Parse(rec.Header().Get("Location"))
+		assert.NoError(t, err)
+		resetToken = location.Query().Get("token")
+
+		rec = httptest.NewRecorder()
+		ah.NotMe(rec, httptest.NewRequest("GET", u.RequestURI(), nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Given a password reset token When resetting the password Then sessions revoked", func(t *testing.T) {
+		relogged := session
+		relogged.UserID = s.UserID
+		relogged.ID, _ = authRepo.UpsertSession(relogged)
+		assert.NoError(t, ah.remember(httptest.NewRecorder(), s.UserID))
+		saveTestAccessToken(t, ah, s.UserID)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"token":"`+resetToken+`","password":"n3w-p4ssw0rd"}`))
+		req.Header.Set("Content-Type", "application/json")
+		ah.ResetPassword(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.False(t, authRepo.session[relogged.ID].Actived)
+		assertRemembersRevoked(t, ah, s.UserID)
+		assertAccessTokensRevoked(t, ah, s.UserID)
+	})
+
+	t.Run("Given a expired not me link When following it Then error", func(t *testing.T) {
+		notifier.logins = nil
+		loginFrom(ah, s, chromeUA, "192.0.2.1")
+		assert.Len(t, notifier.logins, 1)
+
+		devices := ah.devices.(*deviceRepositoryImpl).devices
+		for id, d := range devices {
+			d.RevokeExpiresAt = time.Now().Add(-time.Second)
+			devices[id] = d
+		}
+
+		u, err := url.Parse(notifier.logins[0].RevokeURL)
+		assert.NoError(t, err)
+		rec := httptest.NewRecorder()
+		ah.NotMe(rec, httptest.NewRequest("GET", u.RequestURI(), nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// saveTestAccessToken saves a personal access token of the user.
+func saveTestAccessToken(t *testing.T, ah AuthHandler, userID int) {
+	t.Helper()
+
+	token, _, err := auth.NewAccessToken(userID, "cli", nil, nil)
+	assert.NoError(t, err)
+	_, err = ah.tokens.SaveAccessToken(token)
+	assert.NoError(t, err)
+}
+
+// assertAccessTokensRevoked asserts the user has personal access tokens and all of them are revoked.
+func assertAccessTokensRevoked(t *testing.T, ah AuthHandler, userID int) {
+	t.Helper()
+
+	tokens := ah.tokens.(*tokenRepositoryImpl).tokens
+	assert.NotEmpty(t, tokens)
+	for _, token := range tokens {
+		if token.UserID == userID {
+			assert.NotNil(t, token.RevokedAt)
+		}
+	}
+}
+
+// assertRemembersRevoked asserts the user has persistent login series and all of them are revoked.
+func assertRemembersRevoked(t *testing.T, ah AuthHandler, userID int) {
+	t.Helper()
+
+	tokens := ah.remembers.(*rememberTokenRepositoryImpl).tokens
+	assert.NotEmpty(t, tokens)
+	for _, token := range tokens {
+		if token.UserID == userID {
+			assert.NotNil(t, token.RevokedAt)
+		}
+	}
+}
+
+func TestDeviceIdentification(t *testing.T) {
+	t.Run("Given user agents When getting the family Then browser and os families", func(t *testing.T) {
+		assert.Equal(t, "Firefox on Linux", auth.UserAgentFamily(firefoxUA))
+		assert.Equal(t, "Chrome on Windows", auth.UserAgentFamily(chromeUA))
+		assert.Equal(t, "Other on Other", auth.UserAgentFamily(""))
+	})
+
+	t.Run("Given ip addresses When getting the network Then masked network", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.0/24", auth.IPNetwork("203.0.113.10"))
+		assert.Equal(t, "2001:db8:1::/48", auth.IPNetwork("2001:db8:1:2::1"))
+	})
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+)
+
+// ChangeEmail changes the email of the authenticated user, it requires the sudo mode.
+//  The new email is validated like the sign up ones, and it must be of a allowed domain
+//  when the registration is restricted by domain.
+func (a AuthHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	principal, _ := PrincipalFromContext(r.Context())
+
+	req := struct {
+		Email string `json:"email"`
+	}{}
+	err := a.reader.JSON(r, &req)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err))
+		return
+	}
+
+	email, err := users.ValidateEmail(req.Email)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	email = users.NormalizeEmail(email)
+
+	if a.config.Registration.Mode == DOMAIN_REGISTRATION && !allowedDomain(email, a.config.Registration.AllowedDomains) {
+		a.handleError(w, sErrors.NewClientError(http.StatusForbidden, "forbidden: the email %s is not of a allowed domain", email))
+		return
+	}
+
+	err = a.users.UpdateEmail(principal.UserID, email)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	a.recordSecurityEvent(r, principal.UserID, auth.SecurityEventEmailChanged, "", principal.SessionID)
+	log.Printf("Changed email of user %d", principal.UserID)
+	a.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"email": email,
+	})
+}
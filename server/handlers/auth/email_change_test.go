@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/users"
+	"github.com/stretchr/testify/assert"
+)
+
+// changeEmail requests the email change of the body for the user provided.
+func changeEmail(ah AuthHandler, userID int, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/api/v1/users/me/email", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, auth.Principal{UserID: userID, SessionID: 1}))
+	ah.ChangeEmail(rec, req)
+	return rec
+}
+
+func TestChangeEmail(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	usersRepo := usersRepositoryImpl{users: map[string]users.User{
+		"alice@host.com": {ID: 1, Email: "alice@host.com"},
+		"bob@host.com":   {ID: 2, Email: "bob@host.com"},
+	}}
+	ah.users = usersRepo
+	securityLog := ah.securityLog.(*securityLogRepositoryImpl)
+
+	t.Run("Given a new email When changing the email Then normalized email updated and recorded", func(t *testing.T) {
+		rec := changeEmail(ah, 1, `{"email":"Alice@New.com"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 1, usersRepo.users["alice@new.com"].ID)
+
+		assert.Len(t, securityLog.events, 1)
+		assert.Equal(t, auth.SecurityEventEmailChanged, securityLog.events[0].Type)
+	})
+
+	t.Run("Given the email of other user When changing the email Then conflict error", func(t *testing.T) {
+		rec := changeEmail(ah, 1, `{"email":"BOB@host.com"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Given a invalid email When changing the email Then invalid email error", func(t *testing.T) {
+		rec := changeEmail(ah, 1, `{"email":"invalid.email.format"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Given a domain restricted registration When changing the email to other domain Then forbidden error", func(t *testing.T) {
+		ah.config.Registration.Mode = DOMAIN_REGISTRATION
+		ah.config.Registration.AllowedDomains = []string{"host.com"}
+		defer func() { ah.config.Registration.Mode = OPEN_REGISTRATION }()
+
+		rec := changeEmail(ah, 2, `{"email":"bob@other.com"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, 2, usersRepo.users["bob@host.com"].ID)
+	})
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	sMail "github.com/coffemanfp/chat/mail"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+)
+
+const emailCodeHandlerName handlerName = "email-code"
+
+// emailCodeHandler implements the passwordless login and sign up through numeric one-time codes sent by email.
+//  The code is requested as a external sign and verified as a login user reader.
+//  The user returned by read has no id when the email doesn't belong to any account, so it must be registered.
+type emailCodeHandler struct {
+	conf       config.Config
+	repository database.EmailCodeRepository
+	users      database.UsersRepository
+	mailer     sMail.Mailer
+	reader     handlers.RequestReader
+	writer     handlers.ResponseWriter
+}
+
+func newEmailCodeHandler(conf config.Config, repo database.EmailCodeRepository, usersRepo database.UsersRepository, mailer sMail.Mailer, r handlers.RequestReader, w handlers.ResponseWriter) emailCodeHandler {
+	return emailCodeHandler{
+		conf:       conf,
+		repository: repo,
+		users:      usersRepo,
+		mailer:     mailer,
+		reader:     r,
+		writer:     w,
+	}
+}
+
+func (e emailCodeHandler) passwordless() {}
+
+// requestSignUp sends a new one-time code to the requested email.
+func (e emailCodeHandler) requestSignUp(w http.ResponseWriter, r *http.Request) (err error) {
+	req := struct {
+		Email string `json:"email"`
+	}{}
+	err = e.reader.JSON(r, &req)
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err)
+		return
+	}
+
+	email, err := parseEmail(req.Email)
+	if err != nil {
+		return
+	}
+
+	conf := e.conf.Get()
+	window := time.Duration(conf.EmailCode.WindowInSecs) * time.Second
+	n, err := e.repository.CountEmailCodes(email, time.Now().Add(-window))
+	if err != nil {
+		return
+	}
+	if n >= conf.EmailCode.MaxPerWindow {
+		err = sErrors.NewClientError(http.StatusTooManyRequests, "too many requests: wait before requesting a new code for %s", email)
+		return
+	}
+
+	ttl := time.Duration(conf.EmailCode.TTLInSecs) * time.Second
+	code, raw, err := auth.NewEmailCode(email, conf.EmailCode.Digits, ttl)
+	if err != nil {
+		return
+	}
+
+	err = e.repository.SaveEmailCode(code)
+	if err != nil {
+		return
+	}
+
+	err = e.mailer.Send(sMail.Message{
+		To:      email,
+		Subject: "Your login code",
+		Body: fmt.Sprintf(
+			"Your login code is %s\n\nThe code expires in %d minutes. If you didn't request it, you can ignore this email.\n",
+			raw, int(ttl.Minutes()),
+		),
+	})
+	if err != nil {
+		return
+	}
+
+	e.writer.JSON(w, http.StatusAccepted, handlers.Hash{
+		"message": "a login code has been sent to the email",
+	})
+	return
+}
+
+// read verifies and consumes the code of the request, returning the user of the email.
+//  The CAPTCHA token of the request is kept in the new user of a unknown email, for the sign up guards.
+func (e emailCodeHandler) read(w http.ResponseWriter, r *http.Request) (user users.User, err error) {
+	req := struct {
+		Email        string `json:"email"`
+		Code         string `json:"code"`
+		CaptchaToken string `json:"captcha_token"`
+	}{}
+	err = e.reader.JSON(r, &req)
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err)
+		return
+	}
+
+	email, err := parseEmail(req.Email)
+	if err != nil {
+		return
+	}
+
+	code, err := e.repository.GetEmailCode(email)
+	if err != nil {
+		return
+	}
+
+	conf := e.conf.Get()
+
+	// The attempt is registered before checking the code, so concurrent attempts can't exceed the limit.
+	attempts, err := e.repository.AddEmailCodeAttempt(code.ID)
+	if err != nil {
+		return
+	}
+	if attempts > conf.EmailCode.MaxAttempts {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid code: too many attempts, request a new code")
+		return
+	}
+
+	if !code.Check(strings.TrimSpace(req.Code)) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid code: %d attempts left", conf.EmailCode.MaxAttempts-attempts)
+		return
+	}
+
+	err = e.repository.ConsumeEmailCode(code.ID)
+	if err != nil {
+		return
+	}
+
+	user, err = e.users.GetUserByEmail(email)
+	if err != nil {
+		if hErr, ok := err.(sErrors.ClientError); ok && hErr.HTTPCode() == http.StatusNotFound {
+			log.Printf("Email code verified for unknown email %s, it will be registered", email)
+			user = users.User{Email: email, CaptchaToken: req.CaptchaToken}
+			err = nil
+		}
+	}
+	return
+}
+
+// parseEmail validates the email provided with users.ValidateEmail and returns its normalized address.
+func parseEmail(email string) (addr string, err error) {
+	addr, err = users.ValidateEmail(email)
+	if err != nil {
+		return
+	}
+	addr = users.NormalizeEmail(addr)
+	return
+}
@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/captcha"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	"github.com/coffemanfp/chat/emailcheck"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type emailCodeRepositoryImpl struct {
+	serial int
+	codes  map[int]auth.EmailCode
+	used   map[int]bool
+}
+
+// This statement is to check if the emailCodeRepositoryImpl mock is doing well with the database.EmailCodeRepository interface.
+var _ database.EmailCodeRepository = &emailCodeRepositoryImpl{}
+
+func (e *emailCodeRepositoryImpl) SaveEmailCode(code auth.EmailCode) (err error) {
+	for id, c := range e.codes {
+		if c.Email == code.Email {
+			e.used[id] = true
+		}
+	}
+	e.serial++
+	code.ID = e.serial
+	e.codes[code.ID] = code
+	return
+}
+
+func (e *emailCodeRepositoryImpl) GetEmailCode(email string) (code auth.EmailCode, err error) {
+	for id, c := range e.codes {
+		if c.Email == email && !e.used[id] && time.Now().Before(c.ExpiresAt) {
+			code = c
+			return
+		}
+	}
+	err = sErrors.NewClientError(http.StatusUnauthorized, "invalid code: code expired, used or invalid")
+	return
+}
+
+func (e *emailCodeRepositoryImpl) AddEmailCodeAttempt(id int) (attempts int, err error) {
+	c := e.codes[id]
+	c.Attempts++
+	e.codes[id] = c
+	attempts = c.Attempts
+	return
+}
+
+func (e *emailCodeRepositoryImpl) ConsumeEmailCode(id int) (err error) {
+	if e.used[id] {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid code: code expired, used or invalid")
+		return
+	}
+	e.used[id] = true
+	return
+}
+
+func (e *emailCodeRepositoryImpl) CountEmailCodes(email string, since time.Time) (n int, err error) {
+	for _, c := range e.codes {
+		if c.Email == email && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return
+}
+
+// newTestSignAuthHandler gets a AuthHandler with the config provided whose only sign handler is the one created by newReader.
+//  The handler is a external sign up handler too if it implements it, and the known user is registered.
+//  @param newReader func(ah AuthHandler) userReader: creates the sign handler with the dependencies of ah.
+func newTestSignAuthHandler(t *testing.T, conf config.ConfigInfo, hName handlerName, newReader func(ah AuthHandler) userReader) (ah AuthHandler, authRepo *authRepositoryImpl) {
+	t.Helper()
+
+	repo := newAuthRepositoryImpl()
+	authRepo = &repo
+	ah = newTestAuthHandler(t, authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	ah.config = conf
+	ah.users = usersRepositoryImpl{users: map[string]users.User{
+		user.Email: {ID: 1, Nickname: user.Nickname, Email: user.Email},
+	}}
+
+	reader := newReader(ah)
+	ah.userReaders = map[handlerName]userReader{hName: reader}
+	if h, ok := reader.(externalSignUpHandler); ok {
+		ah.externalSignHandlers = map[handlerName]externalSignUpHandler{hName: h}
+	}
+	return
+}
+
+func newTestEmailCodeAuthHandler(t *testing.T) (ah AuthHandler, authRepo *authRepositoryImpl, mailer *mailerImpl) {
+	t.Helper()
+
+	conf := config.ConfigInfo{}
+	conf.EmailCode.Digits = 6
+	conf.EmailCode.TTLInSecs = 300
+	conf.EmailCode.MaxAttempts = 3
+	conf.EmailCode.MaxPerWindow = 3
+	conf.EmailCode.WindowInSecs = 900
+
+	mailer = &mailerImpl{}
+	codeRepo := &emailCodeRepositoryImpl{codes: map[int]auth.EmailCode{}, used: map[int]bool{}}
+	ah, authRepo = newTestSignAuthHandler(t, conf, emailCodeHandlerName, func(ah AuthHandler) userReader {
+		return newEmailCodeHandler(config.Static(conf), codeRepo, ah.users, mailer, ah.reader, ah.writer)
+	})
+	return
+}
+
+// doEmailCodeRequest performs a email code request of the action provided with the JSON body.
+func doEmailCodeRequest(t *testing.T, ah AuthHandler, action, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/auth/"+action+"/email-code", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"action": action, "handler": emailCodeHandlerName.string()})
+	ah.HandleAuth(rec, req)
+	return rec
+}
+
+// sentCode gets the code of the last sent email.
+func sentCode(t *testing.T, mailer *mailerImpl) string {
+	t.Helper()
+
+	assert.NotEmpty(t, mailer.sent)
+	body := mailer.sent[len(mailer.sent)-1].Body
+	return strings.Fields(strings.TrimPrefix(body, "Your login code is "))[0]
+}
+
+func TestEmailCodeLogin(t *testing.T) {
+	t.Run("Given a known email When verifying the sent code Then session created", func(t *testing.T) {
+		ah, authRepo, mailer := newTestEmailCodeAuthHandler(t)
+
+		rec := doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+user.Email+`"}`)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+
+		code := sentCode(t, mailer)
+		assert.Len(t, code, 6)
+
+		rec = doEmailCodeRequest(t, ah, "login", `{"email":"`+user.Email+`","code":"`+code+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, authRepo.users)
+
+		s, err := authRepo.GetSession(authRepo.sessionSerial)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, s.UserID)
+		assert.Equal(t, emailCodeHandlerName.string(), s.LoggedWith)
+
+		rec = doEmailCodeRequest(t, ah, "login", `{"email":"`+user.Email+`","code":"`+code+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Given a unknown email When verifying the sent code Then user registered", func(t *testing.T) {
+		ah, authRepo, mailer := newTestEmailCodeAuthHandler(t)
+		email := "new@host.com"
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+email+`"}`)
+		rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+email+`","code":"`+sentCode(t, mailer)+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Len(t, authRepo.users, 1)
+		for _, u := range authRepo.users {
+			assert.Equal(t, email, u.Email)
+			assert.Empty(t, u.Password)
+		}
+	})
+
+	t.Run("Given too many wrong codes When verifying the right code Then error", func(t *testing.T) {
+		ah, _, mailer := newTestEmailCodeAuthHandler(t)
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+user.Email+`"}`)
+		code := sentCode(t, mailer)
+		wrong := "0000000"
+		for i := 0; i < 3; i++ {
+			rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+user.Email+`","code":"`+wrong+`"}`)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+
+		rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+user.Email+`","code":"`+code+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Given too many requested codes When requesting a new code Then error", func(t *testing.T) {
+		ah, _, mailer := newTestEmailCodeAuthHandler(t)
+
+		for i := 0; i < 3; i++ {
+			rec := doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+user.Email+`"}`)
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}
+
+		rec := doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+user.Email+`"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Len(t, mailer.sent, 3)
+	})
+
+	t.Run("Given a unknown disposable email When verifying the sent code Then error", func(t *testing.T) {
+		ah, authRepo, mailer := newTestEmailCodeAuthHandler(t)
+		path := filepath.Join(t.TempDir(), "disposable.txt")
+		assert.NoError(t, os.WriteFile(path, []byte("mailinator.com\n"), 0600))
+		conf := config.ConfigInfo{}
+		conf.SignUp.DisposableDomainsFile = path
+		setTestSignUpGuards(t, &ah, conf, nil)
+		email := "bot@mailinator.com"
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+email+`"}`)
+		rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+email+`","code":"`+sentCode(t, mailer)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, authRepo.users)
+	})
+
+	t.Run("Given a captcha verifier When verifying the code of a unknown email Then only solved captchas registered", func(t *testing.T) {
+		ah, authRepo, mailer := newTestEmailCodeAuthHandler(t)
+		setTestSignUpGuards(t, &ah, config.ConfigInfo{}, captcha.FakeVerifier{Token: "solved"})
+		email := "new@host.com"
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+email+`"}`)
+		rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+email+`","code":"`+sentCode(t, mailer)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, authRepo.users)
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+email+`"}`)
+		rec = doEmailCodeRequest(t, ah, "login", `{"email":"`+email+`","code":"`+sentCode(t, mailer)+`","captcha_token":"solved"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, authRepo.users, 1)
+	})
+
+	t.Run("Given a captcha verifier When verifying the code of a known email Then no captcha required", func(t *testing.T) {
+		ah, _, mailer := newTestEmailCodeAuthHandler(t)
+		setTestSignUpGuards(t, &ah, config.ConfigInfo{}, captcha.FakeVerifier{Token: "solved"})
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+user.Email+`"}`)
+		rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+user.Email+`","code":"`+sentCode(t, mailer)+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Given a invalid email When requesting a code Then error", func(t *testing.T) {
+		ah, _, mailer := newTestEmailCodeAuthHandler(t)
+
+		rec := doEmailCodeRequest(t, ah, "external-sign", `{"email":"invalid"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, mailer.sent)
+	})
+
+	t.Run("Given a email of a host without mail server When requesting a code Then error", func(t *testing.T) {
+		users.SetEmailValidator(hostValidatorImpl{host: "host.com"})
+		defer users.SetEmailValidator(emailcheck.SyntaxValidator{})
+		ah, _, mailer := newTestEmailCodeAuthHandler(t)
+
+		rec := doEmailCodeRequest(t, ah, "external-sign", `{"email":"bot@nomail.example"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, mailer.sent)
+
+		rec = doEmailCodeRequest(t, ah, "external-sign", `{"email":"human@host.com"}`)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Len(t, mailer.sent, 1)
+	})
+}
+
+// hostValidatorImpl is a email validator which only accepts the emails of its host, as if the others had no mail server.
+type hostValidatorImpl struct {
+	host string
+}
+
+func (v hostValidatorImpl) Validate(email string) (addr string, err error) {
+	addr, err = emailcheck.SyntaxValidator{}.Validate(email)
+	if err == nil && !strings.HasSuffix(addr, "@"+v.host) {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email host: %s not exists", addr[strings.LastIndex(addr, "@")+1:])
+	}
+	return
+}
@@ -59,15 +59,18 @@ func (f facebookHandler) parseUserInfo(b []byte) (user users.User, err error) {
 	return
 }
 
-func newFacebookHandler(conf config.ConfigInfo) facebookHandler {
+func newFacebookHandler(conf config.Config) facebookHandler {
 	return facebookHandler{
 		oauth: oauth{
-			conf: &oauth2.Config{
-				ClientID:     conf.OAuth.Facebook.ClientID,
-				ClientSecret: conf.OAuth.Facebook.ClientSecret,
-				Endpoint:     conf.OAuth.Facebook.Endpoint,
-				RedirectURL:  conf.OAuth.Facebook.RedirectURIS[0],
-				Scopes:       conf.OAuth.Facebook.Scopes,
+			conf: func() *oauth2.Config {
+				facebook := conf.Get().OAuth.Facebook
+				return &oauth2.Config{
+					ClientID:     facebook.ClientID,
+					ClientSecret: facebook.ClientSecret,
+					Endpoint:     facebook.Endpoint,
+					RedirectURL:  facebook.RedirectURL(),
+					Scopes:       facebook.Scopes,
+				}
 			},
 			handler:     facebookHandlerName,
 			validStates: make(map[string]bool),
@@ -59,15 +59,18 @@ func (g googleHandler) parseUserInfo(b []byte) (user users.User, err error) {
 	return
 }
 
-func newGoogleHandler(conf config.ConfigInfo) googleHandler {
+func newGoogleHandler(conf config.Config) googleHandler {
 	return googleHandler{
 		oauth: oauth{
-			conf: &oauth2.Config{
-				ClientID:     conf.OAuth.Google.ClientID,
-				ClientSecret: conf.OAuth.Google.ClientSecret,
-				Endpoint:     conf.OAuth.Google.Endpoint,
-				RedirectURL:  conf.OAuth.Google.RedirectURIS[0],
-				Scopes:       conf.OAuth.Google.Scopes,
+			conf: func() *oauth2.Config {
+				google := conf.Get().OAuth.Google
+				return &oauth2.Config{
+					ClientID:     google.ClientID,
+					ClientSecret: google.ClientSecret,
+					Endpoint:     google.Endpoint,
+					RedirectURL:  google.RedirectURL(),
+					Scopes:       google.Scopes,
+				}
 			},
 			handler:     googleHandlerName,
 			validStates: make(map[string]bool),
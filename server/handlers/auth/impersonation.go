@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/gorilla/mux"
+)
+
+// impersonatorSessionKey is the session cookie value which keeps the own session of the admin during a impersonation.
+const impersonatorSessionKey = "impersonator_session_id"
+
+// RequireSudo is a middleware which only allows the sessions with a active sudo mode.
+//  Access tokens and impersonation sessions can't use the sudo mode.
+func (a AuthHandler) RequireSudo(next http.Handler) http.Handler {
+	return a.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFromContext(r.Context())
+		if principal.TokenID != 0 || principal.Impersonated() {
+			a.handleError(w, sErrors.NewClientError(http.StatusForbidden, "forbidden: sudo mode requires a user session"))
+			return
+		}
+
+		sudo, err := a.sessions.GetLastSudo(principal.SessionID)
+		if err != nil {
+			if hErr, ok := err.(sErrors.ClientError); ok && hErr.HTTPCode() == http.StatusNotFound {
+				err = sErrors.NewClientError(http.StatusForbidden, "forbidden: sudo mode required")
+			}
+			a.handleError(w, err)
+			return
+		}
+		if !sudo.Active(time.Now()) {
+			a.handleError(w, sErrors.NewClientError(http.StatusForbidden, "forbidden: sudo mode required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// Impersonate starts a impersonation of the user of the path by the authenticated admin.
+//  The session cookie is switched to a new impersonation session of the user, keeping the own session
+//  of the admin to be restored when the impersonation is stopped.
+func (a AuthHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	principal, _ := PrincipalFromContext(r.Context())
+
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid user id: %s is not a valid id", vars["id"]))
+		return
+	}
+	if userID == principal.UserID {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid user id: can't impersonate yourself"))
+		return
+	}
+
+	req := struct {
+		Reason string `json:"reason"`
+	}{}
+	err = a.reader.JSON(r, &req)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err))
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid reason: empty value"))
+		return
+	}
+
+	_, err = a.users.GetUser(userID)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	session, err := auth.NewImpersonationSession(userID, principal.UserID)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	session.ID, err = a.sessions.CreateImpersonationSession(session)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	// The impersonation is refused if it can't be audited.
+	err = a.audit.SaveAuditEntry(auth.NewAuditEntry(principal.UserID, auth.AuditActionImpersonationStarted, userID, session.ID, req.Reason, handlers.ClientIP(r)))
+	if err != nil {
+		if eErr := a.sessions.ExpireSession(session.ID); eErr != nil {
+			log.Printf("failed to expire unaudited impersonation session %d: %s", session.ID, eErr)
+		}
+		a.handleError(w, err)
+		return
+	}
+	a.recordSecurityEvent(r, userID, auth.SecurityEventImpersonated, auth.IMPERSONATION_PLATFORM, session.ID)
+
+	sess, err := a.store.Get(r, authCookieName)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	err = renewSessionToken(a.store, sess)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	sess.Values["session_id"] = session.ID
+	sess.Values[impersonatorSessionKey] = principal.SessionID
+	err = sess.Save(r, w)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	log.Printf("User %d started a impersonation of user %d", principal.UserID, userID)
+	a.writer.JSON(w, http.StatusCreated, handlers.Hash{
+		"session":    session,
+		"expires_at": session.LoggedAt.Add(impersonationLifetime(a.config).Absolute),
+	})
+}
+
+// StopImpersonation finishes the impersonation of the session cookie and restores the own session of the admin.
+func (a AuthHandler) StopImpersonation(w http.ResponseWriter, r *http.Request) {
+	sess, err := a.store.Get(r, authCookieName)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	sessionID, _ := sess.Values["session_id"].(int)
+	impersonatorSessionID, ok := sess.Values[impersonatorSessionKey].(int)
+	if !ok {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid session: the session is not a impersonation"))
+		return
+	}
+
+	// The session is read without checking its lifetime, so a expired impersonation can be stopped too.
+	session, err := a.repository.GetSession(sessionID)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	if session.Actived {
+		err = a.sessions.ExpireSession(session.ID)
+		if err != nil {
+			a.handleError(w, err)
+			return
+		}
+	}
+
+	err = a.audit.SaveAuditEntry(auth.NewAuditEntry(session.ImpersonatorID, auth.AuditActionImpersonationStopped, session.UserID, session.ID, "", handlers.ClientIP(r)))
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = renewSessionToken(a.store, sess)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	sess.Values["session_id"] = impersonatorSessionID
+	delete(sess.Values, impersonatorSessionKey)
+	err = sess.Save(r, w)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	log.Printf("User %d stopped the impersonation of user %d", session.ImpersonatorID, session.UserID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// impersonationLifetime gets the fixed lifetime of the impersonation sessions.
+func impersonationLifetime(conf config.ConfigInfo) auth.Lifetime {
+	return auth.Lifetime{
+		Absolute: time.Duration(conf.Security.ImpersonationTTLInSecs) * time.Second,
+	}
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditLogRepositoryImpl struct {
+	entries []auth.AuditEntry
+}
+
+// This statement is to check if the auditLogRepositoryImpl mock is doing well with the database.AuditLogRepository interface.
+var _ database.AuditLogRepository = &auditLogRepositoryImpl{}
+
+func (a *auditLogRepositoryImpl) SaveAuditEntry(entry auth.AuditEntry) (err error) {
+	entry.ID = len(a.entries) + 1
+	a.entries = append(a.entries, entry)
+	return
+}
+
+// impersonate requests the impersonation of the user provided with the session cookie of the admin session.
+func impersonate(h http.Handler, adminSessionReq *http.Request, userID, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/users/"+userID+"/impersonate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for _, c := range adminSessionReq.Cookies() {
+		req.AddCookie(c)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": userID})
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImpersonation(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	roleRepo := &roleRepositoryImpl{roles: map[int][]auth.Role{}}
+	ah := newTestAuthHandler(t, &authRepo, roleRepo)
+	ah.config.Sudo.DurationInSecs = 300
+	ah.config.Security.ImpersonationTTLInSecs = 900
+	ah.users = usersRepositoryImpl{users: map[string]users.User{user.Email: {ID: 2, Email: user.Email}}}
+	sessionRepo := ah.sessions.(*sessionRepositoryImpl)
+	sessionRepo.sudos = map[int]auth.Sudo{}
+	auditRepo := ah.audit.(*auditLogRepositoryImpl)
+	securityLog := ah.securityLog.(*securityLogRepositoryImpl)
+
+	admin := session
+	admin.UserID = 1
+	admin.ID, _ = authRepo.UpsertSession(admin)
+	roleRepo.roles[admin.UserID] = []auth.Role{{Name: "admin", Permissions: []auth.Permission{auth.PermissionUsersAdmin, auth.PermissionUsersRead}}}
+	roleRepo.roles[2] = []auth.Role{{Name: auth.DEFAULT_ROLE, Permissions: []auth.Permission{auth.PermissionUsersRead, auth.PermissionChatWrite}}}
+
+	h := ah.RequirePermission(auth.PermissionUsersAdmin)(ah.RequireSudo(http.HandlerFunc(ah.Impersonate)))
+	adminReq := newSessionRequest(t, ah, admin.ID)
+
+	t.Run("Given a admin without sudo When impersonating Then forbidden error", func(t *testing.T) {
+		rec := impersonate(h, adminReq, "2", `{"reason":"support ticket 42"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, auditRepo.entries)
+	})
+
+	sessionRepo.sudos[1] = auth.NewSudo(admin.ID, ah.config.Sudo.DurationInSecs)
+
+	t.Run("Given a admin in sudo mode without reason When impersonating Then error", func(t *testing.T) {
+		rec := impersonate(h, adminReq, "2", `{}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	var impersonationCookies []*http.Cookie
+	t.Run("Given a admin in sudo mode When impersonating Then impersonation session created and audited", func(t *testing.T) {
+		rec := impersonate(h, adminReq, "2", `{"reason":"support ticket 42"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		impersonationCookies = rec.Result().Cookies()
+
+		assert.Len(t, auditRepo.entries, 1)
+		entry := auditRepo.entries[0]
+		assert.Equal(t, auth.AuditActionImpersonationStarted, entry.Action)
+		assert.Equal(t, admin.UserID, entry.ActorID)
+		assert.Equal(t, 2, entry.TargetUserID)
+		assert.Equal(t, "support ticket 42", entry.Reason)
+
+		s := authRepo.session[entry.SessionID]
+		assert.Equal(t, 2, s.UserID)
+		assert.Equal(t, admin.UserID, s.ImpersonatorID)
+		assert.True(t, authRepo.session[admin.ID].Actived)
+
+		assert.Len(t, securityLog.events, 1)
+		assert.Equal(t, 2, securityLog.events[0].UserID)
+		assert.Equal(t, auth.SecurityEventImpersonated, securityLog.events[0].Type)
+	})
+
+	t.Run("Given a impersonation session When authenticating Then restricted principal", func(t *testing.T) {
+		var got auth.Principal
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		for _, c := range impersonationCookies {
+			req.AddCookie(c)
+		}
+		ah.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = PrincipalFromContext(r.Context())
+		})).ServeHTTP(rec, req)
+
+		assert.Equal(t, 2, got.UserID)
+		assert.Equal(t, admin.UserID, got.ImpersonatorID)
+		assert.True(t, got.HasPermission(auth.PermissionUsersRead))
+		assert.False(t, got.HasPermission(auth.PermissionChatWrite))
+	})
+
+	t.Run("Given a expired impersonation session When authenticating Then unauthorized error", func(t *testing.T) {
+		id := auditRepo.entries[0].SessionID
+		s := authRepo.session[id]
+		s.LoggedAt = time.Now().Add(-time.Hour)
+		authRepo.session[id] = s
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		for _, c := range impersonationCookies {
+			req.AddCookie(c)
+		}
+		ah.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Given a impersonation When stopping it Then admin session restored", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("DELETE", "/", nil)
+		for _, c := range impersonationCookies {
+			req.AddCookie(c)
+		}
+		ah.StopImpersonation(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Len(t, auditRepo.entries, 2)
+		assert.Equal(t, auth.AuditActionImpersonationStopped, auditRepo.entries[1].Action)
+
+		var got auth.Principal
+		req = httptest.NewRequest("GET", "/", nil)
+		for _, c := range rec.Result().Cookies() {
+			req.AddCookie(c)
+		}
+		ah.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = PrincipalFromContext(r.Context())
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, admin.UserID, got.UserID)
+		assert.False(t, got.Impersonated())
+	})
+}
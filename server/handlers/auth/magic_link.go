@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/mail"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+)
+
+const (
+	magicLinkHandlerName handlerName = "magic-link"
+
+	// magicLinkBrowserCookieName is the cookie which binds a magic link to the browser which requested it.
+	magicLinkBrowserCookieName = "magic_link_browser"
+)
+
+// passwordlessUserReader represents a userReader which already authenticates the user it reads,
+// so the login doesn't require its password.
+type passwordlessUserReader interface {
+	userReader
+	passwordless()
+}
+
+// magicLinkHandler implements the passwordless login through single-use links sent by email.
+//  The link is requested as a external sign and read as a login user reader.
+type magicLinkHandler struct {
+	conf       config.Config
+	secret     []byte
+	repository database.MagicLinkRepository
+	users      database.UsersRepository
+	mailer     mail.Mailer
+	reader     handlers.RequestReader
+	writer     handlers.ResponseWriter
+}
+
+func newMagicLinkHandler(conf config.Config, repo database.MagicLinkRepository, usersRepo database.UsersRepository, mailer mail.Mailer, r handlers.RequestReader, w handlers.ResponseWriter) magicLinkHandler {
+	secret := []byte(conf.Get().MagicLink.Secret)
+	if len(secret) == 0 {
+		log.Println("No magic link secret provided, a ephemeral one will be used and the sent links will be invalid after restart")
+		s, err := auth.RandomToken("")
+		if err != nil {
+			log.Printf("failed to generate magic link secret: %s", err)
+		}
+		secret = []byte(s)
+	}
+
+	return magicLinkHandler{
+		conf:       conf,
+		secret:     secret,
+		repository: repo,
+		users:      usersRepo,
+		mailer:     mailer,
+		reader:     r,
+		writer:     w,
+	}
+}
+
+func (m magicLinkHandler) passwordless() {}
+
+// requestSignUp sends a magic link to the requested email.
+//  The response is the same for unknown or throttled emails to avoid leaking which accounts exist.
+func (m magicLinkHandler) requestSignUp(w http.ResponseWriter, r *http.Request) (err error) {
+	req := struct {
+		Email string `json:"email"`
+	}{}
+	err = m.reader.JSON(r, &req)
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err)
+		return
+	}
+
+	email := users.NormalizeEmail(req.Email)
+	if email == "" {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid email: email can't be empty")
+		return
+	}
+
+	err = m.send(w, email)
+	if err != nil {
+		return
+	}
+
+	m.writer.JSON(w, http.StatusAccepted, handlers.Hash{
+		"message": "if the email belongs to an account, a login link has been sent to it",
+	})
+	return
+}
+
+// send creates and emails a new magic link if the email belongs to a user and it isn't throttled.
+func (m magicLinkHandler) send(w http.ResponseWriter, email string) (err error) {
+	conf := m.conf.Get()
+	window := time.Duration(conf.MagicLink.WindowInSecs) * time.Second
+	n, err := m.repository.CountMagicLinks(email, time.Now().Add(-window))
+	if err != nil {
+		return
+	}
+	if n >= conf.MagicLink.MaxPerWindow {
+		log.Printf("Magic link throttled for %s: %d links sent in the last %s", email, n, window)
+		return
+	}
+
+	user, err := m.users.GetUserByEmail(email)
+	if err != nil {
+		if hErr, ok := err.(sErrors.ClientError); ok && hErr.HTTPCode() == http.StatusNotFound {
+			log.Printf("Magic link requested for unknown email %s", email)
+			err = nil
+		}
+		return
+	}
+
+	ttl := time.Duration(conf.MagicLink.TTLInSecs) * time.Second
+
+	var browser string
+	if conf.MagicLink.RequireSameBrowser {
+		browser, err = auth.RandomToken("")
+		if err != nil {
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     magicLinkBrowserCookieName,
+			Value:    browser,
+			Path:     "/",
+			MaxAge:   int(ttl.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	link, token, err := auth.NewMagicLink(m.secret, user.ID, email, browser, ttl)
+	if err != nil {
+		return
+	}
+
+	err = m.repository.SaveMagicLink(link)
+	if err != nil {
+		return
+	}
+
+	linkURL, err := url.Parse(conf.MagicLink.LinkURL)
+	if err != nil {
+		err = fmt.Errorf("invalid magic link url: %s", err)
+		return
+	}
+	q := linkURL.Query()
+	q.Set("token", token)
+	linkURL.RawQuery = q.Encode()
+
+	err = m.mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Use the following link to login:\n\n%s\n\nThe link can be used only once and expires in %d minutes. If you didn't request it, you can ignore this email.\n",
+			linkURL, int(ttl.Minutes()),
+		),
+	})
+	return
+}
+
+// read verifies and consumes the magic link of the request, returning the user it belongs to.
+func (m magicLinkHandler) read(w http.ResponseWriter, r *http.Request) (user users.User, err error) {
+	link, err := auth.ParseMagicLink(m.secret, r.URL.Query().Get("token"))
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "%s", err)
+		return
+	}
+
+	if m.conf.Get().MagicLink.RequireSameBrowser || link.BrowserHash != "" {
+		if !m.sameBrowser(r, link) {
+			err = sErrors.NewClientError(http.StatusUnauthorized, "invalid magic link: the link must be opened in the browser which requested it")
+			return
+		}
+	}
+
+	err = m.repository.ConsumeMagicLink(link.Hash)
+	if err != nil {
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   magicLinkBrowserCookieName,
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	user = users.User{
+		ID:    link.UserID,
+		Email: link.Email,
+	}
+	return
+}
+
+func (m magicLinkHandler) sameBrowser(r *http.Request, link auth.MagicLink) bool {
+	c, err := r.Cookie(magicLinkBrowserCookieName)
+	if err != nil || link.BrowserHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth.HashToken(c.Value)), []byte(link.BrowserHash)) == 1
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/mail"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type magicLinkRepositoryImpl struct {
+	links map[string]auth.MagicLink
+	used  map[string]bool
+}
+
+// This statement is to check if the magicLinkRepositoryImpl mock is doing well with the database.MagicLinkRepository interface.
+var _ database.MagicLinkRepository = &magicLinkRepositoryImpl{}
+
+func (m *magicLinkRepositoryImpl) SaveMagicLink(link auth.MagicLink) (err error) {
+	m.links[link.Hash] = link
+	return
+}
+
+func (m *magicLinkRepositoryImpl) ConsumeMagicLink(hash string) (err error) {
+	link, ok := m.links[hash]
+	if !ok || m.used[hash] || time.Now().After(link.ExpiresAt) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid magic link: link expired, used or invalid")
+		return
+	}
+	m.used[hash] = true
+	return
+}
+
+func (m *magicLinkRepositoryImpl) CountMagicLinks(email string, since time.Time) (n int, err error) {
+	for _, l := range m.links {
+		if l.Email == email && !l.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return
+}
+
+type usersRepositoryImpl struct {
+	users map[string]users.User
+}
+
+func (u usersRepositoryImpl) GetUser(id int) (user users.User, err error) {
+	for _, us := range u.users {
+		if us.ID == id {
+			user = us
+			return
+		}
+	}
+	err = sErrors.NewClientError(http.StatusNotFound, "not found: user %d don't exists", id)
+	return
+}
+
+func (u usersRepositoryImpl) GetUserByEmail(email string) (user users.User, err error) {
+	user, ok := u.users[email]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: user %s don't exists", email)
+	}
+	return
+}
+
+func (u usersRepositoryImpl) UpdateNickname(id int, nickname, skeleton string) (err error) {
+	user, err := u.GetUser(id)
+	if err != nil {
+		return
+	}
+	for _, us := range u.users {
+		if us.ID != id && us.NicknameSkeleton == skeleton {
+			err = sErrors.NewClientError(http.StatusConflict, "already exists nickname")
+			return
+		}
+	}
+	user.Nickname = nickname
+	user.NicknameSkeleton = skeleton
+	u.users[user.Email] = user
+	return
+}
+
+func (u usersRepositoryImpl) UpdateEmail(id int, email string) (err error) {
+	user, err := u.GetUser(id)
+	if err != nil {
+		return
+	}
+	if _, ok := u.users[email]; ok {
+		err = sErrors.NewClientError(http.StatusConflict, "already exists email")
+		return
+	}
+	delete(u.users, user.Email)
+	user.Email = email
+	u.users[email] = user
+	return
+}
+
+type mailerImpl struct {
+	sent []mail.Message
+}
+
+func (m *mailerImpl) Send(msg mail.Message) (err error) {
+	m.sent = append(m.sent, msg)
+	return
+}
+
+func newTestMagicLinkAuthHandler(t *testing.T, requireSameBrowser bool) (ah AuthHandler, authRepo *authRepositoryImpl, mailer *mailerImpl) {
+	t.Helper()
+
+	conf := config.ConfigInfo{}
+	conf.MagicLink.LinkURL = "https://chat.example/api/v1/auth/login/magic-link"
+	conf.MagicLink.Secret = "testsecret"
+	conf.MagicLink.TTLInSecs = 900
+	conf.MagicLink.MaxPerWindow = 2
+	conf.MagicLink.WindowInSecs = 900
+	conf.MagicLink.RequireSameBrowser = requireSameBrowser
+
+	mailer = &mailerImpl{}
+	linkRepo := &magicLinkRepositoryImpl{links: map[string]auth.MagicLink{}, used: map[string]bool{}}
+	ah, authRepo = newTestSignAuthHandler(t, conf, magicLinkHandlerName, func(ah AuthHandler) userReader {
+		return newMagicLinkHandler(config.Static(conf), linkRepo, ah.users, mailer, ah.reader, ah.writer)
+	})
+	return
+}
+
+// requestMagicLink requests a magic link for the email provided and returns the response.
+func requestMagicLink(t *testing.T, ah AuthHandler, email string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/auth/external-sign/magic-link", strings.NewReader(`{"email":"`+email+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"action": "external-sign", "handler": magicLinkHandlerName.string()})
+	ah.HandleAuth(rec, req)
+	return rec
+}
+
+// followMagicLink performs the login request of the link sent in the message provided.
+func followMagicLink(t *testing.T, ah AuthHandler, msg mail.Message, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var link string
+	for _, l := range strings.Fields(msg.Body) {
+		if strings.HasPrefix(l, "https://") {
+			link = l
+		}
+	}
+	u, err := url.Parse(link)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", u.RequestURI(), nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	req = mux.SetURLVars(req, map[string]string{"action": "login", "handler": magicLinkHandlerName.string()})
+	ah.HandleAuth(rec, req)
+	return rec
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	t.Run("Given a requested magic link When following it Then session created", func(t *testing.T) {
+		ah, authRepo, mailer := newTestMagicLinkAuthHandler(t, false)
+
+		rec := requestMagicLink(t, ah, user.Email)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Len(t, mailer.sent, 1)
+		assert.Equal(t, user.Email, mailer.sent[0].To)
+
+		rec = followMagicLink(t, ah, mailer.sent[0], nil)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+
+		var sessCookie bool
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == authCookieName {
+				sessCookie = true
+			}
+		}
+		assert.True(t, sessCookie)
+
+		s, err := authRepo.GetSession(authRepo.sessionSerial)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, s.UserID)
+		assert.Equal(t, magicLinkHandlerName.string(), s.LoggedWith)
+	})
+
+	t.Run("Given a used magic link When following it again Then error", func(t *testing.T) {
+		ah, _, mailer := newTestMagicLinkAuthHandler(t, false)
+
+		requestMagicLink(t, ah, user.Email)
+		rec := followMagicLink(t, ah, mailer.sent[0], nil)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+
+		rec = followMagicLink(t, ah, mailer.sent[0], nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Given a unknown email When requesting a magic link Then accepted without email", func(t *testing.T) {
+		ah, _, mailer := newTestMagicLinkAuthHandler(t, false)
+
+		rec := requestMagicLink(t, ah, "unknown@host.com")
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Empty(t, mailer.sent)
+	})
+
+	t.Run("Given too many requests When requesting a magic link Then throttled", func(t *testing.T) {
+		ah, _, mailer := newTestMagicLinkAuthHandler(t, false)
+
+		for i := 0; i < 3; i++ {
+			rec := requestMagicLink(t, ah, user.Email)
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}
+		assert.Len(t, mailer.sent, 2)
+	})
+
+	t.Run("Given same browser required When following the link from another browser Then error", func(t *testing.T) {
+		ah, _, mailer := newTestMagicLinkAuthHandler(t, true)
+
+		rec := requestMagicLink(t, ah, user.Email)
+		browserCookies := rec.Result().Cookies()
+		assert.NotEmpty(t, browserCookies)
+
+		rec = followMagicLink(t, ah, mailer.sent[0], nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = followMagicLink(t, ah, mailer.sent[0], browserCookies)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+
+	t.Run("Given a magic link handler When signing up Then error", func(t *testing.T) {
+		ah, _, _ := newTestMagicLinkAuthHandler(t, false)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/auth/signup/magic-link", nil)
+		req = mux.SetURLVars(req, map[string]string{"action": "signup", "handler": magicLinkHandlerName.string()})
+		ah.HandleAuth(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
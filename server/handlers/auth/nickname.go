@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+)
+
+// GrantNickname sets the nickname of the user of the path by the authenticated admin.
+//  It's the override to grant a reserved name to a official account, the blocked words are still refused.
+func (a AuthHandler) GrantNickname(w http.ResponseWriter, r *http.Request) {
+	principal, _ := PrincipalFromContext(r.Context())
+
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid user id: %s is not a valid id", vars["id"]))
+		return
+	}
+
+	req := struct {
+		Nickname string `json:"nickname"`
+		Reason   string `json:"reason"`
+	}{}
+	err = a.reader.JSON(r, &req)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err))
+		return
+	}
+
+	nickname := users.NormalizeNickname(req.Nickname)
+	err = users.ValidateOfficialNickname(nickname)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = a.users.UpdateNickname(userID, nickname, users.NicknameSkeleton(nickname))
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = a.audit.SaveAuditEntry(auth.NewAuditEntry(principal.UserID, auth.AuditActionNicknameGranted, userID, 0, req.Reason, handlers.ClientIP(r)))
+	if err != nil {
+		log.Printf("failed to audit nickname %s granted to user %d by user %d: %s", nickname, userID, principal.UserID, err)
+	}
+
+	a.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"user_id":  userID,
+		"nickname": nickname,
+	})
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// grantNickname requests the admin provided to grant the nickname of the body to the user provided.
+func grantNickname(ah AuthHandler, adminID int, userID, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/api/v1/users/"+userID+"/nickname", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = mux.SetURLVars(req, map[string]string{"id": userID})
+	req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, auth.Principal{UserID: adminID}))
+	ah.GrantNickname(rec, req)
+	return rec
+}
+
+func TestGrantNickname(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	usersRepo := usersRepositoryImpl{users: map[string]users.User{
+		"official@example.com": {ID: 2, Email: "official@example.com", Nickname: "official", NicknameSkeleton: "offlclal"},
+		"other@example.com":    {ID: 3, Email: "other@example.com", Nickname: "other", NicknameSkeleton: "other"},
+	}}
+	ah.users = usersRepo
+	auditRepo := ah.audit.(*auditLogRepositoryImpl)
+
+	users.SetNicknameLists(users.NewNicknameLists([]string{"support"}, []string{"badword"}))
+	defer users.SetNicknameLists(users.NicknameLists{})
+
+	t.Run("Given a reserved name When a user signs up with it Then reserved nickname error", func(t *testing.T) {
+		_, err := users.New(users.User{Nickname: "Support", Password: "1234"})
+		assert.EqualError(t, err, "invalid nickname: Support is reserved")
+	})
+
+	t.Run("Given a reserved name When a admin grants it Then nickname updated and audited", func(t *testing.T) {
+		rec := grantNickname(ah, 1, "2", `{"nickname":"Support","reason":"official support account"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Support", usersRepo.users["official@example.com"].Nickname)
+
+		assert.Len(t, auditRepo.entries, 1)
+		assert.Equal(t, auth.AuditActionNicknameGranted, auditRepo.entries[0].Action)
+		assert.Equal(t, 1, auditRepo.entries[0].ActorID)
+		assert.Equal(t, 2, auditRepo.entries[0].TargetUserID)
+	})
+
+	t.Run("Given a granted nickname When a admin grants a look-alike to other user Then conflict error", func(t *testing.T) {
+		rec := grantNickname(ah, 1, "3", `{"nickname":"supp0rt"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Given a blocked word When a admin grants it Then invalid nickname error", func(t *testing.T) {
+		rec := grantNickname(ah, 1, "3", `{"nickname":"b4dw0rd"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "other", usersRepo.users["other@example.com"].Nickname)
+	})
+
+	t.Run("Given a missing user When a admin grants a nickname Then not found error", func(t *testing.T) {
+		rec := grantNickname(ah, 1, "9", `{"nickname":"support"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
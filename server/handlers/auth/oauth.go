@@ -14,13 +14,15 @@ import (
 )
 
 type oauth struct {
-	conf        *oauth2.Config
+	// conf gets the current OAuth config of the platform, so the reloaded credentials are used by the next request.
+	conf        func() *oauth2.Config
 	handler     handlerName
 	validStates map[string]bool
 }
 
 func (o oauth) redirectToHandler(w http.ResponseWriter, r *http.Request) (err error) {
-	authURL, err := url.Parse(o.conf.Endpoint.AuthURL)
+	conf := o.conf()
+	authURL, err := url.Parse(conf.Endpoint.AuthURL)
 	if err != nil {
 		err = fmt.Errorf("failed to parse auth url: %s", err)
 		return
@@ -31,9 +33,9 @@ func (o oauth) redirectToHandler(w http.ResponseWriter, r *http.Request) (err er
 
 	parameters := url.Values{}
 
-	parameters.Add("client_id", o.conf.ClientID)
-	parameters.Add("scope", strings.Join(o.conf.Scopes, " "))
-	parameters.Add("redirect_uri", o.conf.RedirectURL)
+	parameters.Add("client_id", conf.ClientID)
+	parameters.Add("scope", strings.Join(conf.Scopes, " "))
+	parameters.Add("redirect_uri", conf.RedirectURL)
 	parameters.Add("response_type", "code")
 	parameters.Add("state", state)
 
@@ -61,7 +63,7 @@ func (o oauth) callback(w http.ResponseWriter, r *http.Request) (response []byte
 		}
 		return
 	}
-	token, err := o.conf.Exchange(context.Background(), code)
+	token, err := o.conf().Exchange(context.Background(), code)
 	if err != nil {
 		fmt.Printf("oauth in %s exchange failed with: %s\n", o.handler, err)
 		err = errors.New("failed oauth callback error")
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthRedirect(t *testing.T) {
+	t.Run("Given reloaded OAuth credentials When redirecting to the platform Then the new credentials used", func(t *testing.T) {
+		live := &configImpl{}
+		live.conf.OAuth.Google.ClientID = "old-id"
+		live.conf.OAuth.Google.RedirectURIS = []string{"http://localhost:8080/api/v1/auth/callback/google"}
+		live.conf.OAuth.Google.Endpoint.AuthURL = "https://auth.example/authorize"
+		g := newGoogleHandler(live)
+
+		live.conf.OAuth.Google.ClientID = "new-id"
+
+		rec := httptest.NewRecorder()
+		assert.NoError(t, g.requestSignUp(rec, httptest.NewRequest("GET", "/", nil)))
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+
+		location, err := url.Parse(rec.Header().Get("Location"))
+		assert.NoError(t, err)
+		assert.Equal(t, "new-id", location.Query().Get("client_id"))
+		assert.Equal(t, "http://localhost:8080/api/v1/auth/callback/google", location.Query().Get("redirect_uri"))
+	})
+}
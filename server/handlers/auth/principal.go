@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/gorilla/mux"
+)
+
+type principalContextKey struct{}
+
+// PrincipalFromContext gets the authenticated principal stored by the Authenticate middleware.
+//  @param ctx context.Context: request context.
+//  @return principal auth.Principal: authenticated principal.
+//  @return ok bool: the context has an authenticated principal.
+func PrincipalFromContext(ctx context.Context) (principal auth.Principal, ok bool) {
+	principal, ok = ctx.Value(principalContextKey{}).(auth.Principal)
+	return
+}
+
+// Authenticate is a middleware which resolves the principal of the request session.
+//  Requests without a valid session are rejected with a unauthorized error.
+func (a AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.getPrincipal(w, r)
+		if err != nil {
+			a.handleError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalContextKey{}, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequirePermission initializes a new middleware which only allows the principals
+//  with the permission provided.
+//  @param permission auth.Permission: required permission.
+//  @return $1 mux.MiddlewareFunc: new middleware.
+func (a AuthHandler) RequirePermission(permission auth.Permission) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return a.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := PrincipalFromContext(r.Context())
+			if !principal.HasPermission(permission) {
+				a.handleError(w, sErrors.NewClientError(http.StatusForbidden, "forbidden: missing %s permission", permission))
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// AssignRole assigns the role of the path to the user of the path.
+func (a AuthHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	userID, role, err := readUserRoleVars(r)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = a.roles.AssignRole(userID, role)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	a.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"user_id": userID,
+		"role":    role,
+	})
+}
+
+// RevokeRole removes the role of the path from the user of the path.
+func (a AuthHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	userID, role, err := readUserRoleVars(r)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	err = a.roles.RevokeRole(userID, role)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getPrincipal gets the principal of the bearer access token or the session cookie of the request.
+//  A expired or missing session is re-established with the persistent login cookie, if any.
+func (a AuthHandler) getPrincipal(w http.ResponseWriter, r *http.Request) (principal auth.Principal, err error) {
+	if raw, ok := bearerToken(r); ok {
+		principal, err = a.getTokenPrincipal(r, raw)
+		return
+	}
+
+	session, err := a.Session(r)
+	if err != nil {
+		hErr, ok := err.(sErrors.ClientError)
+		if !ok || hErr.HTTPCode() != http.StatusUnauthorized || !hasCookie(r, rememberCookieName) {
+			return
+		}
+
+		session, err = a.restoreSession(w, r)
+		if err != nil {
+			return
+		}
+	}
+
+	roles, err := a.roles.GetUserRoles(session.UserID)
+	if err != nil {
+		return
+	}
+
+	principal = auth.NewPrincipal(session, roles)
+	return
+}
+
+// Session gets the active session of the session cookie of the request.
+//  The session is expired when it exceeded its idle or absolute lifetime, otherwise its last seen time is updated.
+//  @param r *http.Request: request to read.
+//  @return session auth.Session: active session of the request.
+//  @return err error: missing, invalid, expired or inactive session error.
+func (a AuthHandler) Session(r *http.Request) (session auth.Session, err error) {
+	sess, err := a.store.Get(r, authCookieName)
+	if err != nil {
+		return
+	}
+
+	sessionID, ok := sess.Values["session_id"].(int)
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		return
+	}
+
+	session, err = a.repository.GetSession(sessionID)
+	if err != nil {
+		return
+	}
+	if !session.Actived {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		return
+	}
+
+	now := time.Now()
+	if session.Expired(now, LifetimeOf(a.config, session)) {
+		err = a.sessions.ExpireSession(session.ID)
+		if err != nil {
+			return
+		}
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		return
+	}
+
+	// The last seen time is only updated once by touch interval to avoid a write by request.
+	if now.Sub(session.LastSeenAt) >= time.Duration(a.config.Session.TouchIntervalInSecs)*time.Second {
+		err = a.sessions.TouchSession(session.ID, now)
+		if err != nil {
+			return
+		}
+		session.LastSeenAt = now
+	}
+	return
+}
+
+// getTokenPrincipal gets the principal of the personal access token provided and tracks its usage.
+func (a AuthHandler) getTokenPrincipal(r *http.Request, raw string) (principal auth.Principal, err error) {
+	if !auth.IsAccessToken(raw) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: access token expired or invalid")
+		return
+	}
+
+	token, err := a.tokens.GetAccessTokenByHash(auth.HashToken(raw))
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	if !token.Valid(now) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: access token expired or invalid")
+		return
+	}
+
+	err = a.tokens.TouchAccessToken(token.ID, now, handlers.ClientIP(r))
+	if err != nil {
+		return
+	}
+
+	roles, err := a.roles.GetUserRoles(token.UserID)
+	if err != nil {
+		return
+	}
+
+	principal = auth.NewTokenPrincipal(token, roles)
+	return
+}
+
+// bearerToken gets the token of the Authorization header with the Bearer scheme.
+func bearerToken(r *http.Request) (token string, ok bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < len("Bearer ") || !strings.EqualFold(h[:len("Bearer ")], "Bearer ") {
+		return
+	}
+	token = strings.TrimSpace(h[len("Bearer "):])
+	ok = token != ""
+	return
+}
+
+func readUserRoleVars(r *http.Request) (userID int, role string, err error) {
+	vars := mux.Vars(r)
+	userID, err = strconv.Atoi(vars["id"])
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid user id: %s is not a valid id", vars["id"])
+		return
+	}
+	role = vars["role"]
+	return
+}
+
+// hasCookie checks if the request has the cookie provided.
+func hasCookie(r *http.Request, name string) bool {
+	_, err := r.Cookie(name)
+	return err == nil
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+type roleRepositoryImpl struct {
+	roles map[int][]auth.Role
+}
+
+// This statement is to check if the roleRepositoryImpl mock is doing well with the database.RoleRepository interface.
+var _ database.RoleRepository = &roleRepositoryImpl{}
+
+func (r *roleRepositoryImpl) GetUserRoles(userID int) (roles []auth.Role, err error) {
+	roles = r.roles[userID]
+	return
+}
+
+func (r *roleRepositoryImpl) AssignRole(userID int, role string) (err error) {
+	r.roles[userID] = append(r.roles[userID], auth.Role{Name: role})
+	return
+}
+
+func (r *roleRepositoryImpl) RevokeRole(userID int, role string) (err error) {
+	delete(r.roles, userID)
+	return
+}
+
+type tokenRepositoryImpl struct {
+	tokens map[string]auth.AccessToken
+}
+
+// This statement is to check if the tokenRepositoryImpl mock is doing well with the database.TokenRepository interface.
+var _ database.TokenRepository = &tokenRepositoryImpl{}
+
+func (r *tokenRepositoryImpl) SaveAccessToken(token auth.AccessToken) (id int, err error) {
+	id = len(r.tokens) + 1
+	token.ID = id
+	r.tokens[token.Hash] = token
+	return
+}
+
+func (r *tokenRepositoryImpl) GetAccessTokens(userID int) (tokens []auth.AccessToken, err error) {
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+	return
+}
+
+func (r *tokenRepositoryImpl) GetAccessTokenByHash(hash string) (token auth.AccessToken, err error) {
+	token, ok := r.tokens[hash]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: access token expired or invalid")
+	}
+	return
+}
+
+func (r *tokenRepositoryImpl) RevokeAccessToken(userID, id int) (err error) {
+	for h, t := range r.tokens {
+		if t.ID == id && t.UserID == userID {
+			now := time.Now()
+			t.RevokedAt = &now
+			r.tokens[h] = t
+		}
+	}
+	return
+}
+
+func (r *tokenRepositoryImpl) TouchAccessToken(id int, usedAt time.Time, ip string) (err error) {
+	for h, t := range r.tokens {
+		if t.ID == id {
+			t.LastUsedAt = &usedAt
+			t.LastUsedIP = ip
+			r.tokens[h] = t
+		}
+	}
+	return
+}
+
+func newTestAuthHandler(t *testing.T, authRepo *authRepositoryImpl, roleRepo *roleRepositoryImpl) AuthHandler {
+	t.Helper()
+
+	remembers := &rememberTokenRepositoryImpl{tokens: map[string]auth.RememberToken{}}
+	tokens := &tokenRepositoryImpl{tokens: map[string]auth.AccessToken{}}
+	sessionRepo := &sessionRepositoryImpl{auth: authRepo, remembers: remembers, tokens: tokens}
+	return AuthHandler{
+		repository:  authRepo,
+		roles:       roleRepo,
+		tokens:      tokens,
+		sessions:    sessionRepo,
+		remembers:   remembers,
+		users:       usersRepositoryImpl{users: map[string]users.User{}},
+		devices:     &deviceRepositoryImpl{devices: map[int]auth.Device{}},
+		resets:      &passwordResetRepositoryImpl{resets: map[string]auth.PasswordReset{}, sessions: sessionRepo},
+		securityLog: &securityLogRepositoryImpl{},
+		audit:       &auditLogRepositoryImpl{},
+		invites:     &inviteRepositoryImpl{invites: map[string]auth.Invite{}},
+		notifier:    &notifierImpl{},
+		writer:      handlers.GetResponseWriterImpl(),
+		reader:      handlers.GetRequestReaderImpl(),
+		store:       sessions.NewCookieStore([]byte("testkey")),
+	}
+}
+
+// newSessionRequest creates a new request with the session cookie of the session id provided.
+func newSessionRequest(t *testing.T, ah AuthHandler, sessionID int) *http.Request {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	sess, err := ah.store.Get(req, authCookieName)
+	assert.NoError(t, err)
+	sess.Values["session_id"] = sessionID
+	assert.NoError(t, sess.Save(req, rec))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestRequirePermission(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	roleRepo := &roleRepositoryImpl{roles: map[int][]auth.Role{}}
+	ah := newTestAuthHandler(t, &authRepo, roleRepo)
+
+	adminSession := session
+	adminSession.UserID = 1
+	adminSession.ID, _ = authRepo.UpsertSession(adminSession)
+	roleRepo.roles[adminSession.UserID] = []auth.Role{{Name: "admin", Permissions: []auth.Permission{auth.PermissionUsersAdmin}}}
+
+	userSession := session
+	userSession.UserID = 2
+	userSession.ID, _ = authRepo.UpsertSession(userSession)
+	roleRepo.roles[userSession.UserID] = []auth.Role{{Name: auth.DEFAULT_ROLE, Permissions: []auth.Permission{auth.PermissionChatWrite}}}
+
+	var got auth.Principal
+	h := ah.RequirePermission(auth.PermissionUsersAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("Given a session with the permission When calling a protected route Then success", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newSessionRequest(t, ah, adminSession.ID))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, adminSession.UserID, got.UserID)
+		assert.Equal(t, adminSession.ID, got.SessionID)
+	})
+	t.Run("Given a session without the permission When calling a protected route Then forbidden error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newSessionRequest(t, ah, userSession.ID))
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("Given a request without session When calling a protected route Then unauthorized error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthenticateAccessToken(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	roleRepo := &roleRepositoryImpl{roles: map[int][]auth.Role{
+		1: {{Name: "admin", Permissions: []auth.Permission{auth.PermissionUsersAdmin, auth.PermissionUsersRead}}},
+	}}
+	ah := newTestAuthHandler(t, &authRepo, roleRepo)
+	tokens := ah.tokens.(*tokenRepositoryImpl)
+
+	newToken := func(t *testing.T, scopes []auth.Permission, expiresAt *time.Time) string {
+		t.Helper()
+
+		token, raw, err := auth.NewAccessToken(1, "script", scopes, expiresAt)
+		assert.NoError(t, err)
+		_, err = tokens.SaveAccessToken(token)
+		assert.NoError(t, err)
+		return raw
+	}
+	serve := func(t *testing.T, permission auth.Permission, raw string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+raw)
+		ah.RequirePermission(permission)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Given a token with the scope When calling a protected route Then success and usage tracked", func(t *testing.T) {
+		raw := newToken(t, []auth.Permission{auth.PermissionUsersRead}, nil)
+
+		assert.Equal(t, http.StatusOK, serve(t, auth.PermissionUsersRead, raw).Code)
+
+		got := tokens.tokens[auth.HashToken(raw)]
+		assert.NotNil(t, got.LastUsedAt)
+		assert.Equal(t, "192.0.2.1", got.LastUsedIP)
+	})
+	t.Run("Given a token without the scope When calling a protected route Then forbidden error", func(t *testing.T) {
+		raw := newToken(t, []auth.Permission{auth.PermissionUsersRead}, nil)
+
+		assert.Equal(t, http.StatusForbidden, serve(t, auth.PermissionUsersAdmin, raw).Code)
+	})
+	t.Run("Given a expired token When calling a protected route Then unauthorized error", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		raw := newToken(t, []auth.Permission{auth.PermissionUsersRead}, &expiresAt)
+
+		assert.Equal(t, http.StatusUnauthorized, serve(t, auth.PermissionUsersRead, raw).Code)
+	})
+	t.Run("Given a unknown token When calling a protected route Then unauthorized error", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(t, auth.PermissionUsersRead, auth.ACCESS_TOKEN_PREFIX+"unknown").Code)
+	})
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"log"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+)
+
+// SessionReaper expires the sessions which exceeded their lifetime and purges the old inactive sessions and sudo records.
+type SessionReaper struct {
+	repository database.SessionRepository
+	lifetime   auth.Lifetime
+	purgeAfter time.Duration
+
+	stop chan struct{}
+}
+
+// NewSessionReaper initializes a new *SessionReaper instance.
+//  @param repo database.SessionRepository: SessionRepository interface for the sessions handling.
+//  @param conf config.ConfigInfo: keeps the session lifetimes.
+//  @return $1 *SessionReaper: new *SessionReaper instance.
+func NewSessionReaper(repo database.SessionRepository, conf config.ConfigInfo) *SessionReaper {
+	return &SessionReaper{
+		repository: repo,
+		lifetime:   sessionLifetime(conf),
+		purgeAfter: time.Duration(conf.Session.PurgeAfterInSecs) * time.Second,
+		stop:       make(chan struct{}),
+	}
+}
+
+// Reap expires and purges the sessions in bulk.
+//  @param now time.Time: time of the reaping.
+//  @return err error: failed records update or deletion.
+func (s *SessionReaper) Reap(now time.Time) (err error) {
+	var idleBefore, loggedBefore time.Time
+	if s.lifetime.Idle > 0 {
+		idleBefore = now.Add(-s.lifetime.Idle)
+	}
+	if s.lifetime.Absolute > 0 {
+		loggedBefore = now.Add(-s.lifetime.Absolute)
+	}
+
+	expired, err := s.repository.ExpireSessions(idleBefore, loggedBefore)
+	if err != nil {
+		return
+	}
+
+	purged, err := s.repository.PurgeSessions(now.Add(-s.purgeAfter))
+	if err != nil {
+		return
+	}
+
+	sudos, err := s.repository.PurgeSudos(now.Add(-s.purgeAfter))
+	if err != nil {
+		return
+	}
+
+	if expired+purged+sudos > 0 {
+		log.Printf("Reaped sessions: %d expired, %d purged, %d sudo records purged", expired, purged, sudos)
+	}
+	return
+}
+
+// Run reaps the sessions every interval until Stop is called. It is intended to be run in a goroutine.
+//  @param interval time.Duration: time between every reaping, zero disables it.
+func (s *SessionReaper) Run(interval time.Duration) {
+	var ticks <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		ticks = t.C
+	}
+
+	for {
+		select {
+		case now := <-ticks:
+			err := s.Reap(now)
+			if err != nil {
+				log.Printf("failed to reap sessions: %s", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the Run loop.
+func (s *SessionReaper) Stop() {
+	close(s.stop)
+}
+
+// sessionLifetime gets the session lifetime limits of the config.
+func sessionLifetime(conf config.ConfigInfo) auth.Lifetime {
+	return auth.Lifetime{
+		Idle:     time.Duration(conf.Session.IdleTimeoutInSecs) * time.Second,
+		Absolute: time.Duration(conf.Session.AbsoluteLifetimeInSecs) * time.Second,
+	}
+}
+
+// LifetimeOf gets the lifetime limits of the session provided, the impersonations have their own lifetime.
+//  @param conf config.ConfigInfo: keeps the session lifetimes.
+//  @param session auth.Session: session to check.
+//  @return $1 auth.Lifetime: lifetime limits of the session.
+func LifetimeOf(conf config.ConfigInfo, session auth.Session) auth.Lifetime {
+	if session.Impersonated() {
+		return impersonationLifetime(conf)
+	}
+	return sessionLifetime(conf)
+}
@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionRepositoryImpl is the database.SessionRepository mock over the sessions of a authRepositoryImpl mock.
+type sessionRepositoryImpl struct {
+	auth      *authRepositoryImpl
+	remembers *rememberTokenRepositoryImpl
+	tokens    *tokenRepositoryImpl
+	sudos     map[int]auth.Sudo
+}
+
+// This statement is to check if the sessionRepositoryImpl mock is doing well with the database.SessionRepository interface.
+var _ database.SessionRepository = &sessionRepositoryImpl{}
+
+func (s *sessionRepositoryImpl) TouchSession(id int, seenAt time.Time) (err error) {
+	s.auth.m.Lock()
+	sess := s.auth.session[id]
+	sess.LastSeenAt = seenAt
+	s.auth.session[id] = sess
+	s.auth.m.Unlock()
+	return
+}
+
+func (s *sessionRepositoryImpl) ExpireSession(id int) (err error) {
+	s.auth.m.Lock()
+	sess := s.auth.session[id]
+	sess.Actived = false
+	s.auth.session[id] = sess
+	s.auth.m.Unlock()
+	return
+}
+
+func (s *sessionRepositoryImpl) RevokeUserSessions(userID int) (err error) {
+	s.auth.m.Lock()
+	for id, sess := range s.auth.session {
+		if sess.UserID == userID {
+			sess.Actived = false
+			s.auth.session[id] = sess
+		}
+	}
+	s.auth.m.Unlock()
+
+	if s.remembers != nil {
+		for series, token := range s.remembers.tokens {
+			if token.UserID == userID && token.RevokedAt == nil {
+				err = s.remembers.RevokeRememberToken(series)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	if s.tokens != nil {
+		for _, token := range s.tokens.tokens {
+			if token.UserID == userID && token.RevokedAt == nil {
+				err = s.tokens.RevokeAccessToken(userID, token.ID)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+func (s *sessionRepositoryImpl) ExpireSessions(idleBefore, loggedBefore time.Time) (n int64, err error) {
+	s.auth.m.Lock()
+	for id, sess := range s.auth.session {
+		if sess.Actived && (sess.LastSeenAt.Before(idleBefore) || sess.LoggedAt.Before(loggedBefore)) {
+			sess.Actived = false
+			s.auth.session[id] = sess
+			n++
+		}
+	}
+	s.auth.m.Unlock()
+	return
+}
+
+func (s *sessionRepositoryImpl) PurgeSessions(before time.Time) (n int64, err error) {
+	s.auth.m.Lock()
+	for id, sess := range s.auth.session {
+		if !sess.Actived && sess.LastSeenAt.Before(before) {
+			delete(s.auth.session, id)
+			n++
+		}
+	}
+	s.auth.m.Unlock()
+	return
+}
+
+func (s *sessionRepositoryImpl) PurgeSudos(before time.Time) (n int64, err error) {
+	for id, sudo := range s.sudos {
+		if sudo.CreatedAt.Add(time.Duration(sudo.DurationInSecs) * time.Second).Before(before) {
+			delete(s.sudos, id)
+			n++
+		}
+	}
+	return
+}
+
+func (s *sessionRepositoryImpl) CreateImpersonationSession(session auth.Session) (id int, err error) {
+	s.auth.m.Lock()
+	s.auth.sessionSerial++
+	session.ID = s.auth.sessionSerial
+	s.auth.session[session.ID] = session
+	id = session.ID
+	s.auth.m.Unlock()
+	return
+}
+
+func (s *sessionRepositoryImpl) GetLastSudo(sessionID int) (sudo auth.Sudo, err error) {
+	for _, su := range s.sudos {
+		if su.SessionID == sessionID && su.CreatedAt.After(sudo.CreatedAt) {
+			sudo = su
+		}
+	}
+	if sudo.SessionID == 0 {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: session %d has no sudo", sessionID)
+	}
+	return
+}
+
+func newLifetimeConfig() (conf config.ConfigInfo) {
+	conf.Session.IdleTimeoutInSecs = 60 * 60
+	conf.Session.AbsoluteLifetimeInSecs = 24 * 60 * 60
+	conf.Session.TouchIntervalInSecs = 60
+	conf.Session.PurgeAfterInSecs = 7 * 24 * 60 * 60
+	return
+}
+
+func TestSessionLifetime(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	ah.config = newLifetimeConfig()
+
+	t.Run("Given a recently seen session When authenticating Then success", func(t *testing.T) {
+		s := session
+		s.LoggedAt = time.Now().Add(-2 * time.Hour)
+		s.LastSeenAt = time.Now().Add(-10 * time.Minute)
+		s.ID, _ = authRepo.UpsertSession(s)
+
+		got, err := ah.Session(newSessionRequest(t, ah, s.ID))
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), got.LastSeenAt, time.Second)
+		assert.WithinDuration(t, time.Now(), authRepo.session[s.ID].LastSeenAt, time.Second)
+	})
+
+	t.Run("Given a idle session When authenticating Then expired", func(t *testing.T) {
+		s := session
+		s.LoggedAt = time.Now().Add(-2 * time.Hour)
+		s.LastSeenAt = time.Now().Add(-2 * time.Hour)
+		s.ID, _ = authRepo.UpsertSession(s)
+
+		_, err := ah.Session(newSessionRequest(t, ah, s.ID))
+		assert.EqualError(t, err, "invalid credentials: user session expired or invalid")
+		assert.False(t, authRepo.session[s.ID].Actived)
+	})
+
+	t.Run("Given a session logged long ago When authenticating Then expired", func(t *testing.T) {
+		s := session
+		s.LoggedAt = time.Now().Add(-48 * time.Hour)
+		s.LastSeenAt = time.Now()
+		s.ID, _ = authRepo.UpsertSession(s)
+
+		rec := httptest.NewRecorder()
+		ah.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, newSessionRequest(t, ah, s.ID))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.False(t, authRepo.session[s.ID].Actived)
+	})
+
+	t.Run("Given a session logged long ago not reaped When logging in again Then session renewed", func(t *testing.T) {
+		s := session
+		s.LoggedAt = time.Now().Add(-48 * time.Hour)
+		s.LastSeenAt = time.Now().Add(-48 * time.Hour)
+		s.ID, _ = authRepo.UpsertSession(s)
+
+		renewed, _ := auth.NewSession(s.UserID, "system")
+		id, err := authRepo.UpsertSession(renewed)
+		assert.NoError(t, err)
+		assert.Equal(t, s.ID, id)
+
+		got, err := ah.Session(newSessionRequest(t, ah, id))
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), got.LoggedAt, time.Second)
+	})
+}
+
+func TestSessionReaper(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	now := time.Now()
+
+	put := func(loggedAt, lastSeenAt time.Time, actived bool) int {
+		authRepo.sessionSerial++
+		authRepo.session[authRepo.sessionSerial] = auth.Session{
+			ID:         authRepo.sessionSerial,
+			LoggedAt:   loggedAt,
+			LastSeenAt: lastSeenAt,
+			Actived:    actived,
+		}
+		return authRepo.sessionSerial
+	}
+
+	fresh := put(now.Add(-time.Hour), now, true)
+	idle := put(now.Add(-2*time.Hour), now.Add(-2*time.Hour), true)
+	old := put(now.Add(-48*time.Hour), now, true)
+	purgeable := put(now.Add(-30*24*time.Hour), now.Add(-10*24*time.Hour), false)
+
+	repo := &sessionRepositoryImpl{
+		auth: &authRepo,
+		sudos: map[int]auth.Sudo{
+			1: {ID: 1, DurationInSecs: 900, CreatedAt: now.Add(-8 * 24 * time.Hour)},
+			2: {ID: 2, DurationInSecs: 900, CreatedAt: now},
+		},
+	}
+
+	t.Run("Given expired and old sessions When reaping Then expired and purged", func(t *testing.T) {
+		err := NewSessionReaper(repo, newLifetimeConfig()).Reap(now)
+		assert.NoError(t, err)
+
+		assert.True(t, authRepo.session[fresh].Actived)
+		assert.False(t, authRepo.session[idle].Actived)
+		assert.False(t, authRepo.session[old].Actived)
+		assert.NotContains(t, authRepo.session, purgeable)
+
+		assert.NotContains(t, repo.sudos, 1)
+		assert.Contains(t, repo.sudos, 2)
+	})
+}
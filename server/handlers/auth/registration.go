@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// OPEN_REGISTRATION allows anyone to sign up.
+	OPEN_REGISTRATION = "open"
+
+	// INVITE_REGISTRATION only allows to sign up with a valid invite code.
+	INVITE_REGISTRATION = "invite"
+
+	// DOMAIN_REGISTRATION only allows to sign up with a email of the allowed domains.
+	DOMAIN_REGISTRATION = "domain"
+)
+
+// inviteCookieName keeps the invite code during the external sign up flows, which can't carry it in the request body.
+const inviteCookieName = "invite_code"
+
+// inviteCookieMaxAge is the max duration of a external sign up flow.
+const inviteCookieMaxAge = 60 * 60
+
+// checkRegistrationConfig checks the registration mode of the config is supported.
+func checkRegistrationConfig(conf config.ConfigInfo) (err error) {
+	switch conf.Registration.Mode {
+	case OPEN_REGISTRATION, INVITE_REGISTRATION, "":
+	case DOMAIN_REGISTRATION:
+		if len(conf.Registration.AllowedDomains) == 0 {
+			err = fmt.Errorf("invalid registration config: %s mode requires allowed domains", DOMAIN_REGISTRATION)
+		}
+	default:
+		err = fmt.Errorf("invalid registration mode: %s is not supported", conf.Registration.Mode)
+	}
+	return
+}
+
+// admit checks the registration mode allows the new user and registers it.
+//  With the invite-only mode a use of the invite of the user is consumed, and released if the register fails.
+//  @param userR users.User: user to register.
+//  @param platform string: platform which the user has been sign.
+//	@return user users.User: ending-user information.
+//	@return session auth.Session: new session of the user.
+//	@return err error: registration closed, invalid invite or connection error
+func (a AuthHandler) admit(userR users.User, platform string) (user users.User, session auth.Session, err error) {
+	var inviteHash string
+	switch a.config.Registration.Mode {
+	case INVITE_REGISTRATION:
+		code := strings.TrimSpace(userR.InviteCode)
+		if code == "" {
+			err = sErrors.NewClientError(http.StatusForbidden, "registration closed: a invite is required to sign up")
+			return
+		}
+		inviteHash = auth.HashToken(code)
+		err = a.invites.UseInvite(inviteHash, time.Now())
+		if err != nil {
+			return
+		}
+	case DOMAIN_REGISTRATION:
+		email := signUpEmail(userR)
+		if !allowedDomain(email, a.config.Registration.AllowedDomains) {
+			err = sErrors.NewClientError(http.StatusForbidden, "registration closed: the email %s is not of a allowed domain", email)
+			return
+		}
+	}
+
+	user, session, err = a.register(userR, platform)
+	if err != nil && inviteHash != "" {
+		if rErr := a.invites.ReleaseInvite(inviteHash); rErr != nil {
+			log.Printf("failed to release invite of a failed sign up: %s", rErr)
+		}
+	}
+	return
+}
+
+// CreateInvite creates a new registration invite.
+//  The code is only present in this response, just its hash is stored.
+func (a AuthHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	principal, _ := PrincipalFromContext(r.Context())
+
+	body := struct {
+		MaxUses   int `json:"max_uses"`
+		TTLInSecs int `json:"ttl_in_secs"`
+	}{}
+	err := a.reader.JSON(r, &body)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid body: %s", err))
+		return
+	}
+
+	if body.MaxUses == 0 {
+		body.MaxUses = 1
+	}
+	if body.TTLInSecs == 0 {
+		body.TTLInSecs = a.config.Registration.InviteTTLInSecs
+	}
+	if body.MaxUses < 0 {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid max uses: %d is not a valid number", body.MaxUses))
+		return
+	}
+	if body.TTLInSecs < 0 {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid ttl: %d is not a valid number", body.TTLInSecs))
+		return
+	}
+
+	invite, raw, err := auth.NewInvite(principal.UserID, body.MaxUses, time.Duration(body.TTLInSecs)*time.Second)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	invite.ID, err = a.invites.SaveInvite(invite)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	a.writer.JSON(w, http.StatusCreated, handlers.Hash{
+		"code":   raw,
+		"invite": invite,
+	})
+}
+
+// RevokeInvite revokes the invite of the path.
+func (a AuthHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid invite id: %s is not a valid id", vars["id"]))
+		return
+	}
+
+	err = a.invites.RevokeInvite(id)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// inviteCode gets the invite code of the user or, if it has none, the code kept by the invite cookie.
+func inviteCode(r *http.Request, user users.User) string {
+	if user.InviteCode != "" {
+		return user.InviteCode
+	}
+	if c, err := r.Cookie(inviteCookieName); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// setInviteCookie keeps the invite code of the query of a external sign request.
+func (a AuthHandler) setInviteCookie(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("invite")
+	if code == "" {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     inviteCookieName,
+		Value:    code,
+		Path:     "/",
+		MaxAge:   inviteCookieMaxAge,
+		Secure:   a.config.Session.SecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearInviteCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   inviteCookieName,
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
+
+// signUpEmail gets the email of a new user, the external users only have the email of the platform.
+func signUpEmail(user users.User) string {
+	if user.Email == "" && len(user.SignedWith) > 0 {
+		return user.SignedWith[0].Email
+	}
+	return user.Email
+}
+
+// allowedDomain checks if the domain of the email is one of the domains provided.
+func allowedDomain(email string, domains []string) bool {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[i+1:])
+	for _, d := range domains {
+		if strings.ToLower(strings.TrimSpace(d)) == domain {
+			return true
+		}
+	}
+	return false
+}
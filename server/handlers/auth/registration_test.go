@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/users"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type inviteRepositoryImpl struct {
+	invites map[string]auth.Invite
+}
+
+// This statement is to check if the inviteRepositoryImpl mock is doing well with the database.InviteRepository interface.
+var _ database.InviteRepository = &inviteRepositoryImpl{}
+
+func (i *inviteRepositoryImpl) SaveInvite(invite auth.Invite) (id int, err error) {
+	id = len(i.invites) + 1
+	invite.ID = id
+	i.invites[invite.Hash] = invite
+	return
+}
+
+func (i *inviteRepositoryImpl) RevokeInvite(id int) (err error) {
+	for h, inv := range i.invites {
+		if inv.ID == id && inv.RevokedAt == nil {
+			now := time.Now()
+			inv.RevokedAt = &now
+			i.invites[h] = inv
+			return
+		}
+	}
+	err = sErrors.NewClientError(http.StatusNotFound, "not found: invite %d not found or already revoked", id)
+	return
+}
+
+func (i *inviteRepositoryImpl) UseInvite(hash string, usedAt time.Time) (err error) {
+	inv, ok := i.invites[hash]
+	if !ok || !inv.Valid(usedAt) {
+		err = sErrors.NewClientError(http.StatusForbidden, "invalid invite: invite expired, revoked, exhausted or invalid")
+		return
+	}
+	inv.Uses++
+	i.invites[hash] = inv
+	return
+}
+
+func (i *inviteRepositoryImpl) ReleaseInvite(hash string) (err error) {
+	inv := i.invites[hash]
+	inv.Uses--
+	i.invites[hash] = inv
+	return
+}
+
+// newSignUpUser creates a new valid user to sign up with the email and invite provided.
+//  The nickname is taken from the email, so each email is a different user.
+func newSignUpUser(email, invite string) users.User {
+	return users.User{
+		Nickname:   "u" + strings.NewReplacer("@", "_", ".", "_").Replace(email),
+		Email:      email,
+		Password:   user.Password,
+		InviteCode: invite,
+	}
+}
+
+func TestRegistrationModes(t *testing.T) {
+	t.Run("Given the invite-only mode When signing up without invite Then error", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.config.Registration.Mode = INVITE_REGISTRATION
+
+		_, _, err := ah.signUp(newSignUpUser("a@host.com", ""))
+		assert.Error(t, err)
+		assert.Empty(t, authRepo.users)
+	})
+
+	t.Run("Given a single-use invite When signing up twice Then second sign up error", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.config.Registration.Mode = INVITE_REGISTRATION
+		invite, raw, err := auth.NewInvite(1, 1, time.Hour)
+		assert.NoError(t, err)
+		_, _ = ah.invites.SaveInvite(invite)
+
+		_, _, err = ah.signUp(newSignUpUser("a@host.com", raw))
+		assert.NoError(t, err)
+
+		_, _, err = ah.signUp(newSignUpUser("b@host.com", raw))
+		assert.Error(t, err)
+		assert.Len(t, authRepo.users, 1)
+	})
+
+	t.Run("Given a invite When the sign up fails Then invite use released", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.config.Registration.Mode = INVITE_REGISTRATION
+		invite, raw, _ := auth.NewInvite(1, 2, time.Hour)
+		_, _ = ah.invites.SaveInvite(invite)
+
+		u := newSignUpUser("a@host.com", raw)
+		_, _, err := ah.signUp(u)
+		assert.NoError(t, err)
+		_, _, err = ah.signUp(u)
+		assert.Error(t, err)
+		assert.Equal(t, 1, ah.invites.(*inviteRepositoryImpl).invites[invite.Hash].Uses)
+	})
+
+	t.Run("Given a expired invite When signing up Then error", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.config.Registration.Mode = INVITE_REGISTRATION
+		invite, raw, _ := auth.NewInvite(1, 5, -time.Minute)
+		_, _ = ah.invites.SaveInvite(invite)
+
+		_, _, err := ah.signUp(newSignUpUser("a@host.com", raw))
+		assert.Error(t, err)
+	})
+
+	t.Run("Given the domain mode When signing up Then only allowed domains registered", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.config.Registration.Mode = DOMAIN_REGISTRATION
+		ah.config.Registration.AllowedDomains = []string{"example.com"}
+
+		_, _, err := ah.signUp(newSignUpUser("a@Example.com", ""))
+		assert.NoError(t, err)
+
+		_, _, err = ah.signUp(newSignUpUser("a@example.com.evil.org", ""))
+		assert.Error(t, err)
+
+		external := users.User{SignedWith: []users.ExternalSigned{{ID: "1", Email: "b@other.com", Platform: "google"}}}
+		_, _, err = ah.admit(external, "google")
+		assert.Error(t, err)
+		assert.Len(t, authRepo.users, 1)
+	})
+
+	t.Run("Given the domain mode When a external user of a allowed domain signs up Then registered", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.config.Registration.Mode = DOMAIN_REGISTRATION
+		ah.config.Registration.AllowedDomains = []string{"example.com"}
+
+		external := users.User{SignedWith: []users.ExternalSigned{{ID: "1", Email: "b@example.com", Platform: "google"}}}
+		_, _, err := ah.admit(external, "google")
+		assert.NoError(t, err)
+		assert.Len(t, authRepo.users, 1)
+	})
+}
+
+func TestInvites(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	ah.config.Registration.InviteTTLInSecs = 3600
+	inviteRepo := ah.invites.(*inviteRepositoryImpl)
+
+	var created struct {
+		Code   string      `json:"code"`
+		Invite auth.Invite `json:"invite"`
+	}
+	t.Run("Given a invite request When creating the invite Then code returned and hash stored", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/invites", strings.NewReader(`{"max_uses":3}`))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, auth.Principal{UserID: 1}))
+		ah.CreateInvite(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.True(t, strings.HasPrefix(created.Code, auth.INVITE_PREFIX))
+		assert.Equal(t, 3, created.Invite.MaxUses)
+		assert.True(t, inviteRepo.invites[auth.HashToken(created.Code)].Valid(time.Now()))
+	})
+
+	t.Run("Given a invite When revoking it Then invite invalid", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest("DELETE", "/", nil), map[string]string{"id": "1"})
+		ah.RevokeInvite(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.False(t, inviteRepo.invites[auth.HashToken(created.Code)].Valid(time.Now()))
+
+		rec = httptest.NewRecorder()
+		ah.RevokeInvite(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+)
+
+const (
+	// rememberCookieName is the cookie which keeps the persistent login token.
+	rememberCookieName = "remember"
+
+	// rememberHandlerName is the platform of the sessions re-established by a persistent login.
+	rememberHandlerName handlerName = "remember-me"
+)
+
+// remember starts a new persistent login series for the user and sets its cookie.
+//  @param w http.ResponseWriter: response writer of the call.
+//  @param userID int: user to remember.
+//  @return err error: random generation or record creation error.
+func (a AuthHandler) remember(w http.ResponseWriter, userID int) (err error) {
+	token, raw, err := auth.NewRememberToken(userID, time.Duration(a.config.Session.RememberMeTTLInSecs)*time.Second)
+	if err != nil {
+		return
+	}
+
+	err = a.remembers.SaveRememberToken(token)
+	if err != nil {
+		return
+	}
+
+	a.setRememberCookie(w, raw, token.ExpiresAt)
+	return
+}
+
+// restoreSession re-establishes a session with the persistent login token of the request, rotating the token.
+//  The reuse of a rotated token is treated as a theft and the whole series is revoked.
+//  @param w http.ResponseWriter: response writer of the call.
+//  @param r *http.Request: request instance of the call.
+//  @return session auth.Session: new session of the user.
+//  @return err error: missing, invalid, expired or reused token error.
+func (a AuthHandler) restoreSession(w http.ResponseWriter, r *http.Request) (session auth.Session, err error) {
+	c, err := r.Cookie(rememberCookieName)
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		return
+	}
+
+	series, hash, err := auth.ParseRememberToken(c.Value)
+	if err != nil {
+		a.clearRememberCookie(w)
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: %s", err)
+		return
+	}
+
+	token, err := a.remembers.GetRememberToken(series)
+	if err != nil {
+		a.clearRememberCookie(w)
+		return
+	}
+
+	now := time.Now()
+	if !token.Valid(now) {
+		a.clearRememberCookie(w)
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: remember token expired or invalid")
+		return
+	}
+
+	next, raw, err := token.Rotate()
+	if err != nil {
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) != 1 {
+		err = a.revokeStolenSeries(w, token)
+		return
+	}
+
+	// The rotation only succeeds if the token wasn't rotated since it was read, otherwise it was reused.
+	err = a.remembers.RotateRememberToken(series, hash, next.Hash, now)
+	if err != nil {
+		if hErr, ok := err.(sErrors.ClientError); ok && hErr.HTTPCode() == http.StatusUnauthorized {
+			err = a.revokeStolenSeries(w, token)
+		}
+		return
+	}
+
+	session, err = auth.NewSession(token.UserID, rememberHandlerName.string())
+	if err != nil {
+		return
+	}
+
+	session.ID, err = a.repository.UpsertSession(session)
+	if err != nil {
+		return
+	}
+
+	sess, err := a.store.Get(r, authCookieName)
+	if err != nil {
+		return
+	}
+	err = renewSessionToken(a.store, sess)
+	if err != nil {
+		return
+	}
+	sess.Values["session_id"] = session.ID
+	err = sess.Save(r, w)
+	if err != nil {
+		return
+	}
+
+	a.setRememberCookie(w, raw, token.ExpiresAt)
+	a.recordSecurityEvent(r, session.UserID, auth.SecurityEventLogin, session.LoggedWith, session.ID)
+	log.Printf("Restored session %d of user %d with a persistent login", session.ID, session.UserID)
+	return
+}
+
+// revokeStolenSeries revokes the series of a reused token.
+func (a AuthHandler) revokeStolenSeries(w http.ResponseWriter, token auth.RememberToken) (err error) {
+	log.Printf("Reused remember token of user %d, revoking its series", token.UserID)
+
+	a.clearRememberCookie(w)
+	err = a.remembers.RevokeRememberToken(token.Series)
+	if err != nil {
+		return
+	}
+
+	err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: remember token reused, the persistent login has been revoked")
+	return
+}
+
+func (a AuthHandler) setRememberCookie(w http.ResponseWriter, raw string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     rememberCookieName,
+		Value:    raw,
+		Path:     "/",
+		Expires:  expiresAt,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+		Secure:   a.config.Session.SecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (a AuthHandler) clearRememberCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   rememberCookieName,
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type rememberTokenRepositoryImpl struct {
+	tokens map[string]auth.RememberToken
+}
+
+// This statement is to check if the rememberTokenRepositoryImpl mock is doing well with the database.RememberTokenRepository interface.
+var _ database.RememberTokenRepository = &rememberTokenRepositoryImpl{}
+
+func (r *rememberTokenRepositoryImpl) SaveRememberToken(token auth.RememberToken) (err error) {
+	r.tokens[token.Series] = token
+	return
+}
+
+func (r *rememberTokenRepositoryImpl) GetRememberToken(series string) (token auth.RememberToken, err error) {
+	token, ok := r.tokens[series]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: remember token expired or invalid")
+	}
+	return
+}
+
+func (r *rememberTokenRepositoryImpl) RotateRememberToken(series, oldHash, newHash string, usedAt time.Time) (err error) {
+	token, ok := r.tokens[series]
+	if !ok || token.Hash != oldHash || token.RevokedAt != nil {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: remember token expired or invalid")
+		return
+	}
+	token.Hash = newHash
+	token.LastUsedAt = &usedAt
+	r.tokens[series] = token
+	return
+}
+
+func (r *rememberTokenRepositoryImpl) RevokeRememberToken(series string) (err error) {
+	token := r.tokens[series]
+	now := time.Now()
+	token.RevokedAt = &now
+	r.tokens[series] = token
+	return
+}
+
+// rememberRequest creates a new request with only the remember cookie provided.
+func rememberRequest(c *http.Cookie) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(c)
+	return req
+}
+
+func rememberCookie(t *testing.T, rec *httptest.ResponseRecorder) (c *http.Cookie) {
+	t.Helper()
+
+	for _, rc := range rec.Result().Cookies() {
+		if rc.Name == rememberCookieName {
+			c = rc
+		}
+	}
+	assert.NotNil(t, c)
+	return
+}
+
+func TestRememberMe(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	rememberRepo := ah.remembers.(*rememberTokenRepositoryImpl)
+	ah.config = newLifetimeConfig()
+	ah.config.Session.RememberMeTTLInSecs = 3600
+
+	var got auth.Principal
+	h := ah.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, ah.remember(rec, 7))
+	first := rememberCookie(t, rec)
+
+	var second *http.Cookie
+
+	t.Run("Given a remember cookie without session When authenticating Then session restored and token rotated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, rememberRequest(first))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 7, got.UserID)
+
+		s, err := authRepo.GetSession(got.SessionID)
+		assert.NoError(t, err)
+		assert.Equal(t, rememberHandlerName.string(), s.LoggedWith)
+
+		second = rememberCookie(t, rec)
+		assert.NotEqual(t, first.Value, second.Value)
+	})
+
+	t.Run("Given a rotated remember token When reusing it Then series revoked", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, rememberRequest(first))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, rememberRequest(second))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		for _, token := range rememberRepo.tokens {
+			assert.NotNil(t, token.RevokedAt)
+		}
+	})
+
+	t.Run("Given a malformed remember cookie When authenticating Then error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, rememberRequest(&http.Cookie{Name: rememberCookieName, Value: "malformed"}))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+)
+
+const (
+	defaultSecurityLogLimit = 50
+	maxSecurityLogLimit     = 100
+)
+
+// recordSecurityEvent writes a new event in the security log of the user.
+//  The errors are only logged, so they don't fail the request which performed the event.
+//  @param r *http.Request: request which performed the event.
+//  @param userID int: owner of the account.
+//  @param eventType auth.SecurityEventType: kind of the event.
+//  @param method string: platform or handler which performed the event.
+//  @param sessionID int: session related to the event, zero if there is none.
+func (a AuthHandler) recordSecurityEvent(r *http.Request, userID int, eventType auth.SecurityEventType, method string, sessionID int) {
+	event := auth.NewSecurityEvent(userID, eventType, method, handlers.ClientIP(r), r.UserAgent(), sessionID)
+	err := a.securityLog.SaveSecurityEvent(event)
+	if err != nil {
+		log.Printf("failed to record security event: %s", err)
+	}
+}
+
+// GetSecurityLog lists a page of the security log of the authenticated user, newest first.
+//  The page is selected with the "before" query param, the id of the last event of the previous page.
+//  It keeps the IPs and user agents of the user, so it's only readable by the own sessions of the user.
+func (a AuthHandler) GetSecurityLog(w http.ResponseWriter, r *http.Request) {
+	principal, err := a.requireSessionPrincipal(r, "read the security log")
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	limit, err := queryInt(r, "limit", defaultSecurityLogLimit)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	if limit <= 0 || limit > maxSecurityLogLimit {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid limit: must be between 1 and %d", maxSecurityLogLimit))
+		return
+	}
+
+	before, err := queryInt(r, "before", 0)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	events, err := a.securityLog.GetSecurityEvents(principal.UserID, before, limit)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	if events == nil {
+		events = []auth.SecurityEvent{}
+	}
+
+	resp := handlers.Hash{
+		"events": events,
+	}
+	if len(events) == limit {
+		resp["next_before"] = events[len(events)-1].ID
+	}
+	a.writer.JSON(w, http.StatusOK, resp)
+}
+
+// queryInt reads the int query param provided.
+func queryInt(r *http.Request, name string, def int) (i int, err error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		i = def
+		return
+	}
+	i, err = strconv.Atoi(v)
+	if err != nil || i < 0 {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid %s: %s is not a valid number", name, v)
+	}
+	return
+}
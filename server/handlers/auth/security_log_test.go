@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type securityLogRepositoryImpl struct {
+	events []auth.SecurityEvent
+}
+
+// This statement is to check if the securityLogRepositoryImpl mock is doing well with the database.SecurityLogRepository interface.
+var _ database.SecurityLogRepository = &securityLogRepositoryImpl{}
+
+func (s *securityLogRepositoryImpl) SaveSecurityEvent(event auth.SecurityEvent) (err error) {
+	event.ID = len(s.events) + 1
+	s.events = append(s.events, event)
+	return
+}
+
+func (s *securityLogRepositoryImpl) GetSecurityEvents(userID, before, limit int) (events []auth.SecurityEvent, err error) {
+	for i := len(s.events) - 1; i >= 0 && len(events) < limit; i-- {
+		e := s.events[i]
+		if e.UserID == userID && (before == 0 || e.ID < before) {
+			events = append(events, e)
+		}
+	}
+	return
+}
+
+// getSecurityLog requests the security log page of the user with the query provided.
+func getSecurityLog(t *testing.T, ah AuthHandler, userID int, query string) (rec *httptest.ResponseRecorder, resp securityLogResponse) {
+	t.Helper()
+
+	return getSecurityLogAs(t, ah, auth.Principal{UserID: userID, SessionID: 1}, query)
+}
+
+// getSecurityLogAs requests the security log page of the principal provided with the query provided.
+func getSecurityLogAs(t *testing.T, ah AuthHandler, principal auth.Principal, query string) (rec *httptest.ResponseRecorder, resp securityLogResponse) {
+	t.Helper()
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/users/me/security-log"+query, nil)
+	req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, principal))
+	ah.GetSecurityLog(rec, req)
+	if rec.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+	return
+}
+
+type securityLogResponse struct {
+	Events     []auth.SecurityEvent `json:"events"`
+	NextBefore int                  `json:"next_before"`
+}
+
+func TestSecurityLog(t *testing.T) {
+	t.Run("Given a email code login When reading the security log Then login recorded", func(t *testing.T) {
+		ah, _, mailer := newTestEmailCodeAuthHandler(t)
+
+		doEmailCodeRequest(t, ah, "external-sign", `{"email":"`+user.Email+`"}`)
+		rec := doEmailCodeRequest(t, ah, "login", `{"email":"`+user.Email+`","code":"`+sentCode(t, mailer)+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		_, resp := getSecurityLog(t, ah, 1, "")
+		assert.Len(t, resp.Events, 1)
+		assert.Equal(t, auth.SecurityEventLogin, resp.Events[0].Type)
+		assert.Equal(t, emailCodeHandlerName.string(), resp.Events[0].Method)
+		assert.Equal(t, "192.0.2.1", resp.Events[0].IP)
+		assert.NotZero(t, resp.Events[0].SessionID)
+	})
+
+	t.Run("Given a wrong password When logging in Then failed login recorded", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+
+		u := user
+		u.ID = 7
+		u.Password = "$2a$10$invalidhashinvalidhashinvalidhashinvalidhashinvalidha"
+		authRepo.users[u.Nickname] = u
+
+		login := user
+		login.Identifier = user.Nickname
+		_, err := ah.login(httptest.NewRequest("POST", "/", nil), login, systemHandlerName)
+		assert.Error(t, err)
+
+		_, resp := getSecurityLog(t, ah, 7, "")
+		assert.Len(t, resp.Events, 1)
+		assert.Equal(t, auth.SecurityEventLoginFailed, resp.Events[0].Type)
+		assert.Zero(t, resp.Events[0].SessionID)
+	})
+
+	t.Run("Given many events When paginating the security log Then newest first pages", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		req := httptest.NewRequest("POST", "/", nil)
+		for i := 0; i < 3; i++ {
+			ah.recordSecurityEvent(req, 1, auth.SecurityEventSudoGranted, systemHandlerName.string(), 1)
+		}
+		ah.recordSecurityEvent(req, 2, auth.SecurityEventLogin, systemHandlerName.string(), 2)
+
+		_, resp := getSecurityLog(t, ah, 1, "?limit=2")
+		assert.Len(t, resp.Events, 2)
+		assert.Equal(t, 3, resp.Events[0].ID)
+		assert.Equal(t, 2, resp.NextBefore)
+
+		_, resp = getSecurityLog(t, ah, 1, "?limit=2&before=2")
+		assert.Len(t, resp.Events, 1)
+		assert.Equal(t, 1, resp.Events[0].ID)
+		assert.Zero(t, resp.NextBefore)
+	})
+
+	t.Run("Given a invalid limit When reading the security log Then error", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+
+		rec, _ := getSecurityLog(t, ah, 1, "?limit=1000")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Given a access token or a impersonation session When reading the security log Then forbidden", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.recordSecurityEvent(httptest.NewRequest("POST", "/", nil), 1, auth.SecurityEventLogin, systemHandlerName.string(), 1)
+
+		rec, _ := getSecurityLogAs(t, ah, auth.Principal{UserID: 1, TokenID: 1}, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec, _ = getSecurityLogAs(t, ah, auth.Principal{UserID: 1, SessionID: 2, ImpersonatorID: 2}, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
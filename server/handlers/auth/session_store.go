@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/gorilla/sessions"
+)
+
+const (
+	// COOKIE_SESSION_STORE keeps the session values signed inside the cookie.
+	COOKIE_SESSION_STORE = "cookie"
+
+	// DATABASE_SESSION_STORE keeps the session values in the database, the cookie only holds a opaque token.
+	DATABASE_SESSION_STORE = "database"
+)
+
+// DatabaseStore is a sessions.Store which keeps the session values in the database.
+//  The cookie only holds a opaque random token, so deleting the token record or
+//  deactivating its user session invalidates the cookie immediately.
+type DatabaseStore struct {
+	repository database.SessionTokenRepository
+	Options    *sessions.Options
+}
+
+// NewDatabaseStore initializes a new *DatabaseStore instance.
+//  @param repo database.SessionTokenRepository: SessionTokenRepository interface for the session tokens handling.
+//  @param opts sessions.Options: default options of the session cookies.
+//  @return $1 *DatabaseStore: new *DatabaseStore instance.
+func NewDatabaseStore(repo database.SessionTokenRepository, opts sessions.Options) *DatabaseStore {
+	return &DatabaseStore{
+		repository: repo,
+		Options:    &opts,
+	}
+}
+
+// Get returns the session of the request registry, loading it if it wasn't loaded yet.
+func (d *DatabaseStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(d, name)
+}
+
+// New loads the session of the request cookie, or returns a new empty session if the cookie is missing or invalid.
+func (d *DatabaseStore) New(r *http.Request, name string) (session *sessions.Session, err error) {
+	session = sessions.NewSession(d, name)
+	opts := *d.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		err = nil
+		return
+	}
+
+	token, err := d.repository.GetSessionToken(auth.HashToken(c.Value))
+	if err != nil {
+		if hErr, ok := err.(sErrors.ClientError); ok && hErr.HTTPCode() == http.StatusNotFound {
+			err = nil
+		}
+		return
+	}
+
+	err = gob.NewDecoder(bytes.NewReader(token.Values)).Decode(&session.Values)
+	if err != nil {
+		err = fmt.Errorf("failed to decode session values: %s", err)
+		return
+	}
+
+	session.ID = c.Value
+	session.IsNew = false
+	return
+}
+
+// Save persists the session values and writes the token cookie.
+//  A session with a negative MaxAge is deleted with its cookie.
+func (d *DatabaseStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) (err error) {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			err = d.repository.DeleteSessionToken(auth.HashToken(session.ID))
+			if err != nil {
+				return
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return
+	}
+
+	if session.ID == "" {
+		session.ID, err = auth.RandomToken("")
+		if err != nil {
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	err = gob.NewEncoder(&buf).Encode(session.Values)
+	if err != nil {
+		err = fmt.Errorf("failed to encode session values: %s", err)
+		return
+	}
+
+	now := time.Now()
+	sessionID, _ := session.Values["session_id"].(int)
+	err = d.repository.SaveSessionToken(auth.SessionToken{
+		Hash:      auth.HashToken(session.ID),
+		SessionID: sessionID,
+		Values:    buf.Bytes(),
+		ExpiresAt: now.Add(time.Duration(session.Options.MaxAge) * time.Second),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), session.ID, session.Options))
+	return
+}
+
+// Renew deletes the token of the session and clears its ID, so the next Save issues a new token.
+func (d *DatabaseStore) Renew(session *sessions.Session) (err error) {
+	if session.ID != "" {
+		err = d.repository.DeleteSessionToken(auth.HashToken(session.ID))
+		if err != nil {
+			return
+		}
+	}
+	session.ID = ""
+	return
+}
+
+// tokenRenewer is implemented by the session stores which keep the session values in the server.
+type tokenRenewer interface {
+	Renew(session *sessions.Session) error
+}
+
+// renewSessionToken makes the next save of the session issue a new cookie token, to avoid session fixation with
+//  the server-side stores. The previous token is deleted, so a cookie of before the sign can't use the new login.
+//  @param store sessions.Store: store of the session.
+//  @param session *sessions.Session: session to renew.
+//  @return err error: failed token deletion.
+func renewSessionToken(store sessions.Store, session *sessions.Session) (err error) {
+	if r, ok := store.(tokenRenewer); ok {
+		return r.Renew(session)
+	}
+	session.ID = ""
+	return
+}
+
+// newSessionStore initializes the session store selected in the config.
+//  @param repo database.SessionTokenRepository: SessionTokenRepository interface for the database store.
+//  @param conf config.ConfigInfo: keeps the session store config.
+//  @return store sessions.Store: selected session store.
+//  @return err error: unsupported session store or missing cookie key error.
+func newSessionStore(repo database.SessionTokenRepository, conf config.ConfigInfo) (store sessions.Store, err error) {
+	switch conf.Session.Store {
+	case DATABASE_SESSION_STORE:
+		maxAge := conf.Session.AbsoluteLifetimeInSecs
+		if maxAge <= 0 {
+			maxAge = 86400 * 30
+		}
+		store = NewDatabaseStore(repo, sessions.Options{
+			Path:     "/",
+			MaxAge:   maxAge,
+			Secure:   conf.Session.SecureCookie,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	case COOKIE_SESSION_STORE, "":
+		key := []byte(conf.Session.CookieKey)
+		if len(key) == 0 {
+			if !conf.Server.Dev {
+				err = fmt.Errorf("invalid session store: the cookie store requires a cookie key outside the dev mode")
+				return
+			}
+			log.Println("No session cookie key provided, a ephemeral one will be used and the sessions will be invalid after restart")
+			var k string
+			k, err = auth.RandomToken("")
+			if err != nil {
+				return
+			}
+			key = []byte(k)
+		}
+		store = sessions.NewCookieStore(key)
+	default:
+		err = fmt.Errorf("invalid session store: %s is not supported", conf.Session.Store)
+	}
+	return
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type sessionTokenRepositoryImpl struct {
+	tokens map[string]auth.SessionToken
+}
+
+// This statement is to check if the sessionTokenRepositoryImpl mock is doing well with the database.SessionTokenRepository interface.
+var _ database.SessionTokenRepository = &sessionTokenRepositoryImpl{}
+
+func (s *sessionTokenRepositoryImpl) GetSessionToken(hash string) (token auth.SessionToken, err error) {
+	token, ok := s.tokens[hash]
+	if !ok || time.Now().After(token.ExpiresAt) {
+		err = sErrors.NewClientError(http.StatusNotFound, "not found: session token expired or invalid")
+	}
+	return
+}
+
+func (s *sessionTokenRepositoryImpl) SaveSessionToken(token auth.SessionToken) (err error) {
+	s.tokens[token.Hash] = token
+	return
+}
+
+func (s *sessionTokenRepositoryImpl) DeleteSessionToken(hash string) (err error) {
+	delete(s.tokens, hash)
+	return
+}
+
+func TestDatabaseStore(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	tokenRepo := &sessionTokenRepositoryImpl{tokens: map[string]auth.SessionToken{}}
+
+	conf := config.ConfigInfo{}
+	conf.Session.Store = DATABASE_SESSION_STORE
+	store, err := newSessionStore(tokenRepo, conf)
+	assert.NoError(t, err)
+
+	ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+	ah.store = store
+
+	s := session
+	s.ID, _ = authRepo.UpsertSession(s)
+
+	t.Run("Given a saved session When reading the cookie Then opaque token with server-side values", func(t *testing.T) {
+		req := newSessionRequest(t, ah, s.ID)
+
+		c, err := req.Cookie(authCookieName)
+		assert.NoError(t, err)
+		assert.Contains(t, tokenRepo.tokens, auth.HashToken(c.Value))
+		assert.Equal(t, s.ID, tokenRepo.tokens[auth.HashToken(c.Value)].SessionID)
+
+		got, err := ah.Session(req)
+		assert.NoError(t, err)
+		assert.Equal(t, s.ID, got.ID)
+	})
+
+	t.Run("Given a deleted token When reading the cookie Then invalid session", func(t *testing.T) {
+		req := newSessionRequest(t, ah, s.ID)
+		c, _ := req.Cookie(authCookieName)
+		delete(tokenRepo.tokens, auth.HashToken(c.Value))
+
+		_, err := ah.Session(req)
+		assert.EqualError(t, err, "invalid credentials: user session expired or invalid")
+	})
+
+	t.Run("Given a session with negative max age When saving Then token deleted", func(t *testing.T) {
+		req := newSessionRequest(t, ah, s.ID)
+		c, _ := req.Cookie(authCookieName)
+
+		sess, err := ah.store.Get(req, authCookieName)
+		assert.NoError(t, err)
+		sess.Options.MaxAge = -1
+
+		rec := httptest.NewRecorder()
+		assert.NoError(t, sess.Save(req, rec))
+		assert.NotContains(t, tokenRepo.tokens, auth.HashToken(c.Value))
+		assert.True(t, strings.Contains(rec.Header().Get("Set-Cookie"), "Max-Age=0"))
+	})
+
+	t.Run("Given a cookie of before the login When logging in Then previous token deleted", func(t *testing.T) {
+		authRepo := newAuthRepositoryImpl()
+		tokenRepo := &sessionTokenRepositoryImpl{tokens: map[string]auth.SessionToken{}}
+		store, err := newSessionStore(tokenRepo, conf)
+		assert.NoError(t, err)
+		ah := newTestAuthHandler(t, &authRepo, &roleRepositoryImpl{roles: map[int][]auth.Role{}})
+		ah.store = store
+		ah.userReaders = map[handlerName]userReader{systemHandlerName: systemUserReader{reader: ah.reader, writer: ah.writer}}
+
+		rec := doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"bob","email":"bob@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		stolen := rec.Result().Cookies()[0]
+		assert.Len(t, tokenRepo.tokens, 1)
+
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/auth/login/system", strings.NewReader(`{"identifier":"bob@host.com","password":"1234"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(stolen)
+		req = mux.SetURLVars(req, map[string]string{"action": "login", "handler": systemHandlerName.string()})
+		ah.HandleAuth(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Len(t, tokenRepo.tokens, 1)
+		assert.NotContains(t, tokenRepo.tokens, auth.HashToken(stolen.Value))
+	})
+
+	t.Run("Given the cookie store without key When creating the store Then error outside the dev mode", func(t *testing.T) {
+		cookieConf := config.ConfigInfo{}
+		cookieConf.Session.Store = COOKIE_SESSION_STORE
+		_, err := newSessionStore(tokenRepo, cookieConf)
+		assert.EqualError(t, err, "invalid session store: the cookie store requires a cookie key outside the dev mode")
+
+		cookieConf.Server.Dev = true
+		_, err = newSessionStore(tokenRepo, cookieConf)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given a unsupported store When creating the store Then error", func(t *testing.T) {
+		conf.Session.Store = "memory"
+		_, err := newSessionStore(tokenRepo, conf)
+		assert.EqualError(t, err, "invalid session store: memory is not supported")
+	})
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/captcha"
+	"github.com/coffemanfp/chat/config"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+)
+
+// velocitySweepSize is the number of tracked addresses which triggers a sweep of the stale ones.
+const velocitySweepSize = 10000
+
+// signUpGuard represents a check of the sign up requests to prevent the abuse of the sign up.
+//  The guards run before the user is built, so a rejected request costs no password hashing nor database access.
+type signUpGuard interface {
+	// check checks the sign up request.
+	//  @param r *http.Request: request of the sign up.
+	//  @param hName handlerName: handler which read the user.
+	//  @param user users.User: user to sign up.
+	//  @return $1 error: rejected sign up error.
+	check(r *http.Request, hName handlerName, user users.User) error
+}
+
+// newSignUpGuards initializes the sign up guard pipeline of the config.
+//  @param conf config.Config: keeps the sign up config. The velocity limits are read on every check, so they can be reloaded.
+//  @param verifier captcha.CaptchaVerifier: CAPTCHA provider, nil to disable the CAPTCHA.
+//  @return guards []signUpGuard: guards in the order they must run.
+//  @return err error: invalid disposable domains file error.
+func newSignUpGuards(conf config.Config, verifier captcha.CaptchaVerifier) (guards []signUpGuard, err error) {
+	if file := conf.Get().SignUp.DisposableDomainsFile; file != "" {
+		var g disposableEmailGuard
+		g, err = newDisposableEmailGuard(file)
+		if err != nil {
+			return
+		}
+		guards = append(guards, g)
+	}
+
+	// The velocity runs before the CAPTCHA, so a flagged client can't make us call the provider.
+	guards = append(guards, newVelocityGuard(conf))
+
+	if verifier != nil {
+		guards = append(guards, captchaGuard{verifier: verifier})
+	}
+	return
+}
+
+// guardSignUp runs the sign up guard pipeline, stopping at the first rejection.
+func (a AuthHandler) guardSignUp(r *http.Request, hName handlerName, user users.User) (err error) {
+	for _, g := range a.signUpGuards {
+		err = g.check(r, hName, user)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// disposableEmailGuard rejects the emails of the disposable email domains, or of their subdomains.
+type disposableEmailGuard struct {
+	domains map[string]bool
+}
+
+// newDisposableEmailGuard loads the disposable domains of the file provided.
+//  The file has a domain by line, the empty lines and the lines starting with # are ignored.
+func newDisposableEmailGuard(path string) (g disposableEmailGuard, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("failed to open disposable domains file: %s", err)
+		return
+	}
+	defer f.Close()
+
+	g.domains = map[string]bool{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.ToLower(strings.TrimSpace(s.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		g.domains[line] = true
+	}
+	err = s.Err()
+	if err != nil {
+		err = fmt.Errorf("failed to read disposable domains file: %s", err)
+	}
+	return
+}
+
+func (d disposableEmailGuard) check(r *http.Request, hName handlerName, user users.User) (err error) {
+	email := signUpEmail(user)
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return
+	}
+
+	domain := strings.ToLower(email[i+1:])
+	for domain != "" {
+		if d.domains[domain] {
+			err = sErrors.NewClientError(http.StatusBadRequest, "invalid email: disposable email addresses are not allowed")
+			return
+		}
+		j := strings.Index(domain, ".")
+		if j < 0 {
+			break
+		}
+		domain = domain[j+1:]
+	}
+	return
+}
+
+// captchaGuard requires a solved CAPTCHA to sign up with the system handler, or with a email code of a unknown email.
+//  The external platforms already verify their users, and their redirects can't carry a CAPTCHA token.
+type captchaGuard struct {
+	verifier captcha.CaptchaVerifier
+}
+
+func (c captchaGuard) check(r *http.Request, hName handlerName, user users.User) (err error) {
+	if hName != systemHandlerName && hName != emailCodeHandlerName {
+		return
+	}
+
+	err = c.verifier.Verify(user.CaptchaToken, handlers.ClientIP(r))
+	if err == captcha.ErrInvalidToken {
+		err = sErrors.NewClientError(http.StatusBadRequest, err.Error())
+	}
+	return
+}
+
+// velocityGuard flags the sign ups over the allowed rate of a IP address or of its subnet.
+//  The flagged sign ups are logged and rejected until the window passes.
+//  The limits are read from the current config on every check, zero disables them.
+type velocityGuard struct {
+	conf config.Config
+
+	m        *sync.Mutex
+	attempts map[string][]time.Time
+}
+
+func newVelocityGuard(conf config.Config) velocityGuard {
+	return velocityGuard{
+		conf:     conf,
+		m:        &sync.Mutex{},
+		attempts: map[string][]time.Time{},
+	}
+}
+
+func (v velocityGuard) check(r *http.Request, hName handlerName, user users.User) (err error) {
+	limits := v.conf.Get().SignUp
+	if limits.VelocityMaxPerIP <= 0 && limits.VelocityMaxPerSubnet <= 0 {
+		return
+	}
+	window := time.Duration(limits.VelocityWindowInSecs) * time.Second
+
+	ip := handlers.ClientIP(r)
+	subnet := auth.IPNetwork(ip)
+	now := time.Now()
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if len(v.attempts) > velocitySweepSize {
+		for k := range v.attempts {
+			v.prune(k, now, window)
+		}
+	}
+
+	ipKey, subnetKey := "ip:"+ip, "net:"+subnet
+	ipN, subnetN := v.prune(ipKey, now, window), v.prune(subnetKey, now, window)
+	if (limits.VelocityMaxPerIP > 0 && ipN >= limits.VelocityMaxPerIP) || (limits.VelocityMaxPerSubnet > 0 && subnetN >= limits.VelocityMaxPerSubnet) {
+		log.Printf("Flagged suspicious sign up velocity of %s %s from %s (%d by ip, %d by subnet %s)", user.Nickname, user.Email, ip, ipN, subnetN, subnet)
+		err = sErrors.NewClientError(http.StatusTooManyRequests, "too many requests: too many sign ups from your network, try again later")
+		return
+	}
+
+	v.attempts[ipKey] = append(v.attempts[ipKey], now)
+	v.attempts[subnetKey] = append(v.attempts[subnetKey], now)
+	return
+}
+
+// prune removes the attempts of the key out of the window, returning the remaining ones.
+func (v velocityGuard) prune(key string, now time.Time, window time.Duration) int {
+	attempts := v.attempts[key]
+	i := 0
+	for i < len(attempts) && now.Sub(attempts[i]) >= window {
+		i++
+	}
+	if i == len(attempts) {
+		delete(v.attempts, key)
+		return 0
+	}
+	v.attempts[key] = attempts[i:]
+	return len(attempts) - i
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coffemanfp/chat/captcha"
+	"github.com/coffemanfp/chat/config"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// configImpl is a config which can be changed while it's used, as a reloaded one.
+type configImpl struct {
+	conf config.ConfigInfo
+}
+
+func (c *configImpl) Get() config.ConfigInfo {
+	return c.conf
+}
+
+// doSignUpRequest performs a system sign up request from the ip provided with the JSON body.
+func doSignUpRequest(t *testing.T, ah AuthHandler, ip, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/auth/signup/system", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":51000"
+	req = mux.SetURLVars(req, map[string]string{"action": "signup", "handler": systemHandlerName.string()})
+	ah.HandleAuth(rec, req)
+	return rec
+}
+
+func newTestGuardedAuthHandler(t *testing.T, conf config.ConfigInfo, verifier captcha.CaptchaVerifier) (ah AuthHandler, authRepo *authRepositoryImpl) {
+	t.Helper()
+
+	ah, authRepo = newTestSignAuthHandler(t, conf, systemHandlerName, func(ah AuthHandler) userReader {
+		return systemUserReader{reader: ah.reader, writer: ah.writer}
+	})
+	setTestSignUpGuards(t, &ah, conf, verifier)
+	return
+}
+
+// setTestSignUpGuards sets the sign up guards of the config and verifier provided to the AuthHandler.
+func setTestSignUpGuards(t *testing.T, ah *AuthHandler, conf config.ConfigInfo, verifier captcha.CaptchaVerifier) {
+	t.Helper()
+
+	guards, err := newSignUpGuards(config.Static(conf), verifier)
+	assert.NoError(t, err)
+	ah.signUpGuards = guards
+}
+
+func TestSignUpGuards(t *testing.T) {
+	t.Run("Given a disposable email When signing up Then error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "disposable.txt")
+		assert.NoError(t, os.WriteFile(path, []byte("# disposable domains\nmailinator.com\n\n"), 0600))
+
+		conf := config.ConfigInfo{}
+		conf.SignUp.DisposableDomainsFile = path
+		ah, authRepo := newTestGuardedAuthHandler(t, conf, nil)
+
+		rec := doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"bot","email":"bot@eu.Mailinator.com","password":"1234"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, authRepo.users)
+
+		rec = doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"human","email":"human@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, authRepo.users, 1)
+	})
+
+	t.Run("Given a missing disposable domains file When initializing the guards Then error", func(t *testing.T) {
+		conf := config.ConfigInfo{}
+		conf.SignUp.DisposableDomainsFile = filepath.Join(t.TempDir(), "missing.txt")
+
+		_, err := newSignUpGuards(config.Static(conf), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Given a captcha verifier When signing up Then only solved captchas registered", func(t *testing.T) {
+		ah, authRepo := newTestGuardedAuthHandler(t, config.ConfigInfo{}, captcha.FakeVerifier{Token: "solved"})
+
+		rec := doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"bot","email":"bot@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"bot","email":"bot@host.com","password":"1234","captcha_token":"wrong"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, authRepo.users)
+
+		rec = doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"human","email":"human@host.com","password":"1234","captcha_token":"solved"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, authRepo.users, 1)
+	})
+
+	t.Run("Given many sign ups from a network When signing up Then flagged and rejected", func(t *testing.T) {
+		conf := config.ConfigInfo{}
+		conf.SignUp.VelocityWindowInSecs = 3600
+		conf.SignUp.VelocityMaxPerIP = 2
+		conf.SignUp.VelocityMaxPerSubnet = 3
+		ah, _ := newTestGuardedAuthHandler(t, conf, nil)
+
+		for _, nickname := range []string{"first", "second"} {
+			rec := doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"`+nickname+`","email":"`+nickname+`@host.com","password":"1234"}`)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		rec := doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"third","email":"third@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+		rec = doSignUpRequest(t, ah, "203.0.113.2", `{"nickname":"fourth","email":"fourth@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = doSignUpRequest(t, ah, "203.0.113.3", `{"nickname":"fifth","email":"fifth@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+		rec = doSignUpRequest(t, ah, "198.51.100.1", `{"nickname":"sixth","email":"sixth@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("Given reloaded velocity limits When signing up Then the new limits used", func(t *testing.T) {
+		live := &configImpl{}
+		ah, _ := newTestGuardedAuthHandler(t, live.conf, nil)
+		guards, err := newSignUpGuards(live, nil)
+		assert.NoError(t, err)
+		ah.signUpGuards = guards
+
+		rec := doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"first","email":"first@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		live.conf.SignUp.VelocityWindowInSecs = 3600
+		live.conf.SignUp.VelocityMaxPerIP = 1
+
+		rec = doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"second","email":"second@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = doSignUpRequest(t, ah, "203.0.113.1", `{"nickname":"third","email":"third@host.com","password":"1234"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	})
+}
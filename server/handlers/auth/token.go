@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/gorilla/mux"
+)
+
+// CreateAccessToken creates a new personal access token for the authenticated user.
+//  The token is only present in this response, just its hash is stored.
+func (a AuthHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	principal, err := a.requireSessionPrincipal(r, "manage access tokens")
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	body := struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}{}
+	err = a.reader.JSON(r, &body)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid token name: empty value"))
+		return
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid token expiration: %s is in the past", body.ExpiresAt))
+		return
+	}
+
+	scopes := make([]auth.Permission, len(body.Scopes))
+	for i, s := range body.Scopes {
+		scopes[i] = auth.Permission(s)
+		if !principal.HasPermission(scopes[i]) {
+			a.handleError(w, sErrors.NewClientError(http.StatusForbidden, "forbidden: missing %s permission for the token scope", s))
+			return
+		}
+	}
+
+	token, raw, err := auth.NewAccessToken(principal.UserID, body.Name, scopes, body.ExpiresAt)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	token.ID, err = a.tokens.SaveAccessToken(token)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	a.writer.JSON(w, http.StatusCreated, handlers.Hash{
+		"token":        raw,
+		"access_token": token,
+	})
+}
+
+// GetAccessTokens lists the personal access tokens of the authenticated user.
+func (a AuthHandler) GetAccessTokens(w http.ResponseWriter, r *http.Request) {
+	principal, err := a.requireSessionPrincipal(r, "manage access tokens")
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	tokens, err := a.tokens.GetAccessTokens(principal.UserID)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+	if tokens == nil {
+		tokens = []auth.AccessToken{}
+	}
+
+	a.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"access_tokens": tokens,
+	})
+}
+
+// RevokeAccessToken revokes a personal access token of the authenticated user.
+func (a AuthHandler) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	principal, err := a.requireSessionPrincipal(r, "manage access tokens")
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	rawID := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		a.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid token id: %s is not a valid id", rawID))
+		return
+	}
+
+	err = a.tokens.RevokeAccessToken(principal.UserID, id)
+	if err != nil {
+		a.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireSessionPrincipal gets the authenticated principal, rejecting the ones authenticated
+//  with a personal access token or with a impersonation session.
+//  Tokens can't be used to manage other tokens, nor to read the account security data.
+//  @param r *http.Request: request of the call.
+//  @param action string: action performed by the call, for the error message.
+//  @return principal auth.Principal: authenticated principal.
+//  @return err error: missing principal, or access token or impersonation principal error.
+func (a AuthHandler) requireSessionPrincipal(r *http.Request, action string) (principal auth.Principal, err error) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		return
+	}
+	if principal.TokenID != 0 {
+		err = sErrors.NewClientError(http.StatusForbidden, "forbidden: access tokens can't %s", action)
+		return
+	}
+	if principal.Impersonated() {
+		err = sErrors.NewClientError(http.StatusForbidden, "forbidden: impersonation sessions can't %s", action)
+	}
+	return
+}
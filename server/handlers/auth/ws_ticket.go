@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+)
+
+// errImpersonatedWSTicket is the error of the tickets of the impersonation sessions.
+var errImpersonatedWSTicket = sErrors.NewClientError(http.StatusForbidden, "forbidden: impersonation sessions can't open chat connections")
+
+// WSTicketHandler handles the single-use tickets which authenticate the chat WebSocket upgrades.
+type WSTicketHandler struct {
+	repository database.WSTicketRepository
+	auth       database.AuthRepository
+	config     config.ConfigInfo
+	reader     handlers.RequestReader
+	writer     handlers.ResponseWriter
+}
+
+// NewWSTicketHandler initializes a new WSTicketHandler instance.
+//  @param repo database.WSTicketRepository: WSTicketRepository interface for the tickets handling.
+//  @param authRepo database.AuthRepository: AuthRepository interface for the sessions handling.
+//  @param conf config.ConfigInfo: keeps the session lifetimes.
+//  @param r handlers.RequestReader: RequestReader interface for reading request operations.
+//  @param w handlers.ResponseWriter: ResponseWriter interface for writing request response operations.
+//  @return h WSTicketHandler: new WSTicketHandler instance.
+func NewWSTicketHandler(repo database.WSTicketRepository, authRepo database.AuthRepository, conf config.ConfigInfo, r handlers.RequestReader, w handlers.ResponseWriter) (h WSTicketHandler) {
+	return WSTicketHandler{
+		repository: repo,
+		auth:       authRepo,
+		config:     conf,
+		reader:     r,
+		writer:     w,
+	}
+}
+
+// CreateTicket issues a new ticket bound to the session and origin of the request.
+//  It must be called behind the Authenticate middleware. The impersonation sessions can't get tickets, as the chat
+//  socket server has no way to restrict their actions.
+func (h WSTicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok || principal.SessionID == 0 {
+		handleError(h.writer, w, sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid"))
+		return
+	}
+	if principal.Impersonated() {
+		handleError(h.writer, w, errImpersonatedWSTicket)
+		return
+	}
+
+	ticket, raw, err := auth.NewWSTicket(auth.Session{ID: principal.SessionID, UserID: principal.UserID}, r.Header.Get("Origin"))
+	if err != nil {
+		handleError(h.writer, w, err)
+		return
+	}
+
+	err = h.repository.SaveWSTicket(ticket)
+	if err != nil {
+		handleError(h.writer, w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.writer.JSON(w, http.StatusCreated, handlers.Hash{
+		"ticket":     raw,
+		"expires_at": ticket.ExpiresAt,
+	})
+}
+
+// VerifyTicket exchanges a ticket for the user and session identity.
+//  It is called by the chat socket server during the WebSocket upgrade.
+func (h WSTicketHandler) VerifyTicket(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Ticket string `json:"ticket"`
+		Origin string `json:"origin"`
+	}{}
+	err := h.reader.JSON(r, &body)
+	if err != nil {
+		handleError(h.writer, w, sErrors.NewClientError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	ticket, err := VerifyWSTicket(h.repository, h.auth, h.config, body.Ticket, body.Origin)
+	if err != nil {
+		handleError(h.writer, w, err)
+		return
+	}
+
+	h.writer.JSON(w, http.StatusOK, ticket)
+}
+
+// VerifyWSTicket consumes the ticket provided and checks it is still valid for the origin of the upgrade request.
+//  @param repo database.WSTicketRepository: WSTicketRepository interface for the tickets handling.
+//  @param authRepo database.AuthRepository: AuthRepository interface to check the ticket session.
+//  @param conf config.ConfigInfo: keeps the session lifetimes.
+//  @param raw string: ticket sent by the client.
+//  @param origin string: Origin header of the WebSocket upgrade request.
+//  @return ticket auth.WSTicket: verified ticket with the user and session identity.
+//  @return err error: invalid, used, expired ticket, inactive or expired session or impersonation session error.
+func VerifyWSTicket(repo database.WSTicketRepository, authRepo database.AuthRepository, conf config.ConfigInfo, raw, origin string) (ticket auth.WSTicket, err error) {
+	ticket, err = repo.ConsumeWSTicket(auth.HashToken(raw))
+	if err != nil {
+		return
+	}
+
+	if time.Now().After(ticket.ExpiresAt) || ticket.Origin != origin {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid ticket: ticket expired, used or invalid")
+		ticket = auth.WSTicket{}
+		return
+	}
+
+	session, err := authRepo.GetSession(ticket.SessionID)
+	if err != nil {
+		ticket = auth.WSTicket{}
+		return
+	}
+	// The sessions over their lifetime which aren't reaped yet are still active.
+	if !session.Actived || session.Expired(time.Now(), LifetimeOf(conf, session)) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		ticket = auth.WSTicket{}
+		return
+	}
+	// The tickets issued before the impersonation sessions were rejected are refused too.
+	if session.Impersonated() {
+		err = errImpersonatedWSTicket
+		ticket = auth.WSTicket{}
+	}
+	return
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+type wsTicketRepositoryImpl struct {
+	m       sync.Mutex
+	tickets map[string]auth.WSTicket
+}
+
+// This statement is to check if the wsTicketRepositoryImpl mock is doing well with the database.WSTicketRepository interface.
+var _ database.WSTicketRepository = &wsTicketRepositoryImpl{}
+
+func (w *wsTicketRepositoryImpl) SaveWSTicket(ticket auth.WSTicket) (err error) {
+	w.m.Lock()
+	w.tickets[ticket.Hash] = ticket
+	w.m.Unlock()
+	return
+}
+
+func (w *wsTicketRepositoryImpl) ConsumeWSTicket(hash string) (ticket auth.WSTicket, err error) {
+	w.m.Lock()
+	ticket, ok := w.tickets[hash]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid ticket: ticket expired, used or invalid")
+	}
+	delete(w.tickets, hash)
+	w.m.Unlock()
+	return
+}
+
+func TestVerifyWSTicket(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ticketRepo := &wsTicketRepositoryImpl{tickets: map[string]auth.WSTicket{}}
+	conf := newLifetimeConfig()
+
+	sessionExp := session
+	sessionExp.UserID = 1
+	sessionExp.ID, _ = authRepo.UpsertSession(sessionExp)
+
+	origin := "https://chat.example"
+	newTicket := func(t *testing.T) string {
+		t.Helper()
+
+		ticket, raw, err := auth.NewWSTicket(sessionExp, origin)
+		assert.NoError(t, err)
+		assert.NoError(t, ticketRepo.SaveWSTicket(ticket))
+		return raw
+	}
+
+	t.Run("Given a valid ticket When verifying it Then user and session identity", func(t *testing.T) {
+		raw := newTicket(t)
+
+		ticket, err := VerifyWSTicket(ticketRepo, &authRepo, conf, raw, origin)
+		assert.NoError(t, err)
+		assert.Equal(t, sessionExp.UserID, ticket.UserID)
+		assert.Equal(t, sessionExp.ID, ticket.SessionID)
+	})
+	t.Run("Given a used ticket When verifying it again Then invalid ticket error", func(t *testing.T) {
+		raw := newTicket(t)
+
+		_, err := VerifyWSTicket(ticketRepo, &authRepo, conf, raw, origin)
+		assert.NoError(t, err)
+		_, err = VerifyWSTicket(ticketRepo, &authRepo, conf, raw, origin)
+		assert.EqualError(t, err, "invalid ticket: ticket expired, used or invalid")
+	})
+	t.Run("Given a ticket of another origin When verifying it Then invalid ticket error", func(t *testing.T) {
+		raw := newTicket(t)
+
+		_, err := VerifyWSTicket(ticketRepo, &authRepo, conf, raw, "https://evil.example")
+		assert.EqualError(t, err, "invalid ticket: ticket expired, used or invalid")
+	})
+	t.Run("Given a expired ticket When verifying it Then invalid ticket error", func(t *testing.T) {
+		ticket, raw, err := auth.NewWSTicket(sessionExp, origin)
+		assert.NoError(t, err)
+		ticket.ExpiresAt = time.Now().Add(-time.Second)
+		assert.NoError(t, ticketRepo.SaveWSTicket(ticket))
+
+		_, err = VerifyWSTicket(ticketRepo, &authRepo, conf, raw, origin)
+		assert.EqualError(t, err, "invalid ticket: ticket expired, used or invalid")
+	})
+	t.Run("Given a ticket of a session over its lifetime When verifying it Then invalid session error", func(t *testing.T) {
+		raw := newTicket(t)
+		s := authRepo.session[sessionExp.ID]
+		s.LoggedAt = time.Now().Add(-48 * time.Hour)
+		authRepo.session[sessionExp.ID] = s
+
+		_, err := VerifyWSTicket(ticketRepo, &authRepo, conf, raw, origin)
+		assert.EqualError(t, err, "invalid credentials: user session expired or invalid")
+	})
+	t.Run("Given a ticket of a impersonation session When verifying it Then forbidden error", func(t *testing.T) {
+		impersonated, err := auth.NewImpersonationSession(sessionExp.UserID, 2)
+		assert.NoError(t, err)
+		impersonated.ID, _ = authRepo.UpsertSession(impersonated)
+		ticket, raw, err := auth.NewWSTicket(impersonated, origin)
+		assert.NoError(t, err)
+		assert.NoError(t, ticketRepo.SaveWSTicket(ticket))
+
+		_, err = VerifyWSTicket(ticketRepo, &authRepo, conf, raw, origin)
+		assert.EqualError(t, err, "forbidden: impersonation sessions can't open chat connections")
+	})
+}
+
+func TestCreateWSTicket(t *testing.T) {
+	authRepo := newAuthRepositoryImpl()
+	ticketRepo := &wsTicketRepositoryImpl{tickets: map[string]auth.WSTicket{}}
+	h := NewWSTicketHandler(ticketRepo, &authRepo, newLifetimeConfig(), handlers.GetRequestReaderImpl(), handlers.GetResponseWriterImpl())
+
+	createTicket := func(principal auth.Principal) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, principal))
+		h.CreateTicket(rec, req)
+		return rec
+	}
+
+	t.Run("Given a session principal When creating a ticket Then ticket", func(t *testing.T) {
+		rec := createTicket(auth.Principal{UserID: 1, SessionID: 1})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Len(t, ticketRepo.tickets, 1)
+	})
+	t.Run("Given a impersonation principal When creating a ticket Then forbidden", func(t *testing.T) {
+		rec := createTicket(auth.Principal{UserID: 1, SessionID: 2, ImpersonatorID: 2})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Len(t, ticketRepo.tickets, 1)
+	})
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP gets the ip address of the client which performs the request.
+// 	@param r *http.Request: Request to read.
+//	@return $1 string: ip address of the client.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,39 @@
+package oauth
+
+import (
+	"net/http"
+
+	"github.com/coffemanfp/chat/server/handlers"
+)
+
+// Discovery implements the OpenID Connect discovery document.
+func (p Provider) Discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := p.config.OIDC.Issuer
+	p.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"issuer":                                        issuer,
+		"authorization_endpoint":                        issuer + "/oauth/authorize",
+		"token_endpoint":                                issuer + "/oauth/token",
+		"userinfo_endpoint":                             issuer + "/oauth/userinfo",
+		"jwks_uri":                                      issuer + "/.well-known/jwks.json",
+		"introspection_endpoint":                        issuer + "/oauth/introspect",
+		"revocation_endpoint":                           issuer + "/oauth/revoke",
+		"scopes_supported":                              supportedScopes,
+		"response_types_supported":                      []string{"code"},
+		"grant_types_supported":                         []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":                       []string{"public"},
+		"id_token_signing_alg_values_supported":         p.signer.Algorithms(),
+		"token_endpoint_auth_methods_supported":         []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":              []string{"S256"},
+		"introspection_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"revocation_endpoint_auth_methods_supported":    []string{"client_secret_basic", "client_secret_post"},
+		"claims_supported":                              []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "sid", "preferred_username", "nickname", "picture", "email"},
+	})
+}
+
+// JWKS publishes the public keys used to verify the ID tokens.
+func (p Provider) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	p.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"keys": p.signer.JWKS(),
+	})
+}
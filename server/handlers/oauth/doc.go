@@ -0,0 +1,4 @@
+// Package oauth implements the OAuth 2.0 and OpenID Connect authorization server,
+// used by the other chat applications to sign their users with this service.
+
+package oauth
@@ -0,0 +1,121 @@
+package oauth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+)
+
+// Introspect implements the RFC 7662 token introspection endpoint for the resource servers.
+//  A token is active only while neither it or the session which granted it have been revoked or expired.
+func (p Provider) Introspect(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	_, err = p.authenticateConfidentialClient(r)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	token, err := p.repository.GetToken(auth.HashToken(r.PostForm.Get("token")))
+	if err == nil && token.Valid(time.Now()) {
+		err = p.checkSession(token.SessionID)
+	} else if err == nil {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_token: token expired or invalid")
+	}
+	if err != nil {
+		if _, ok := err.(sErrors.ClientError); !ok {
+			p.handleError(w, err)
+			return
+		}
+		p.writer.JSON(w, http.StatusOK, handlers.Hash{
+			"active": false,
+		})
+		return
+	}
+
+	tokenType := "Bearer"
+	if token.Type == auth.OAUTH_REFRESH_TOKEN {
+		tokenType = auth.OAUTH_REFRESH_TOKEN
+	}
+
+	p.writer.JSON(w, http.StatusOK, handlers.Hash{
+		"active":     true,
+		"scope":      strings.Join(token.Scopes, " "),
+		"client_id":  token.ClientID,
+		"sub":        strconv.Itoa(token.UserID),
+		"exp":        token.ExpiresAt.Unix(),
+		"iat":        token.CreatedAt.Unix(),
+		"iss":        p.config.OIDC.Issuer,
+		"token_type": tokenType,
+		"sid":        strconv.Itoa(token.SessionID),
+	})
+}
+
+// Revoke implements the RFC 7009 token revocation endpoint.
+//  Revoking a refresh token revokes the access tokens of the same client and session too.
+//  Unknown tokens or tokens of other clients are ignored, as the RFC requires.
+func (p Provider) Revoke(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	client, err := p.authenticateConfidentialClient(r)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	token, err := p.repository.GetToken(auth.HashToken(r.PostForm.Get("token")))
+	if err != nil {
+		if _, ok := err.(sErrors.ClientError); !ok {
+			p.handleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if token.ClientID != client.ID {
+		log.Printf("Client %s tried to revoke a token of client %s", client.ID, token.ClientID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if token.Type == auth.OAUTH_REFRESH_TOKEN {
+		err = p.repository.RevokeClientSessionTokens(token.ClientID, token.SessionID)
+	} else {
+		err = p.repository.RevokeToken(token.ID)
+	}
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticateConfidentialClient authenticates the client of the request, rejecting the public clients.
+func (p Provider) authenticateConfidentialClient(r *http.Request) (client auth.OAuthClient, err error) {
+	client, err = p.authenticateClient(r)
+	if err != nil {
+		return
+	}
+	if client.Public() {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_client: client credentials are required")
+	}
+	return
+}
@@ -0,0 +1,498 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/keys"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type oauthRepositoryImpl struct {
+	m           sync.Mutex
+	tokenSerial int
+	clients     map[string]auth.OAuthClient
+	codes       map[string]auth.AuthorizationCode
+	usedCodes   map[string]bool
+	tokens      map[string]auth.OAuthToken
+}
+
+// This statement is to check if the oauthRepositoryImpl mock is doing well with the database.OAuthRepository interface.
+var _ database.OAuthRepository = &oauthRepositoryImpl{}
+
+func newOAuthRepositoryImpl() *oauthRepositoryImpl {
+	return &oauthRepositoryImpl{
+		clients:   map[string]auth.OAuthClient{},
+		codes:     map[string]auth.AuthorizationCode{},
+		usedCodes: map[string]bool{},
+		tokens:    map[string]auth.OAuthToken{},
+	}
+}
+
+func (o *oauthRepositoryImpl) SaveClient(client auth.OAuthClient) (err error) {
+	o.m.Lock()
+	o.clients[client.ID] = client
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) GetClient(id string) (client auth.OAuthClient, err error) {
+	o.m.Lock()
+	client, ok := o.clients[id]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_client: client %s don't exists", id)
+	}
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) SaveAuthorizationCode(code auth.AuthorizationCode) (err error) {
+	o.m.Lock()
+	o.codes[code.Hash] = code
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) ConsumeAuthorizationCode(hash string) (code auth.AuthorizationCode, err error) {
+	o.m.Lock()
+	code, ok := o.codes[hash]
+	if !ok || o.usedCodes[hash] {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: authorization code expired, used or invalid")
+	}
+	o.usedCodes[hash] = true
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) SaveToken(token auth.OAuthToken) (id int, err error) {
+	o.m.Lock()
+	o.tokenSerial++
+	token.ID = o.tokenSerial
+	o.tokens[token.Hash] = token
+	id = token.ID
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) GetToken(hash string) (token auth.OAuthToken, err error) {
+	o.m.Lock()
+	token, ok := o.tokens[hash]
+	if !ok {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_token: token expired or invalid")
+	}
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) ConsumeToken(id int) (err error) {
+	o.m.Lock()
+	err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: refresh token expired or invalid")
+	for h, t := range o.tokens {
+		if t.ID == id && t.RevokedAt == nil {
+			now := time.Now()
+			t.RevokedAt = &now
+			o.tokens[h] = t
+			err = nil
+		}
+	}
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) RevokeToken(id int) (err error) {
+	o.m.Lock()
+	for h, t := range o.tokens {
+		if t.ID == id {
+			now := time.Now()
+			t.RevokedAt = &now
+			o.tokens[h] = t
+		}
+	}
+	o.m.Unlock()
+	return
+}
+
+func (o *oauthRepositoryImpl) RevokeClientSessionTokens(clientID string, sessionID int) (err error) {
+	o.m.Lock()
+	for h, t := range o.tokens {
+		if t.ClientID == clientID && t.SessionID == sessionID {
+			now := time.Now()
+			t.RevokedAt = &now
+			o.tokens[h] = t
+		}
+	}
+	o.m.Unlock()
+	return
+}
+
+// sessionsImpl implements both the sessionReader and the session methods of the database.AuthRepository.
+type sessionsImpl struct {
+	database.AuthRepository
+	current  *auth.Session
+	sessions map[int]auth.Session
+}
+
+func (s sessionsImpl) Session(r *http.Request) (session auth.Session, err error) {
+	if s.current == nil {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid credentials: user session expired or invalid")
+		return
+	}
+	session = *s.current
+	return
+}
+
+func (s sessionsImpl) GetSession(id int) (session auth.Session, err error) {
+	session, ok := s.sessions[id]
+	if !ok {
+		err = errors.New("not found: session don't exists")
+	}
+	return
+}
+
+type usersRepositoryImpl struct{}
+
+func (u usersRepositoryImpl) GetUser(id int) (user users.User, err error) {
+	user = users.User{ID: id, Nickname: "example", Email: "example@host.com"}
+	return
+}
+
+func (u usersRepositoryImpl) GetUserByEmail(email string) (user users.User, err error) {
+	user = users.User{ID: 1, Nickname: "example", Email: email}
+	return
+}
+
+func (u usersRepositoryImpl) UpdateNickname(id int, nickname, skeleton string) (err error) {
+	return
+}
+
+func (u usersRepositoryImpl) UpdateEmail(id int, email string) (err error) {
+	return
+}
+
+const (
+	redirectURI  = "https://app.example/callback"
+	codeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXkdBjftJeZ4CVP"
+)
+
+func newTestProvider(t *testing.T) (p Provider, repo *oauthRepositoryImpl, sessions *sessionsImpl, client auth.OAuthClient, secret string) {
+	t.Helper()
+
+	s, err := keys.NewManager(keys.NewMemoryStore(), keys.Schedule{
+		Algorithm: keys.RS256,
+		ActiveFor: time.Hour,
+		Retention: time.Hour,
+	})
+	assert.NoError(t, err)
+
+	session := auth.Session{ID: 7, UserID: 3, LoggedAt: time.Now(), Actived: true}
+	sessions = &sessionsImpl{current: &session, sessions: map[int]auth.Session{session.ID: session}}
+	repo = newOAuthRepositoryImpl()
+
+	client, secret, err = auth.NewOAuthClient("chat", []string{redirectURI}, supportedScopes, true)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.SaveClient(client))
+
+	conf := config.ConfigInfo{}
+	conf.OIDC.Issuer = "https://auth.example"
+	conf.OIDC.AuthorizationCodeTTLInSecs = 60
+	conf.OIDC.AccessTokenTTLInSecs = 60
+	conf.OIDC.RefreshTokenTTLInSecs = 60
+	conf.OIDC.IDTokenTTLInSecs = 60
+
+	p = Provider{
+		config:     conf,
+		repository: repo,
+		auth:       sessions,
+		users:      usersRepositoryImpl{},
+		sessions:   sessions,
+		reader:     handlers.GetRequestReaderImpl(),
+		writer:     handlers.GetResponseWriterImpl(),
+		signer:     s,
+	}
+	return
+}
+
+func authorize(t *testing.T, p Provider, client auth.OAuthClient) (code string) {
+	t.Helper()
+
+	h := sha256.Sum256([]byte(codeVerifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid profile email"},
+		"state":                 {"xyz"},
+		"nonce":                 {"n-0S6_WzA2Mj"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(h[:])},
+		"code_challenge_method": {"S256"},
+	}
+	rec := httptest.NewRecorder()
+	p.Authorize(rec, httptest.NewRequest("GET", "/oauth/authorize?"+q.Encode(), nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz", loc.Query().Get("state"))
+	return loc.Query().Get("code")
+}
+
+func token(t *testing.T, p Provider, client auth.OAuthClient, secret string, form url.Values) (rec *httptest.ResponseRecorder, res map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client.ID, secret)
+
+	rec = httptest.NewRecorder()
+	p.Token(rec, req)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	return
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	p, _, _, client, secret := newTestProvider(t)
+
+	t.Run("Given a valid authorization code and verifier When exchanging the code Then tokens issued", func(t *testing.T) {
+		code := authorize(t, p, client)
+		assert.NotEmpty(t, code)
+
+		rec, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, res["access_token"])
+		assert.NotEmpty(t, res["refresh_token"])
+
+		idToken, err := jwt.Parse(res["id_token"].(string), func(tk *jwt.Token) (interface{}, error) {
+			key, _ := p.signer.(*keys.Manager).PublicKey(tk.Header["kid"].(string))
+			return key.PrivateKey.Public(), nil
+		})
+		assert.NoError(t, err)
+		claims := idToken.Claims.(jwt.MapClaims)
+		assert.Equal(t, "3", claims["sub"])
+		assert.Equal(t, client.ID, claims["aud"])
+		assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+		current, _ := p.signer.(*keys.Manager).Current()
+		assert.Equal(t, current.ID, idToken.Header["kid"])
+
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/oauth/userinfo", nil)
+		req.Header.Set("Authorization", "Bearer "+res["access_token"].(string))
+		p.UserInfo(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "example@host.com")
+	})
+	t.Run("Given a invalid code verifier When exchanging the code Then invalid grant error", func(t *testing.T) {
+		code := authorize(t, p, client)
+
+		rec, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {strings.Repeat("a", 43)},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid_grant", res["error"])
+	})
+	t.Run("Given a used refresh token When refreshing Then invalid grant error", func(t *testing.T) {
+		code := authorize(t, p, client)
+		_, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+		refreshToken := res["refresh_token"].(string)
+
+		rec, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, refreshToken, res["refresh_token"])
+
+		rec, res = token(t, p, client, secret, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid_grant", res["error"])
+	})
+	t.Run("Given concurrent replays of a refresh token When refreshing Then only one succeeds", func(t *testing.T) {
+		code := authorize(t, p, client)
+		_, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+		refreshToken := res["refresh_token"].(string)
+
+		var wg sync.WaitGroup
+		codes := make([]int, 8)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				rec, _ := token(t, p, client, secret, url.Values{
+					"grant_type":    {"refresh_token"},
+					"refresh_token": {refreshToken},
+				})
+				codes[i] = rec.Code
+			}(i)
+		}
+		wg.Wait()
+
+		var ok int
+		for _, c := range codes {
+			if c == http.StatusOK {
+				ok++
+			}
+		}
+		assert.Equal(t, 1, ok)
+	})
+	t.Run("Given a invalid client secret When exchanging the code Then invalid client error", func(t *testing.T) {
+		code := authorize(t, p, client)
+
+		rec, res := token(t, p, client, "invalid", url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "invalid_client", res["error"])
+	})
+}
+
+func TestAuthorizeWithoutSession(t *testing.T) {
+	p, _, sessions, client, _ := newTestProvider(t)
+	sessions.current = nil
+	p.config.OIDC.LoginURL = "https://chat.example/login"
+
+	t.Run("Given a user without session When authorizing Then redirected to login", func(t *testing.T) {
+		q := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {client.ID},
+			"code_challenge":        {"challenge"},
+			"code_challenge_method": {"S256"},
+		}
+		rec := httptest.NewRecorder()
+		p.Authorize(rec, httptest.NewRequest("GET", "/oauth/authorize?"+q.Encode(), nil))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Location"), p.config.OIDC.LoginURL+"?return_to="))
+	})
+}
+
+func TestIntrospectAndRevoke(t *testing.T) {
+	p, _, sessions, client, secret := newTestProvider(t)
+
+	code := authorize(t, p, client)
+	_, res := token(t, p, client, secret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	})
+	accessToken := res["access_token"].(string)
+	refreshToken := res["refresh_token"].(string)
+
+	call := func(t *testing.T, h http.HandlerFunc, secret, token string) (rec *httptest.ResponseRecorder) {
+		t.Helper()
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(client.ID, secret)
+
+		rec = httptest.NewRecorder()
+		h(rec, req)
+		return
+	}
+	introspect := func(t *testing.T, token string) (res map[string]interface{}) {
+		t.Helper()
+
+		rec := call(t, p.Introspect, secret, token)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+		return
+	}
+
+	t.Run("Given a active access token When introspecting Then active token info", func(t *testing.T) {
+		res := introspect(t, accessToken)
+		assert.Equal(t, true, res["active"])
+		assert.Equal(t, "3", res["sub"])
+		assert.Equal(t, "7", res["sid"])
+		assert.Equal(t, client.ID, res["client_id"])
+		assert.Equal(t, "openid profile email", res["scope"])
+	})
+	t.Run("Given invalid client credentials When introspecting Then invalid client error", func(t *testing.T) {
+		rec := call(t, p.Introspect, "invalid", accessToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	t.Run("Given a unknown token When introspecting Then inactive token", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{"active": false}, introspect(t, "unknown"))
+	})
+	t.Run("Given a revoked refresh token When introspecting its access token Then inactive token", func(t *testing.T) {
+		rec := call(t, p.Revoke, secret, refreshToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, false, introspect(t, refreshToken)["active"])
+		assert.Equal(t, false, introspect(t, accessToken)["active"])
+	})
+	t.Run("Given a revoked session When introspecting its tokens Then inactive token", func(t *testing.T) {
+		code := authorize(t, p, client)
+		_, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+		accessToken := res["access_token"].(string)
+		assert.Equal(t, true, introspect(t, accessToken)["active"])
+
+		session := sessions.sessions[7]
+		session.Actived = false
+		sessions.sessions[7] = session
+
+		assert.Equal(t, false, introspect(t, accessToken)["active"])
+	})
+	t.Run("Given a session over its lifetime When introspecting its tokens Then inactive token", func(t *testing.T) {
+		session := auth.Session{ID: 7, UserID: 3, LoggedAt: time.Now(), Actived: true}
+		sessions.sessions[7] = session
+		code := authorize(t, p, client)
+		_, res := token(t, p, client, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+		accessToken := res["access_token"].(string)
+		assert.Equal(t, true, introspect(t, accessToken)["active"])
+
+		p.config.Session.AbsoluteLifetimeInSecs = 60
+		session.LoggedAt = time.Now().Add(-time.Hour)
+		sessions.sessions[7] = session
+
+		assert.Equal(t, false, introspect(t, accessToken)["active"])
+	})
+}
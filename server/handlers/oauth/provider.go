@@ -0,0 +1,330 @@
+package oauth
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/config"
+	"github.com/coffemanfp/chat/database"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/keys"
+	"github.com/coffemanfp/chat/server/handlers"
+	authHandlers "github.com/coffemanfp/chat/server/handlers/auth"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// supportedScopes are the OpenID Connect scopes known by the provider.
+var supportedScopes = []string{"openid", "profile", "email", "offline_access"}
+
+// sessionReader represents a service which reads the logged user session of a request.
+type sessionReader interface {
+	// Session gets the active session of the request.
+	//  @param r *http.Request: request to read.
+	//	@return $1 auth.Session: active session of the request.
+	//	@return $2 error: missing, invalid or inactive session error.
+	Session(r *http.Request) (auth.Session, error)
+}
+
+// tokenSigner represents a service which signs the ID tokens and publishes its public keys.
+type tokenSigner interface {
+	// Sign signs the claims provided as a JWT.
+	//  @param claims jwt.Claims: claims of the token.
+	//	@return $1 string: signed token.
+	//	@return $2 error: signing error.
+	Sign(claims jwt.Claims) (string, error)
+
+	// JWKS gets the public keys to verify the signed tokens.
+	JWKS() []keys.JWK
+
+	// Algorithms gets the signing algorithms of the public keys.
+	Algorithms() []string
+}
+
+// Provider represents the OAuth 2.0 and OpenID Connect authorization server handler.
+//  The user login state is backed by the auth.Session of the AuthHandler.
+//  The registered clients are internal applications, so the user consent is implicit.
+type Provider struct {
+	config     config.ConfigInfo
+	repository database.OAuthRepository
+	auth       database.AuthRepository
+	users      database.UsersRepository
+	sessions   sessionReader
+	reader     handlers.RequestReader
+	writer     handlers.ResponseWriter
+	signer     tokenSigner
+}
+
+// NewProvider initializes a new Provider instance.
+//  @param repo database.OAuthRepository: OAuthRepository interface for the clients, codes and tokens handling.
+//  @param authRepo database.AuthRepository: AuthRepository interface for the sessions handling.
+//  @param usersRepo database.UsersRepository: UsersRepository interface for the user claims.
+//  @param sessions sessionReader: reader of the logged user session.
+//  @param signer tokenSigner: signer of the ID tokens.
+//  @param r handlers.RequestReader: RequestReader interface for reading request operations.
+//  @param w handlers.ResponseWriter: ResponseWriter interface for writing request response operations.
+//  @param conf config.ConfigInfo: keeps the config information of the service.
+//  @return p Provider: new Provider instance.
+func NewProvider(repo database.OAuthRepository, authRepo database.AuthRepository, usersRepo database.UsersRepository, sessions sessionReader, signer tokenSigner, r handlers.RequestReader, w handlers.ResponseWriter, conf config.ConfigInfo) (p Provider) {
+	return Provider{
+		config:     conf,
+		repository: repo,
+		auth:       authRepo,
+		users:      usersRepo,
+		sessions:   sessions,
+		reader:     r,
+		writer:     w,
+		signer:     signer,
+	}
+}
+
+// Authorize implements the authorization endpoint for the authorization code grant with PKCE.
+func (p Provider) Authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	client, err := p.repository.GetClient(q.Get("client_id"))
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	redirectURI := q.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.ValidRedirectURI(redirectURI) {
+		p.writeError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for the client")
+		return
+	}
+
+	state := q.Get("state")
+	redirectError := func(code, description string) {
+		p.redirect(w, r, redirectURI, url.Values{
+			"error":             {code},
+			"error_description": {description},
+			"state":             {state},
+		})
+	}
+
+	if q.Get("response_type") != "code" {
+		redirectError("unsupported_response_type", "only the code response type is supported")
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		redirectError("invalid_request", "PKCE with the S256 code challenge method is required")
+		return
+	}
+
+	session, err := p.sessions.Session(r)
+	if err != nil {
+		if q.Get("prompt") == "none" {
+			redirectError("login_required", "the user is not logged in")
+			return
+		}
+		p.redirectToLogin(w, r)
+		return
+	}
+	if session.Impersonated() {
+		redirectError("access_denied", "impersonation sessions can't authorize clients")
+		return
+	}
+
+	scopes := client.AllowedScopes(auth.ParseScopes(q.Get("scope")))
+	code, raw, err := auth.NewAuthorizationCode(client, session, redirectURI, scopes, p.ttl(p.config.OIDC.AuthorizationCodeTTLInSecs))
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+	code.CodeChallenge = q.Get("code_challenge")
+	code.CodeChallengeMethod = q.Get("code_challenge_method")
+	code.Nonce = q.Get("nonce")
+
+	err = p.repository.SaveAuthorizationCode(code)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	log.Printf("Authorized client %s for user %d", client.ID, session.UserID)
+	p.redirect(w, r, redirectURI, url.Values{
+		"code":  {raw},
+		"state": {state},
+	})
+}
+
+// UserInfo implements the OpenID Connect userinfo endpoint.
+func (p Provider) UserInfo(w http.ResponseWriter, r *http.Request) {
+	token, err := p.bearerAccessToken(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		p.handleError(w, err)
+		return
+	}
+
+	user, err := p.users.GetUser(token.UserID)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	p.writer.JSON(w, http.StatusOK, userClaims(user, token.Scopes))
+}
+
+// RegisterClient registers a new OAuth client. The client secret is only present in this response.
+func (p Provider) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Confidential bool     `json:"confidential"`
+	}{}
+	err := p.reader.JSON(r, &body)
+	if err != nil {
+		p.handleError(w, sErrors.NewClientError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	// Resource servers are confidential clients without redirect uris, only used for introspection.
+	if strings.TrimSpace(body.Name) == "" || (len(body.RedirectURIs) == 0 && !body.Confidential) {
+		p.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid client: name and redirect_uris are required"))
+		return
+	}
+	for _, u := range body.RedirectURIs {
+		parsed, err := url.Parse(u)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			p.handleError(w, sErrors.NewClientError(http.StatusBadRequest, "invalid redirect uri: %s must be a absolute uri without fragment", u))
+			return
+		}
+	}
+	if len(body.Scopes) == 0 {
+		body.Scopes = supportedScopes
+	}
+
+	client, secret, err := auth.NewOAuthClient(body.Name, body.RedirectURIs, body.Scopes, body.Confidential)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	err = p.repository.SaveClient(client)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	res := handlers.Hash{
+		"client": client,
+	}
+	if secret != "" {
+		res["client_secret"] = secret
+	}
+	p.writer.JSON(w, http.StatusCreated, res)
+}
+
+// bearerAccessToken gets the active OAuth access token of the Authorization header.
+func (p Provider) bearerAccessToken(r *http.Request) (token auth.OAuthToken, err error) {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_token: missing bearer token")
+		return
+	}
+
+	token, err = p.activeToken(strings.TrimPrefix(h, "Bearer "), auth.OAUTH_ACCESS_TOKEN)
+	return
+}
+
+// activeToken gets the token of the type provided if neither it or its session have been revoked or expired.
+func (p Provider) activeToken(raw, tokenType string) (token auth.OAuthToken, err error) {
+	token, err = p.repository.GetToken(auth.HashToken(raw))
+	if err != nil {
+		return
+	}
+	if token.Type != tokenType || !token.Valid(time.Now()) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_token: token expired or invalid")
+		return
+	}
+
+	err = p.checkSession(token.SessionID)
+	return
+}
+
+// checkSession checks the session which has granted a code or token is still active.
+//  The sessions over their lifetime which aren't reaped yet are inactive too.
+func (p Provider) checkSession(sessionID int) (err error) {
+	session, err := p.auth.GetSession(sessionID)
+	if err != nil {
+		return
+	}
+	if !session.Actived || session.Expired(time.Now(), authHandlers.LifetimeOf(p.config, session)) {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: user session expired or invalid")
+	}
+	return
+}
+
+func (p Provider) redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	if p.config.OIDC.LoginURL == "" {
+		p.handleError(w, sErrors.NewClientError(http.StatusUnauthorized, "login_required: the user is not logged in"))
+		return
+	}
+
+	loginURL, err := url.Parse(p.config.OIDC.LoginURL)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+	q := loginURL.Query()
+	q.Set("return_to", p.config.OIDC.Issuer+r.URL.RequestURI())
+	loginURL.RawQuery = q.Encode()
+
+	http.Redirect(w, r, loginURL.String(), http.StatusFound)
+}
+
+func (p Provider) redirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		if len(v) > 0 && v[0] != "" {
+			q.Set(k, v[0])
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func (p Provider) ttl(secs int) time.Duration {
+	return time.Duration(secs) * time.Second
+}
+
+// handleError writes the error with the OAuth error response format.
+//  The client errors messages are formatted as "error_code: description".
+func (p Provider) handleError(w http.ResponseWriter, err error) {
+	hErr, ok := err.(sErrors.ClientError)
+	if !ok {
+		log.Println(err)
+		p.writeError(w, http.StatusInternalServerError, "server_error", sErrors.SERVER_ERROR_MESSAGE)
+		return
+	}
+
+	code, description := "invalid_request", hErr.Error()
+	if i := strings.Index(description, ": "); i > 0 && !strings.Contains(description[:i], " ") {
+		code, description = description[:i], description[i+2:]
+	}
+	p.writeError(w, hErr.HTTPCode(), code, description)
+}
+
+func (p Provider) writeError(w http.ResponseWriter, httpCode int, code, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	p.writer.JSON(w, httpCode, handlers.Hash{
+		"error":             code,
+		"error_description": description,
+	})
+}
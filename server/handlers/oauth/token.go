@@ -0,0 +1,204 @@
+package oauth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffemanfp/chat/auth"
+	sErrors "github.com/coffemanfp/chat/errors"
+	"github.com/coffemanfp/chat/server/handlers"
+	"github.com/coffemanfp/chat/users"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Token implements the token endpoint for the authorization code and refresh token grants.
+func (p Provider) Token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	client, err := p.authenticateClient(r)
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	var res handlers.Hash
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "authorization_code":
+		res, err = p.exchangeAuthorizationCode(client, r)
+	case "refresh_token":
+		res, err = p.refresh(client, r)
+	default:
+		err = sErrors.NewClientError(http.StatusBadRequest, "unsupported_grant_type: %s is not supported", grantType)
+	}
+	if err != nil {
+		p.handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	p.writer.JSON(w, http.StatusOK, res)
+}
+
+// authenticateClient gets the client of the request, authenticated with HTTP Basic or the form body.
+//  Public clients are identified only by its client_id.
+func (p Provider) authenticateClient(r *http.Request) (client auth.OAuthClient, err error) {
+	id, secret, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id == "" {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_client: missing client authentication")
+		return
+	}
+
+	client, err = p.repository.GetClient(id)
+	if err != nil {
+		return
+	}
+
+	if !client.Public() && !client.CheckSecret(secret) {
+		err = sErrors.NewClientError(http.StatusUnauthorized, "invalid_client: client authentication failed")
+	}
+	return
+}
+
+func (p Provider) exchangeAuthorizationCode(client auth.OAuthClient, r *http.Request) (res handlers.Hash, err error) {
+	code, err := p.repository.ConsumeAuthorizationCode(auth.HashToken(r.PostForm.Get("code")))
+	if err != nil {
+		return
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != r.PostForm.Get("redirect_uri") || time.Now().After(code.ExpiresAt) {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: authorization code expired, used or invalid")
+		return
+	}
+	if !auth.VerifyCodeChallenge(r.PostForm.Get("code_verifier"), code.CodeChallenge, code.CodeChallengeMethod) {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: invalid code_verifier")
+		return
+	}
+
+	err = p.checkSession(code.SessionID)
+	if err != nil {
+		return
+	}
+
+	res, err = p.issueTokens(client, code.UserID, code.SessionID, code.Scopes, code.AuthTime, code.Nonce)
+	return
+}
+
+// refresh issues new tokens for a refresh token. The refresh token is rotated in every usage, and it's consumed
+//  atomically, so the concurrent replays of the same token fail.
+func (p Provider) refresh(client auth.OAuthClient, r *http.Request) (res handlers.Hash, err error) {
+	token, err := p.activeToken(r.PostForm.Get("refresh_token"), auth.OAUTH_REFRESH_TOKEN)
+	if err != nil {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: refresh token expired or invalid")
+		return
+	}
+	if token.ClientID != client.ID {
+		err = sErrors.NewClientError(http.StatusBadRequest, "invalid_grant: refresh token was issued to another client")
+		return
+	}
+
+	scopes := token.Scopes
+	if requested := auth.ParseScopes(r.PostForm.Get("scope")); len(requested) > 0 {
+		scopes = auth.FilterScopes(requested, token.Scopes)
+	}
+
+	err = p.repository.ConsumeToken(token.ID)
+	if err != nil {
+		return
+	}
+
+	res, err = p.issueTokens(client, token.UserID, token.SessionID, scopes, token.AuthTime, "")
+	return
+}
+
+// issueTokens creates the access, refresh and, for the openid scope, ID tokens of a grant.
+func (p Provider) issueTokens(client auth.OAuthClient, userID, sessionID int, scopes []string, authTime time.Time, nonce string) (res handlers.Hash, err error) {
+	accessToken, rawAccess, err := auth.NewOAuthToken(auth.OAUTH_ACCESS_TOKEN, client.ID, userID, sessionID, scopes, authTime, p.ttl(p.config.OIDC.AccessTokenTTLInSecs))
+	if err != nil {
+		return
+	}
+	_, err = p.repository.SaveToken(accessToken)
+	if err != nil {
+		return
+	}
+
+	refreshToken, rawRefresh, err := auth.NewOAuthToken(auth.OAUTH_REFRESH_TOKEN, client.ID, userID, sessionID, scopes, authTime, p.ttl(p.config.OIDC.RefreshTokenTTLInSecs))
+	if err != nil {
+		return
+	}
+	_, err = p.repository.SaveToken(refreshToken)
+	if err != nil {
+		return
+	}
+
+	res = handlers.Hash{
+		"access_token":  rawAccess,
+		"token_type":    "Bearer",
+		"expires_in":    p.config.OIDC.AccessTokenTTLInSecs,
+		"refresh_token": rawRefresh,
+		"scope":         strings.Join(scopes, " "),
+	}
+
+	if accessToken.HasScope("openid") {
+		var idToken string
+		idToken, err = p.newIDToken(client, userID, sessionID, scopes, authTime, nonce)
+		if err != nil {
+			return
+		}
+		res["id_token"] = idToken
+	}
+	return
+}
+
+func (p Provider) newIDToken(client auth.OAuthClient, userID, sessionID int, scopes []string, authTime time.Time, nonce string) (token string, err error) {
+	user, err := p.users.GetUser(userID)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":       p.config.OIDC.Issuer,
+		"aud":       client.ID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(p.ttl(p.config.OIDC.IDTokenTTLInSecs)).Unix(),
+		"auth_time": authTime.Unix(),
+		"sid":       strconv.Itoa(sessionID),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for k, v := range userClaims(user, scopes) {
+		claims[k] = v
+	}
+
+	token, err = p.signer.Sign(claims)
+	return
+}
+
+// userClaims gets the OpenID Connect standard claims of the user allowed by the scopes.
+func userClaims(user users.User, scopes []string) (claims handlers.Hash) {
+	claims = handlers.Hash{
+		"sub": strconv.Itoa(user.ID),
+	}
+	if auth.HasScope(scopes, "profile") {
+		claims["preferred_username"] = user.Nickname
+		claims["nickname"] = user.Nickname
+		if user.Picture != "" {
+			claims["picture"] = user.Picture
+		}
+	}
+	if auth.HasScope(scopes, "email") && user.Email != "" {
+		claims["email"] = user.Email
+	}
+	return
+}
@@ -2,13 +2,23 @@ package server
 
 import (
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"time"
 
+	sAuth "github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/captcha"
 	"github.com/coffemanfp/chat/config"
 	"github.com/coffemanfp/chat/database"
+	"github.com/coffemanfp/chat/emailcheck"
+	"github.com/coffemanfp/chat/keys"
+	"github.com/coffemanfp/chat/mail"
+	"github.com/coffemanfp/chat/notify"
 	"github.com/coffemanfp/chat/server/handlers"
 	"github.com/coffemanfp/chat/server/handlers/auth"
+	"github.com/coffemanfp/chat/server/handlers/oauth"
+	"github.com/coffemanfp/chat/users"
 	muxhandlers "github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
@@ -24,20 +34,31 @@ func (s Server) Run() (err error) {
 }
 
 // NewServer initializes a new *Server instance.
-//	@param conf config.ConfigInfo: keeps the current config information.
+//	@param live config.Config: keeps the current config information, its reloadable values are read on every use.
 //	@param db database.Database: database for the repositories.
 //	@param host string: host to listening.
 //	@param port int: port to listening.
-//	@return $1 *Server: new *Server instance.
-func NewServer(conf config.ConfigInfo, db database.Database, host string, port int) *Server {
+//	@return s *Server: new *Server instance.
+//	@return err error: auth or oauth handlers set up error.
+func NewServer(live config.Config, db database.Database, host string, port int) (s *Server, err error) {
 	r := mux.NewRouter().StrictSlash(false)
 	v1R := r.PathPrefix("/api/v1").Subrouter()
+	conf := live.Get()
 
-	setUpMiddlewares(r, conf)
+	setUpMiddlewares(r, live)
 	setUpAPIHandlers(r)
-	setUpAuthHandlers(v1R, conf, db)
+	ah, err := setUpAuthHandlers(v1R, live, db)
+	if err != nil {
+		err = fmt.Errorf("failed to set up auth handlers: %s", err)
+		return
+	}
+	err = setUpOAuthHandlers(r, v1R, conf, db, ah)
+	if err != nil {
+		err = fmt.Errorf("failed to set up oauth handlers: %s", err)
+		return
+	}
 
-	return &Server{
+	s = &Server{
 		srv: &http.Server{
 			Handler:      r,
 			Addr:         fmt.Sprintf("%s:%d", host, port),
@@ -45,6 +66,7 @@ func NewServer(conf config.ConfigInfo, db database.Database, host string, port i
 			ReadTimeout:  30 * time.Second,
 		},
 	}
+	return
 }
 
 func setUpAPIHandlers(r *mux.Router) {
@@ -54,25 +76,308 @@ func setUpAPIHandlers(r *mux.Router) {
 	}).Methods("GET")
 }
 
-func setUpMiddlewares(r *mux.Router, conf config.ConfigInfo) {
+func setUpMiddlewares(r *mux.Router, live config.Config) {
 	r.Use(logginMiddleware)
 	r.Use(muxhandlers.RecoveryHandler(muxhandlers.PrintRecoveryStack(true)))
-	r.Use(muxhandlers.CORS(muxhandlers.AllowedOrigins(conf.Server.AllowedOrigins)))
+	r.Use(muxhandlers.CORS(muxhandlers.AllowedOriginValidator(allowedOrigin(live))))
+}
+
+// allowedOrigin creates a CORS origin validator with the current allowed origins, so they can be reloaded.
+//  The "*" origin allows any origin.
+func allowedOrigin(live config.Config) muxhandlers.OriginValidator {
+	return func(origin string) bool {
+		for _, o := range live.Get().Server.AllowedOrigins {
+			if o == "*" || o == origin {
+				return true
+			}
+		}
+		return false
+	}
 }
 
-func setUpAuthHandlers(r *mux.Router, conf config.ConfigInfo, db database.Database) {
+func setUpAuthHandlers(r *mux.Router, live config.Config, db database.Database) (ah auth.AuthHandler, err error) {
+	conf := live.Get()
 	repo, err := database.GetAuthRepository(db.Repositories)
 	if err != nil {
 		return
 	}
 
-	ah := auth.NewAuthHandler(
-		repo,
+	roleRepo, err := database.GetRoleRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	tokenRepo, err := database.GetTokenRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	usersRepo, err := database.GetUsersRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	magicLinkRepo, err := database.GetMagicLinkRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	emailCodeRepo, err := database.GetEmailCodeRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	sessionRepo, err := database.GetSessionRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	sessionTokenRepo, err := database.GetSessionTokenRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	rememberRepo, err := database.GetRememberTokenRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	deviceRepo, err := database.GetDeviceRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	resetRepo, err := database.GetPasswordResetRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	securityLogRepo, err := database.GetSecurityLogRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	auditRepo, err := database.GetAuditLogRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	inviteRepo, err := database.GetInviteRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	verifier, err := setUpCaptcha(conf)
+	if err != nil {
+		return
+	}
+
+	nicknameLists, err := users.LoadNicknameLists(conf.SignUp.ReservedNicknamesFile, conf.SignUp.BlockedNicknameWordsFile)
+	if err != nil {
+		return
+	}
+	users.SetNicknameLists(nicknameLists)
+
+	emailValidator, err := setUpEmailValidator(conf)
+	if err != nil {
+		return
+	}
+	users.SetEmailValidator(emailValidator)
+
+	mailer := setUpMailer(conf)
+	ah, err = auth.NewAuthHandler(
+		auth.Repositories{
+			Auth:           repo,
+			Roles:          roleRepo,
+			Tokens:         tokenRepo,
+			Sessions:       sessionRepo,
+			SessionTokens:  sessionTokenRepo,
+			RememberTokens: rememberRepo,
+			Devices:        deviceRepo,
+			PasswordResets: resetRepo,
+			SecurityLog:    securityLogRepo,
+			AuditLog:       auditRepo,
+			Invites:        inviteRepo,
+			Users:          usersRepo,
+			MagicLinks:     magicLinkRepo,
+			EmailCodes:     emailCodeRepo,
+		},
+		mailer,
+		notify.NewEmailNotifier(mailer),
+		verifier,
 		handlers.GetRequestReaderImpl(),
 		handlers.GetResponseWriterImpl(),
-		conf,
+		live,
 	)
+	if err != nil {
+		return
+	}
+
+	reaper := auth.NewSessionReaper(sessionRepo, conf)
+	go reaper.Run(time.Duration(conf.Session.ReaperIntervalInSecs) * time.Second)
 
 	r.HandleFunc("/auth/{action}/{handler}", ah.HandleAuth).Methods("GET", "POST")
 	r.HandleFunc("/auth/sudo", ah.CreateSudo).Methods("POST")
+	r.HandleFunc("/auth/not-me", ah.NotMe).Methods("GET")
+	r.HandleFunc("/auth/password-reset", ah.ResetPassword).Methods("POST")
+	r.HandleFunc("/auth/impersonation", ah.StopImpersonation).Methods("DELETE")
+
+	ticketRepo, err := database.GetWSTicketRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	th := auth.NewWSTicketHandler(
+		ticketRepo,
+		repo,
+		conf,
+		handlers.GetRequestReaderImpl(),
+		handlers.GetResponseWriterImpl(),
+	)
+
+	r.Handle("/auth/ws-ticket", ah.Authenticate(http.HandlerFunc(th.CreateTicket))).Methods("POST")
+	r.Handle("/auth/ws-ticket/verify", ah.RequirePermission(sAuth.PermissionWSTicketVerify)(http.HandlerFunc(th.VerifyTicket))).Methods("POST")
+
+	meR := r.PathPrefix("/users/me").Subrouter()
+	meR.Use(ah.Authenticate)
+	meR.HandleFunc("/tokens", ah.CreateAccessToken).Methods("POST")
+	meR.HandleFunc("/tokens", ah.GetAccessTokens).Methods("GET")
+	meR.HandleFunc("/tokens/{id:[0-9]+}", ah.RevokeAccessToken).Methods("DELETE")
+	meR.HandleFunc("/security-log", ah.GetSecurityLog).Methods("GET")
+	meR.Handle("/email", ah.RequireSudo(http.HandlerFunc(ah.ChangeEmail))).Methods("PUT")
+
+	invitesR := r.PathPrefix("/invites").Subrouter()
+	invitesR.Use(ah.RequirePermission(sAuth.PermissionInvitesManage))
+	invitesR.HandleFunc("", ah.CreateInvite).Methods("POST")
+	invitesR.HandleFunc("/{id:[0-9]+}", ah.RevokeInvite).Methods("DELETE")
+
+	usersAdminR := r.PathPrefix("/users").Subrouter()
+	usersAdminR.Use(ah.RequirePermission(sAuth.PermissionUsersAdmin))
+	usersAdminR.HandleFunc("/{id:[0-9]+}/roles/{role}", ah.AssignRole).Methods("PUT")
+	usersAdminR.HandleFunc("/{id:[0-9]+}/roles/{role}", ah.RevokeRole).Methods("DELETE")
+	usersAdminR.HandleFunc("/{id:[0-9]+}/nickname", ah.GrantNickname).Methods("PUT")
+	usersAdminR.Handle("/{id:[0-9]+}/impersonate", ah.RequireSudo(http.HandlerFunc(ah.Impersonate))).Methods("POST")
+	return
+}
+
+func setUpOAuthHandlers(r, v1R *mux.Router, conf config.ConfigInfo, db database.Database, ah auth.AuthHandler) (err error) {
+	repo, err := database.GetOAuthRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	authRepo, err := database.GetAuthRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	usersRepo, err := database.GetUsersRepository(db.Repositories)
+	if err != nil {
+		return
+	}
+
+	signer, err := setUpSigningKeys(conf, db)
+	if err != nil {
+		return
+	}
+
+	p := oauth.NewProvider(
+		repo,
+		authRepo,
+		usersRepo,
+		ah,
+		signer,
+		handlers.GetRequestReaderImpl(),
+		handlers.GetResponseWriterImpl(),
+		conf,
+	)
+
+	r.HandleFunc("/.well-known/openid-configuration", p.Discovery).Methods("GET")
+	r.HandleFunc("/.well-known/jwks.json", p.JWKS).Methods("GET")
+	r.HandleFunc("/oauth/authorize", p.Authorize).Methods("GET")
+	r.HandleFunc("/oauth/token", p.Token).Methods("POST")
+	r.HandleFunc("/oauth/userinfo", p.UserInfo).Methods("GET", "POST")
+	r.HandleFunc("/oauth/introspect", p.Introspect).Methods("POST")
+	r.HandleFunc("/oauth/revoke", p.Revoke).Methods("POST")
+
+	v1R.Handle("/oauth/clients", ah.RequirePermission(sAuth.PermissionUsersAdmin)(http.HandlerFunc(p.RegisterClient))).Methods("POST")
+	return
+}
+
+// setUpMailer initializes the SMTP mailer, or a mailer which only logs the emails if no SMTP server is configured.
+func setUpMailer(conf config.ConfigInfo) mail.Mailer {
+	if conf.Mail.SMTPHost == "" {
+		log.Println("No SMTP host provided, the emails will only be logged")
+		return mail.LogMailer{}
+	}
+	return mail.NewSMTPMailer(conf.Mail.SMTPHost, conf.Mail.SMTPPort, conf.Mail.User, conf.Mail.Password, conf.Mail.From)
+}
+
+// setUpCaptcha initializes the CAPTCHA verifier of the configured provider, nil if no provider is configured.
+func setUpCaptcha(conf config.ConfigInfo) (v captcha.CaptchaVerifier, err error) {
+	switch conf.SignUp.CaptchaProvider {
+	case "hcaptcha":
+		v = captcha.NewHCaptchaVerifier(conf.SignUp.CaptchaSecret)
+	case "turnstile":
+		v = captcha.NewTurnstileVerifier(conf.SignUp.CaptchaSecret)
+	case "fake":
+		log.Println("Fake CAPTCHA provider configured, the sign ups only need the configured secret as token")
+		v = captcha.FakeVerifier{Token: conf.SignUp.CaptchaSecret}
+	case "":
+	default:
+		err = fmt.Errorf("invalid captcha provider: %s is not supported", conf.SignUp.CaptchaProvider)
+	}
+	return
+}
+
+// setUpEmailValidator initializes the email validator of the configured mode.
+func setUpEmailValidator(conf config.ConfigInfo) (v emailcheck.Validator, err error) {
+	switch conf.EmailValidation.Mode {
+	case "dns", "":
+		v = emailcheck.NewDNSValidator(
+			net.DefaultResolver,
+			time.Duration(conf.EmailValidation.CacheTTLInSecs)*time.Second,
+			time.Duration(conf.EmailValidation.LookupTimeoutInSecs)*time.Second,
+		)
+	case "offline":
+		log.Println("Offline email validation mode configured, only the syntax of the emails will be validated")
+		v = emailcheck.SyntaxValidator{}
+	default:
+		err = fmt.Errorf("invalid email validation mode: %s is not supported", conf.EmailValidation.Mode)
+	}
+	return
+}
+
+// setUpSigningKeys initializes the signing keys manager with the configured store and starts its rotation.
+func setUpSigningKeys(conf config.ConfigInfo, db database.Database) (m *keys.Manager, err error) {
+	var store keys.Store
+	switch conf.SigningKeys.Store {
+	case "database":
+		store, err = database.GetKeyRepository(db.Repositories)
+	case "dir", "":
+		if conf.SigningKeys.Dir == "" {
+			log.Println("No signing keys dir provided, the signing keys will only be kept in memory")
+			store = keys.NewMemoryStore()
+		} else {
+			store, err = keys.NewDirStore(conf.SigningKeys.Dir)
+		}
+	default:
+		err = fmt.Errorf("invalid signing keys store: %s is not supported", conf.SigningKeys.Store)
+	}
+	if err != nil {
+		return
+	}
+
+	m, err = keys.NewManager(store, keys.Schedule{
+		Algorithm:  conf.SigningKeys.Algorithm,
+		ActiveFor:  time.Duration(conf.SigningKeys.RotationIntervalInSecs) * time.Second,
+		Retention:  time.Duration(conf.SigningKeys.RetentionInSecs) * time.Second,
+		Prepublish: time.Duration(conf.SigningKeys.PrepublishInSecs) * time.Second,
+	})
+	if err != nil {
+		return
+	}
+
+	go m.Run(time.Duration(conf.SigningKeys.CheckIntervalInSecs) * time.Second)
+	return
 }
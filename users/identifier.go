@@ -0,0 +1,35 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/coffemanfp/chat/errors"
+)
+
+// NormalizeEmail normalizes the email to the form kept by the users, the emails are case-insensitive.
+//  @param email string: email to normalize.
+//  @return $1 string: normalized email.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ResolveIdentifier resolves the login identifier provided as a email or a nickname.
+//  The nicknames can't contain a "@", so the identifiers containing it are always emails.
+//  @param identifier string: email or nickname of the user.
+//  @return email string: normalized email, empty if the identifier is a nickname.
+//  @return nickname string: normalized nickname, empty if the identifier is a email.
+//  @return err error: empty identifier.
+func ResolveIdentifier(identifier string) (email, nickname string, err error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		err = errors.NewClientError(http.StatusBadRequest, "invalid identifier: empty value")
+		return
+	}
+	if strings.Contains(identifier, "@") {
+		email = NormalizeEmail(identifier)
+		return
+	}
+	nickname = NormalizeNickname(identifier)
+	return
+}
@@ -0,0 +1,58 @@
+package users
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps the characters which look like a latin letter or digit to it.
+//  It covers the common homoglyphs of the Cyrillic and Greek scripts and the ASCII look-alikes,
+//  the compatibility forms like the fullwidth letters are already folded by the NFKC normalization.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ѐ': 'e', 'ё': 'e', 'һ': 'h', 'і': 'l', 'ї': 'l',
+	'ј': 'j', 'к': 'k', 'ӏ': 'l', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'т': 't',
+	'ц': 'u', 'ѵ': 'v', 'ԝ': 'w', 'х': 'x', 'у': 'y', 'ү': 'y', 'ь': 'b', 'г': 'r',
+
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'γ': 'y', 'ω': 'w', 'ϲ': 'c', 'ϳ': 'j',
+
+	// ASCII and latin look-alikes
+	'0': 'o', '1': 'l', 'i': 'l', '|': 'l', 'ı': 'l', 'ł': 'l', 'ɡ': 'g',
+}
+
+// multiConfusables replaces the sequences of characters which look like a single letter.
+var multiConfusables = strings.NewReplacer("rn", "m", "vv", "w")
+
+// NormalizeNickname normalizes the nickname with the NFKC normalization, keeping its casing for display.
+//  @param nickname string: nickname to normalize.
+//  @return $1 string: normalized nickname.
+func NormalizeNickname(nickname string) string {
+	return norm.NFKC.String(strings.TrimSpace(nickname))
+}
+
+// NicknameSkeleton gets the form of the nickname used to check its uniqueness.
+//  The nickname is normalized with NFKC, case folded, stripped of its diacritics and its confusable
+//  characters are replaced, so the nicknames which look alike to the users have the same skeleton.
+//  @param nickname string: nickname to get the skeleton of.
+//  @return $1 string: skeleton of the nickname.
+func NicknameSkeleton(nickname string) string {
+	// A Caser is stateful, so a new one is used by call to be safe for concurrent use.
+	s := cases.Fold().String(norm.NFKC.String(strings.TrimSpace(nickname)))
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		b.WriteRune(r)
+	}
+	return multiConfusables.Replace(b.String())
+}
@@ -0,0 +1,142 @@
+package users
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/coffemanfp/chat/errors"
+)
+
+// leetspeak maps the digits and symbols used as letters to them.
+//  The ones which are confusables of a letter, like "0" or "1", are already replaced in the nickname skeleton.
+var leetspeak = strings.NewReplacer(
+	"2", "z", "3", "e", "4", "a", "5", "s", "6", "g", "7", "t", "8", "b", "9", "g", "@", "a", "$", "s", "_", "",
+)
+
+// NicknameLists keeps the reserved names and the blocked words which can't be part of a nickname.
+//  The entries are kept in the matching form, so the substrings and leetspeak variants of them are matched.
+type NicknameLists struct {
+	// Reserved are the names which can only be granted to the official accounts by a admin.
+	Reserved []string
+
+	// Blocked are the words which can't be part of any nickname, like slurs.
+	Blocked []string
+}
+
+// NewNicknameLists initializes a new NicknameLists instance with the entries provided.
+//  @param reserved []string: reserved names.
+//  @param blocked []string: blocked words.
+//  @return $1 NicknameLists: new NicknameLists instance.
+func NewNicknameLists(reserved, blocked []string) NicknameLists {
+	return NicknameLists{
+		Reserved: matchingForms(reserved),
+		Blocked:  matchingForms(blocked),
+	}
+}
+
+// LoadNicknameLists loads the nickname lists of the files provided.
+//  The files have a entry by line, the empty lines and the lines starting with # are ignored.
+//  A empty path is a empty list.
+//  @param reservedFile string: path of the reserved names file.
+//  @param blockedFile string: path of the blocked words file.
+//  @return l NicknameLists: loaded NicknameLists instance.
+//  @return err error: failed file reading.
+func LoadNicknameLists(reservedFile, blockedFile string) (l NicknameLists, err error) {
+	reserved, err := readListFile(reservedFile)
+	if err != nil {
+		err = fmt.Errorf("failed to read reserved nicknames file: %s", err)
+		return
+	}
+	blocked, err := readListFile(blockedFile)
+	if err != nil {
+		err = fmt.Errorf("failed to read blocked nickname words file: %s", err)
+		return
+	}
+	l = NewNicknameLists(reserved, blocked)
+	return
+}
+
+// Check checks the nickname provided doesn't contain a blocked word nor a reserved name.
+//  @param nickname string: normalized nickname to check.
+//  @param allowReserved bool: allows the reserved names, for the official accounts.
+//  @return err error: nickname containing a blocked word or a reserved name.
+func (l NicknameLists) Check(nickname string, allowReserved bool) (err error) {
+	form := matchingForm(nickname)
+	if containsAny(form, l.Blocked) {
+		err = errors.NewClientError(http.StatusBadRequest, "invalid nickname: %s contains a blocked word", nickname)
+		return
+	}
+	if !allowReserved && containsAny(form, l.Reserved) {
+		err = errors.NewClientError(http.StatusBadRequest, "invalid nickname: %s is reserved", nickname)
+	}
+	return
+}
+
+var (
+	nicknameLists   NicknameLists
+	nicknameListsMu sync.RWMutex
+)
+
+// SetNicknameLists sets the nickname lists checked by ValidateNickname.
+//  @param l NicknameLists: nickname lists to check.
+func SetNicknameLists(l NicknameLists) {
+	nicknameListsMu.Lock()
+	defer nicknameListsMu.Unlock()
+	nicknameLists = l
+}
+
+func getNicknameLists() NicknameLists {
+	nicknameListsMu.RLock()
+	defer nicknameListsMu.RUnlock()
+	return nicknameLists
+}
+
+// matchingForm gets the form of the text used to match the list entries: its skeleton
+//  without underscores and with the leetspeak characters replaced.
+func matchingForm(s string) string {
+	return leetspeak.Replace(NicknameSkeleton(s))
+}
+
+func matchingForms(entries []string) (forms []string) {
+	for _, e := range entries {
+		if f := matchingForm(e); f != "" {
+			forms = append(forms, f)
+		}
+	}
+	return
+}
+
+func containsAny(s string, entries []string) bool {
+	for _, e := range entries {
+		if strings.Contains(s, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func readListFile(path string) (entries []string, err error) {
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	err = s.Err()
+	return
+}
@@ -1,14 +1,13 @@
 package users
 
 import (
-	"net"
 	"net/http"
-	"net/mail"
 	"regexp"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/coffemanfp/chat/auth"
+	"github.com/coffemanfp/chat/emailcheck"
 	"github.com/coffemanfp/chat/errors"
 )
 
@@ -21,6 +20,22 @@ type User struct {
 	Picture    string           `json:"picture,omitempty"`
 	CreatedAt  time.Time        `json:"created_at"`
 	SignedWith []ExternalSigned `json:"signed_with,omitempty"`
+
+	// NicknameSkeleton is the form of the nickname which must be unique, see NicknameSkeleton.
+	//  The Nickname keeps the casing chosen by the user for display.
+	NicknameSkeleton string `json:"-"`
+
+	// Identifier is the email or the nickname of the user to login with a password, see ResolveIdentifier.
+	Identifier string `json:"identifier,omitempty"`
+
+	// RememberMe requests a persistent login on the login.
+	RememberMe bool `json:"remember_me,omitempty"`
+
+	// InviteCode is the invite used to sign up when the registration is invite-only.
+	InviteCode string `json:"invite_code,omitempty"`
+
+	// CaptchaToken is the CAPTCHA solved by the client to sign up.
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // ExternalSigned represents the data required for external sign in services models.
@@ -37,20 +52,33 @@ type ExternalSigned struct {
 // 	@return user User: User builded
 // 	@return err error: error in the validation of the based user.
 func New(userR User) (user User, err error) {
-	// If the user is not registered with an external platform, validate the nickname and password.
+	// If the user is not registered with an external platform, validate the nickname, email and password.
 	user = userR
+	user.Nickname = NormalizeNickname(user.Nickname)
+	user.Email = NormalizeEmail(user.Email)
 	if len(user.SignedWith) == 0 {
 		err = ValidateNickname(user.Nickname)
 		if err != nil {
 			user = User{}
 			return
 		}
+		if user.Email != "" {
+			user.Email, err = ValidateEmail(user.Email)
+			if err != nil {
+				user = User{}
+				return
+			}
+			user.Email = NormalizeEmail(user.Email)
+		}
 		err = HashPassword(&user.Password)
 		if err != nil {
 			user = User{}
 			return
 		}
 	}
+	if user.Nickname != "" {
+		user.NicknameSkeleton = NicknameSkeleton(user.Nickname)
+	}
 	user.CreatedAt = time.Now()
 	return
 }
@@ -72,35 +100,56 @@ func HashPassword(orig *string) (err error) {
 	return
 }
 
-var nicknameRegex = regexp.MustCompile(`^[^0-9]\w+$`)
+// nicknameRegex allows the letters of any script, combining marks, digits and underscores, not starting with a digit.
+var nicknameRegex = regexp.MustCompile(`^[\p{L}_][\p{L}\p{M}\p{N}_]+$`)
 
-// ValidateNickname validate the nickname with a regular expression.
+// ValidateNickname validate the nickname with a regular expression and
+//  check it doesn't contain a reserved name or a blocked word of the nickname lists.
+//  The nickname must be already normalized with NormalizeNickname.
 // 	@param nickname string: nickname to validate.
-//  @return err error: don't match the regex with the string provided.
+//  @return err error: don't match the regex with the string provided or listed nickname.
 func ValidateNickname(nickname string) (err error) {
+	return validateNickname(nickname, false)
+}
+
+// ValidateOfficialNickname validate the nickname of a official account, which can be a reserved name.
+//  It must only be used for the nicknames granted by a admin, the blocked words are still checked.
+// 	@param nickname string: nickname to validate.
+//  @return err error: don't match the regex with the string provided or blocked nickname.
+func ValidateOfficialNickname(nickname string) (err error) {
+	return validateNickname(nickname, true)
+}
+
+func validateNickname(nickname string, allowReserved bool) (err error) {
 	if !nicknameRegex.MatchString(nickname) {
 		err = errors.NewClientError(http.StatusBadRequest, "invalid nickname: invalid nickname format of %s", nickname)
+		return
 	}
+	err = getNicknameLists().Check(nickname, allowReserved)
 	return
 }
 
-// ValidateEmail validate the email with a standart library and
-//  check the host.
+// ValidateEmail validate the email with the email validator set with SetEmailValidator,
+//  syntax-only by default.
 // @param email string: email to validate.
+// @return addr string: address of the email, with the domain in its ASCII form.
 // @return err error: invalid format of the email or the host.
-func ValidateEmail(email string) (err error) {
-	// Check email format
-	_, err = mail.ParseAddress(email)
-	if err != nil {
-		err = errors.NewClientError(http.StatusBadRequest, "invalid email format: %s is not valid, cause %s", email, err)
-		return
-	}
+func ValidateEmail(email string) (addr string, err error) {
+	emailValidatorMu.RLock()
+	v := emailValidator
+	emailValidatorMu.RUnlock()
+	return v.Validate(email)
+}
 
-	// Check the host
-	host := strings.Split(email, "@")[1]
-	_, err = net.LookupHost(host)
-	if err != nil {
-		err = errors.NewClientError(http.StatusBadRequest, "invalid email host: %s not exists", host)
-	}
-	return
+var (
+	emailValidator   emailcheck.Validator = emailcheck.SyntaxValidator{}
+	emailValidatorMu sync.RWMutex
+)
+
+// SetEmailValidator sets the email validator used by ValidateEmail.
+//  @param v emailcheck.Validator: email validator to use.
+func SetEmailValidator(v emailcheck.Validator) {
+	emailValidatorMu.Lock()
+	defer emailValidatorMu.Unlock()
+	emailValidator = v
 }
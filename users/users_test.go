@@ -1,10 +1,15 @@
 package users
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/coffemanfp/chat/emailcheck"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -12,6 +17,7 @@ import (
 var (
 	nickname        = "exampleuser"
 	invalidNickname = "$**exampleNickname"
+	unicodeNickname = "Δημήτρης_日本"
 	password        = "1234"
 )
 
@@ -30,8 +36,67 @@ func TestNew(t *testing.T) {
 			user.CreatedAt = time.Time{}
 			gotUser.CreatedAt = time.Time{}
 		}
+		user.NicknameSkeleton = "exampleuser"
 		assert.Equal(t, user, gotUser)
 	})
+
+	t.Run("Given a user with a non-normalized nickname When creating new user Then the nickname is normalized keeping its casing", func(t *testing.T) {
+		gotUser, err := New(User{
+			Nickname: " Ｊｏｓé_Ñandú ",
+			Password: password,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "José_Ñandú", gotUser.Nickname)
+		assert.Equal(t, "jose_nandu", gotUser.NicknameSkeleton)
+	})
+}
+
+func TestNicknameSkeleton(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		nickname string
+		other    string
+	}{
+		{
+			name:     "Given nicknames which only differ in casing When getting their skeletons Then same skeleton",
+			nickname: "Admin",
+			other:    "aDMIN",
+		},
+		{
+			name:     "Given a nickname with cyrillic homoglyphs When getting their skeletons Then same skeleton",
+			nickname: "admin",
+			other:    "аdmіn",
+		},
+		{
+			name:     "Given a nickname with digit look-alikes When getting their skeletons Then same skeleton",
+			nickname: "admin",
+			other:    "adm1n",
+		},
+		{
+			name:     "Given a nickname with multi character look-alikes When getting their skeletons Then same skeleton",
+			nickname: "modern",
+			other:    "rnodern",
+		},
+		{
+			name:     "Given a nickname with fullwidth characters When getting their skeletons Then same skeleton",
+			nickname: "chat",
+			other:    "ｃｈａｔ",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, NicknameSkeleton(tt.nickname), NicknameSkeleton(tt.other))
+		})
+	}
+
+	t.Run("Given different nicknames When getting their skeletons Then different skeletons", func(t *testing.T) {
+		assert.NotEqual(t, NicknameSkeleton("alice"), NicknameSkeleton("bob"))
+	})
 }
 
 func TestErrorNew(t *testing.T) {
@@ -67,6 +132,7 @@ func TestErrorNew(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
@@ -104,6 +170,10 @@ func TestValidateNickname(t *testing.T) {
 	t.Run("Given a valid nickname When validating nickname Then success", func(t *testing.T) {
 		assert.NoError(t, ValidateNickname(nickname))
 	})
+
+	t.Run("Given a valid nickname with non-latin letters When validating nickname Then success", func(t *testing.T) {
+		assert.NoError(t, ValidateNickname(unicodeNickname))
+	})
 }
 
 func TestErrorValidateNickname(t *testing.T) {
@@ -115,35 +185,74 @@ func TestErrorValidateNickname(t *testing.T) {
 	})
 }
 
+type resolverImpl struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+}
+
+func (r resolverImpl) LookupMX(ctx context.Context, name string) (mxs []*net.MX, err error) {
+	mxs, ok := r.mx[name]
+	if !ok {
+		err = &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return
+}
+
+func (r resolverImpl) LookupHost(ctx context.Context, host string) (addrs []string, err error) {
+	addrs, ok := r.hosts[host]
+	if !ok {
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return
+}
+
 func TestValidateEmail(t *testing.T) {
 	t.Parallel()
 
 	t.Run("Given valid email When validating email Then success", func(t *testing.T) {
+		addr, err := ValidateEmail("example@gmail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "example@gmail.com", addr)
+	})
 
-		assert.NoError(t, ValidateEmail("example@gmail.com"))
+	t.Run("Given a email with a internationalized domain When validating email Then address with the ASCII domain", func(t *testing.T) {
+		addr, err := ValidateEmail("example@bücher.example")
+		assert.NoError(t, err)
+		assert.Equal(t, "example@xn--bcher-kva.example", addr)
 	})
 }
 
+// TestErrorValidateEmail isn't parallel, it sets the email validator used by the other tests.
 func TestErrorValidateEmail(t *testing.T) {
-	t.Parallel()
+	SetEmailValidator(emailcheck.NewDNSValidator(resolverImpl{
+		mx: map[string][]*net.MX{"gmail.com": {{Host: "mx.gmail.com.", Pref: 10}}},
+	}, time.Minute, time.Second))
+	defer SetEmailValidator(emailcheck.SyntaxValidator{})
 
 	t.Run("Given invalid email format When validating email format Then invalid email format", func(t *testing.T) {
-		t.Parallel()
-
 		invalidEmail := "invalid.email.format"
 
-		err := ValidateEmail(invalidEmail)
+		_, err := ValidateEmail(invalidEmail)
 		assert.Contains(t, err.Error(), fmt.Sprintf("invalid email format: %s is not valid, cause", invalidEmail))
 	})
 
 	t.Run("Given invalid email host When validating email host Then invalid email host error", func(t *testing.T) {
-		t.Parallel()
-
 		invalidHost := "invalid.host"
 
-		err := ValidateEmail("example@" + invalidHost)
+		_, err := ValidateEmail("example@" + invalidHost)
 		assert.EqualError(t, err, fmt.Sprintf("invalid email host: %s not exists", invalidHost))
 	})
+
+	t.Run("Given a user with a email of a invalid host When creating new user Then invalid email host error", func(t *testing.T) {
+		_, err := New(User{Nickname: nickname, Email: "example@invalid.host", Password: password})
+		assert.EqualError(t, err, "invalid email host: invalid.host not exists")
+	})
+
+	t.Run("Given a user with a valid email When creating new user Then normalized email", func(t *testing.T) {
+		gotUser, err := New(User{Nickname: nickname, Email: "Example@GMAIL.com", Password: password})
+		assert.NoError(t, err)
+		assert.Equal(t, "example@gmail.com", gotUser.Email)
+	})
 }
 
 func equalDateTime(t *testing.T, expected, got time.Time) bool {
@@ -154,3 +263,125 @@ func equalDateTime(t *testing.T, expected, got time.Time) bool {
 		expected.Format("200601021504") == got.Format("200601021504"),
 	)
 }
+
+func TestNicknameListsCheck(t *testing.T) {
+	t.Parallel()
+
+	l := NewNicknameLists([]string{"admin", "support"}, []string{"badword"})
+
+	tests := []struct {
+		name          string
+		nickname      string
+		allowReserved bool
+		wantErr       string
+	}{
+		{
+			name:     "Given a not listed nickname When checking nickname Then success",
+			nickname: "exampleuser",
+		},
+		{
+			name:     "Given a reserved name When checking nickname Then reserved nickname error",
+			nickname: "Admin",
+			wantErr:  "invalid nickname: Admin is reserved",
+		},
+		{
+			name:     "Given a nickname containing a reserved name When checking nickname Then reserved nickname error",
+			nickname: "the_support_team",
+			wantErr:  "invalid nickname: the_support_team is reserved",
+		},
+		{
+			name:     "Given a leetspeak variant of a reserved name When checking nickname Then reserved nickname error",
+			nickname: "Sup_p0rt",
+			wantErr:  "invalid nickname: Sup_p0rt is reserved",
+		},
+		{
+			name:          "Given a reserved name of a official account When checking nickname Then success",
+			nickname:      "support",
+			allowReserved: true,
+		},
+		{
+			name:          "Given a leetspeak variant of a blocked word of a official account When checking nickname Then blocked nickname error",
+			nickname:      "x_b4dw0rd_x",
+			allowReserved: true,
+			wantErr:       "invalid nickname: x_b4dw0rd_x contains a blocked word",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := l.Check(tt.nickname, tt.allowReserved)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadNicknameLists(t *testing.T) {
+	t.Run("Given nickname list files When loading and validating nicknames Then the listed nicknames are refused", func(t *testing.T) {
+		dir := t.TempDir()
+		reservedFile := filepath.Join(dir, "reserved.txt")
+		blockedFile := filepath.Join(dir, "blocked.txt")
+		assert.NoError(t, os.WriteFile(reservedFile, []byte("# official names\nsystem\n\n"), 0600))
+		assert.NoError(t, os.WriteFile(blockedFile, []byte("badword\n"), 0600))
+
+		l, err := LoadNicknameLists(reservedFile, blockedFile)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"system"}, l.Reserved)
+
+		SetNicknameLists(l)
+		defer SetNicknameLists(NicknameLists{})
+
+		assert.EqualError(t, ValidateNickname("Syst3m"), "invalid nickname: Syst3m is reserved")
+		assert.NoError(t, ValidateOfficialNickname("System"))
+		assert.EqualError(t, ValidateOfficialNickname("badword"), "invalid nickname: badword contains a blocked word")
+		assert.NoError(t, ValidateNickname(nickname))
+	})
+
+	t.Run("Given a missing list file When loading nickname lists Then error", func(t *testing.T) {
+		_, err := LoadNicknameLists(filepath.Join(t.TempDir(), "missing.txt"), "")
+		assert.Error(t, err)
+	})
+}
+
+func TestResolveIdentifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		identifier   string
+		wantEmail    string
+		wantNickname string
+	}{
+		{
+			name:       "Given a email identifier When resolving identifier Then normalized email",
+			identifier: " Bob@X.com ",
+			wantEmail:  "bob@x.com",
+		},
+		{
+			name:         "Given a nickname identifier When resolving identifier Then normalized nickname",
+			identifier:   "Ｂob",
+			wantNickname: "Bob",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			email, nickname, err := ResolveIdentifier(tt.identifier)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantEmail, email)
+			assert.Equal(t, tt.wantNickname, nickname)
+		})
+	}
+
+	t.Run("Given a empty identifier When resolving identifier Then invalid identifier error", func(t *testing.T) {
+		_, _, err := ResolveIdentifier("  ")
+		assert.EqualError(t, err, "invalid identifier: empty value")
+	})
+}